@@ -28,10 +28,14 @@ type SessionRepository struct {
 // NewSessionRepository はSessionRepositoryのポインタを生成する関数です
 func NewSessionRepository(dbMap *gorp.DbMap) *SessionRepository {
 	dbMap.AddTableWithName(sessionDTO{}, "sessions").SetKeys(false, "ID")
-	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks")
+	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks").SetKeys(true, "ID")
+	dbMap.AddTableWithName(queueTrackVoteDTO{}, "queue_track_votes").SetKeys(false, "QueueTrackID", "UserID")
+	dbMap.AddTableWithName(playedTrackDTO{}, "played_tracks").SetKeys(true, "ID")
 	return &SessionRepository{dbMap: dbMap}
 }
 
+const sessionColumns = "id, name, creator_id, queue_head, state_type, device_id, expired_at, allow_to_control_by_others, progress_when_paused, queue_mode, max_pending_tracks_per_user, enqueue_rate_limit, enqueue_rate_window, reject_duplicate_tracks, head_started_at, radio_mode, max_volume, device_fallback, repeat_queue, sleep_after_tracks, sleep_at, scheduled_start_at"
+
 // FindByID は指定されたIDを持つsessionをDBから取得します
 func (r *SessionRepository) FindByID(ctx context.Context, id string) (*entity.Session, error) {
 	dao, ok := getTx(ctx)
@@ -40,14 +44,14 @@ func (r *SessionRepository) FindByID(ctx context.Context, id string) (*entity.Se
 	}
 
 	var dto sessionDTO
-	if err := dao.SelectOne(&dto, "SELECT id, name, creator_id, queue_head, state_type, device_id, expired_at, allow_to_control_by_others, progress_when_paused FROM sessions WHERE id = ?", id); err != nil {
+	if err := dao.SelectOne(&dto, "SELECT "+sessionColumns+" FROM sessions WHERE id = ?", id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("select session: %w", entity.ErrSessionNotFound)
 		}
 		return nil, fmt.Errorf("select session: %w", err)
 	}
 
-	queueTracks, errOnGetQueue := r.getQueueTracksBySessionID(id)
+	queueTracks, errOnGetQueue := r.getQueueTracksBySessionID(ctx, id)
 	if errOnGetQueue != nil {
 		return nil, fmt.Errorf("get queue tracks: %w", errOnGetQueue)
 	}
@@ -57,7 +61,12 @@ func (r *SessionRepository) FindByID(ctx context.Context, id string) (*entity.Se
 		return nil, fmt.Errorf("find session: %w", entity.ErrInvalidStateType)
 	}
 
-	return r.dtoToSession(dto, stateType, queueTracks), nil
+	queueMode, err := entity.NewQueueMode(dto.QueueMode)
+	if err != nil {
+		return nil, fmt.Errorf("find session: %w", entity.ErrInvalidQueueMode)
+	}
+
+	return r.dtoToSession(dto, stateType, queueMode, queueTracks), nil
 }
 
 // FindByIDForUpdate は指定されたIDを持つsessionをDBから取得します
@@ -68,14 +77,14 @@ func (r *SessionRepository) FindByIDForUpdate(ctx context.Context, id string) (*
 	}
 
 	var dto sessionDTO
-	if err := dao.SelectOne(&dto, "SELECT id, name, creator_id, queue_head, state_type, device_id, expired_at, allow_to_control_by_others, progress_when_paused FROM sessions WHERE id = ? FOR UPDATE", id); err != nil {
+	if err := dao.SelectOne(&dto, "SELECT "+sessionColumns+" FROM sessions WHERE id = ? FOR UPDATE", id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, fmt.Errorf("select session: %w", entity.ErrSessionNotFound)
 		}
 		return nil, fmt.Errorf("select session: %w", err)
 	}
 
-	queueTracks, errOnGetQueue := r.getQueueTracksBySessionID(id)
+	queueTracks, errOnGetQueue := r.getQueueTracksBySessionID(ctx, id)
 	if errOnGetQueue != nil {
 		return nil, fmt.Errorf("get queue tracks: %w", errOnGetQueue)
 	}
@@ -85,7 +94,12 @@ func (r *SessionRepository) FindByIDForUpdate(ctx context.Context, id string) (*
 		return nil, fmt.Errorf("find session: %w", entity.ErrInvalidStateType)
 	}
 
-	return r.dtoToSession(dto, stateType, queueTracks), nil
+	queueMode, err := entity.NewQueueMode(dto.QueueMode)
+	if err != nil {
+		return nil, fmt.Errorf("find session: %w", entity.ErrInvalidQueueMode)
+	}
+
+	return r.dtoToSession(dto, stateType, queueMode, queueTracks), nil
 }
 
 // FindCreatorTokenBySessionID はSessionIDからCreatorのTokenを取得します
@@ -152,12 +166,125 @@ func (r *SessionRepository) StoreQueueTrack(ctx context.Context, queueTrack *ent
 		dao = r.dbMap
 	}
 
-	if _, err := dao.Exec("INSERT INTO queue_tracks(`index`, uri, session_id) SELECT COALESCE(MAX(`index`),-1)+1, ?, ? from queue_tracks as qt WHERE session_id = ?;", queueTrack.URI, queueTrack.SessionID, queueTrack.SessionID); err != nil {
+	if _, err := dao.Exec("INSERT INTO queue_tracks(`index`, uri, session_id, added_by_user_id, added_by_nickname, added_by_system, added_at) SELECT COALESCE(MAX(`index`),-1)+1, ?, ?, ?, ?, ?, ? from queue_tracks as qt WHERE session_id = ?;",
+		queueTrack.URI, queueTrack.SessionID, queueTrack.AddedByUserID, queueTrack.AddedByNickname, queueTrack.AddedBySystem, queueTrack.AddedAt, queueTrack.SessionID); err != nil {
 		return fmt.Errorf("insert queue_tracks: %w", err)
 	}
 	return nil
 }
 
+// DeleteQueueTrack はQueueTrackをDBから削除します。
+// 後ろのQueueTrackのindexは詰められないので、UpdateQueueTrackIndexesで更新する必要があります。
+func (r *SessionRepository) DeleteQueueTrack(ctx context.Context, queueTrack *entity.QueueTrack) error {
+	dao, ok := getTx(ctx)
+	if !ok {
+		dao = r.dbMap
+	}
+
+	if _, err := dao.Exec("DELETE FROM queue_tracks WHERE id = ?", queueTrack.ID); err != nil {
+		return fmt.Errorf("delete queue_tracks id=%d: %w", queueTrack.ID, err)
+	}
+	return nil
+}
+
+// UpdateQueueTrackIndexes は与えられたQueueTrackのindexをDBに反映します。
+// (session_id, index)のユニーク制約に途中で引っかからないように、一度負の値に退避させてから更新します。
+func (r *SessionRepository) UpdateQueueTrackIndexes(ctx context.Context, queueTracks []*entity.QueueTrack) error {
+	dao, ok := getTx(ctx)
+	if !ok {
+		dao = r.dbMap
+	}
+
+	for _, qt := range queueTracks {
+		if _, err := dao.Exec("UPDATE queue_tracks SET `index` = -1 - `index` WHERE id = ?", qt.ID); err != nil {
+			return fmt.Errorf("escape queue_tracks index id=%d: %w", qt.ID, err)
+		}
+	}
+
+	for _, qt := range queueTracks {
+		if _, err := dao.Exec("UPDATE queue_tracks SET `index` = ? WHERE id = ?", qt.Index, qt.ID); err != nil {
+			return fmt.Errorf("update queue_tracks index id=%d: %w", qt.ID, err)
+		}
+	}
+	return nil
+}
+
+// StoreQueueTrackVote はQueueTrackへの投票をDBに保存します。既に投票している場合は上書きします。
+func (r *SessionRepository) StoreQueueTrackVote(ctx context.Context, vote *entity.QueueTrackVote) error {
+	dao, ok := getTx(ctx)
+	if !ok {
+		dao = r.dbMap
+	}
+
+	if _, err := dao.Exec("INSERT INTO queue_track_votes(queue_track_id, user_id, value) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE value = VALUES(value)", vote.QueueTrackID, vote.UserID, vote.Value); err != nil {
+		return fmt.Errorf("insert queue_track_votes queue_track_id=%d user_id=%s: %w", vote.QueueTrackID, vote.UserID, err)
+	}
+	return nil
+}
+
+// DeleteQueueTrackVote はQueueTrackへの投票をDBから削除します。
+func (r *SessionRepository) DeleteQueueTrackVote(ctx context.Context, queueTrackID int64, userID string) error {
+	dao, ok := getTx(ctx)
+	if !ok {
+		dao = r.dbMap
+	}
+
+	if _, err := dao.Exec("DELETE FROM queue_track_votes WHERE queue_track_id = ? AND user_id = ?", queueTrackID, userID); err != nil {
+		return fmt.Errorf("delete queue_track_votes queue_track_id=%d user_id=%s: %w", queueTrackID, userID, err)
+	}
+	return nil
+}
+
+// StorePlayedTrack は再生された曲の履歴をDBに保存します。
+func (r *SessionRepository) StorePlayedTrack(ctx context.Context, playedTrack *entity.PlayedTrack) error {
+	dao, ok := getTx(ctx)
+	if !ok {
+		dao = r.dbMap
+	}
+
+	dto := &playedTrackDTO{
+		SessionID: playedTrack.SessionID,
+		URI:       playedTrack.URI,
+		StartedAt: playedTrack.StartedAt,
+		EndedAt:   playedTrack.EndedAt,
+		EndReason: playedTrack.EndReason.String(),
+	}
+	if err := dao.Insert(dto); err != nil {
+		return fmt.Errorf("insert played_tracks session_id=%s: %w", playedTrack.SessionID, err)
+	}
+	return nil
+}
+
+// FindPlayedTracksBySessionID は指定されたセッションで再生された曲の履歴を再生された順にDBから取得します。
+func (r *SessionRepository) FindPlayedTracksBySessionID(ctx context.Context, sessionID string) ([]*entity.PlayedTrack, error) {
+	dao, ok := getTx(ctx)
+	if !ok {
+		dao = r.dbMap
+	}
+
+	var dto []playedTrackDTO
+	if _, err := dao.Select(&dto, "SELECT * FROM played_tracks WHERE session_id = ? ORDER BY id ASC", sessionID); err != nil {
+		return nil, fmt.Errorf("select played_tracks session_id=%s: %w", sessionID, err)
+	}
+
+	playedTracks := make([]*entity.PlayedTrack, len(dto))
+	for i, d := range dto {
+		reason, err := entity.NewTrackEndReason(d.EndReason)
+		if err != nil {
+			return nil, fmt.Errorf("played_tracks id=%d: %w", d.ID, err)
+		}
+		playedTracks[i] = &entity.PlayedTrack{
+			ID:        d.ID,
+			SessionID: d.SessionID,
+			URI:       d.URI,
+			StartedAt: d.StartedAt,
+			EndedAt:   d.EndedAt,
+			EndReason: reason,
+		}
+	}
+	return playedTracks, nil
+}
+
 // ArchiveSessionsForBatch は以下の条件に当てはまるSessionのstateをArchivedに変更します
 //// - 作成から3日以上が経過している。もしくはArchiveが解除されてから3日以上が経過している
 func (r *SessionRepository) ArchiveSessionsForBatch() error {
@@ -168,22 +295,71 @@ func (r *SessionRepository) ArchiveSessionsForBatch() error {
 	return nil
 }
 
-func (r *SessionRepository) getQueueTracksBySessionID(id string) ([]*entity.QueueTrack, error) {
+// FindIDsByState は指定されたstateのsessionのIDを取得します
+func (r *SessionRepository) FindIDsByState(ctx context.Context, stateType entity.StateType) ([]string, error) {
+	dao, ok := getTx(ctx)
+	if !ok {
+		dao = r.dbMap
+	}
+
+	var ids []string
+	if _, err := dao.Select(&ids, "SELECT id FROM sessions WHERE state_type = ?", stateType.String()); err != nil {
+		return nil, fmt.Errorf("select session ids state_type=%s: %w", stateType, err)
+	}
+	return ids, nil
+}
+
+// FindIDsScheduledToStart は再生開始の予約日時がnow以前になっているsessionのIDを取得します
+func (r *SessionRepository) FindIDsScheduledToStart(ctx context.Context, now time.Time) ([]string, error) {
+	dao, ok := getTx(ctx)
+	if !ok {
+		dao = r.dbMap
+	}
+
+	var ids []string
+	if _, err := dao.Select(&ids, "SELECT id FROM sessions WHERE scheduled_start_at IS NOT NULL AND scheduled_start_at <= ? ORDER BY scheduled_start_at", now); err != nil {
+		return nil, fmt.Errorf("select session ids scheduled to start: %w", err)
+	}
+	return ids, nil
+}
+
+func (r *SessionRepository) getQueueTracksBySessionID(ctx context.Context, id string) ([]*entity.QueueTrack, error) {
+	dao, ok := getTx(ctx)
+	if !ok {
+		dao = r.dbMap
+	}
+
 	var dto []queueTrackDTO
-	if _, err := r.dbMap.Select(&dto, "SELECT * FROM queue_tracks WHERE session_id = ? ORDER BY `index` ASC", id); err != nil {
+	if _, err := dao.Select(&dto, "SELECT * FROM queue_tracks WHERE session_id = ? ORDER BY `index` ASC", id); err != nil {
 		return nil, fmt.Errorf("select queue_tracks: %w", err)
 	}
-	return r.toQueueTracks(dto), nil
+
+	var scoreDTO []queueTrackScoreDTO
+	if _, err := dao.Select(&scoreDTO, "SELECT v.queue_track_id, SUM(v.value) AS score FROM queue_track_votes AS v INNER JOIN queue_tracks AS qt ON qt.id = v.queue_track_id WHERE qt.session_id = ? GROUP BY v.queue_track_id", id); err != nil {
+		return nil, fmt.Errorf("select queue_track_votes: %w", err)
+	}
+	return r.toQueueTracks(dto, scoreDTO), nil
 }
 
-func (r *SessionRepository) toQueueTracks(resultQueueTracks []queueTrackDTO) []*entity.QueueTrack {
+func (r *SessionRepository) toQueueTracks(resultQueueTracks []queueTrackDTO, resultScores []queueTrackScoreDTO) []*entity.QueueTrack {
 	queueTracks := make([]*entity.QueueTrack, len(resultQueueTracks))
 
+	scores := make(map[int64]int, len(resultScores))
+	for _, rs := range resultScores {
+		scores[rs.QueueTrackID] = rs.Score
+	}
+
 	for i, rs := range resultQueueTracks {
 		queueTracks[i] = &entity.QueueTrack{
-			Index:     rs.Index,
-			URI:       rs.URI,
-			SessionID: rs.SessionID,
+			ID:              rs.ID,
+			Index:           rs.Index,
+			URI:             rs.URI,
+			SessionID:       rs.SessionID,
+			AddedByUserID:   rs.AddedByUserID,
+			AddedByNickname: rs.AddedByNickname,
+			AddedBySystem:   rs.AddedBySystem,
+			Score:           scores[rs.ID],
+			AddedAt:         rs.AddedAt,
 		}
 	}
 
@@ -211,49 +387,113 @@ func (r *SessionRepository) DoInTx(ctx context.Context, f func(ctx context.Conte
 	return v, nil
 }
 
-func (r *SessionRepository) dtoToSession(dto sessionDTO, stateType entity.StateType, queueTracks []*entity.QueueTrack) *entity.Session {
+func (r *SessionRepository) dtoToSession(dto sessionDTO, stateType entity.StateType, queueMode entity.QueueMode, queueTracks []*entity.QueueTrack) *entity.Session {
 	return &entity.Session{
-		ID:                     dto.ID,
-		Name:                   dto.Name,
-		CreatorID:              dto.CreatorID,
-		DeviceID:               dto.DeviceID,
-		StateType:              stateType,
-		QueueHead:              dto.QueueHead,
-		QueueTracks:            queueTracks,
-		ExpiredAt:              dto.ExpiredAt,
-		AllowToControlByOthers: dto.AllowToControlByOthers,
-		ProgressWhenPaused:     time.Duration(dto.ProgressWhenPaused) * time.Millisecond,
+		ID:                      dto.ID,
+		Name:                    dto.Name,
+		CreatorID:               dto.CreatorID,
+		DeviceID:                dto.DeviceID,
+		StateType:               stateType,
+		QueueHead:               dto.QueueHead,
+		QueueTracks:             queueTracks,
+		ExpiredAt:               dto.ExpiredAt,
+		AllowToControlByOthers:  dto.AllowToControlByOthers,
+		ProgressWhenPaused:      time.Duration(dto.ProgressWhenPaused) * time.Millisecond,
+		QueueMode:               queueMode,
+		MaxPendingTracksPerUser: dto.MaxPendingTracksPerUser,
+		EnqueueRateLimit:        dto.EnqueueRateLimit,
+		EnqueueRateWindow:       time.Duration(dto.EnqueueRateWindow) * time.Millisecond,
+		RejectDuplicateTracks:   dto.RejectDuplicateTracks,
+		HeadStartedAt:           dto.HeadStartedAt.Time,
+		RadioMode:               dto.RadioMode,
+		MaxVolume:               dto.MaxVolume,
+		DeviceFallback:          dto.DeviceFallback,
+		RepeatQueue:             dto.RepeatQueue,
+		SleepAfterTracks:        dto.SleepAfterTracks,
+		SleepAt:                 dto.SleepAt.Time,
+		ScheduledStartAt:        dto.ScheduledStartAt.Time,
 	}
 }
 
 func (r *SessionRepository) sessionToDTO(session *entity.Session) *sessionDTO {
 	return &sessionDTO{
-		ID:                     session.ID,
-		Name:                   session.Name,
-		CreatorID:              session.CreatorID,
-		QueueHead:              session.QueueHead,
-		StateType:              session.StateType.String(),
-		DeviceID:               session.DeviceID,
-		ExpiredAt:              session.ExpiredAt,
-		AllowToControlByOthers: session.AllowToControlByOthers,
-		ProgressWhenPaused:     session.ProgressWhenPaused.Milliseconds(),
+		ID:                      session.ID,
+		Name:                    session.Name,
+		CreatorID:               session.CreatorID,
+		QueueHead:               session.QueueHead,
+		StateType:               session.StateType.String(),
+		DeviceID:                session.DeviceID,
+		ExpiredAt:               session.ExpiredAt,
+		AllowToControlByOthers:  session.AllowToControlByOthers,
+		ProgressWhenPaused:      session.ProgressWhenPaused.Milliseconds(),
+		QueueMode:               session.QueueMode.String(),
+		MaxPendingTracksPerUser: session.MaxPendingTracksPerUser,
+		EnqueueRateLimit:        session.EnqueueRateLimit,
+		EnqueueRateWindow:       session.EnqueueRateWindow.Milliseconds(),
+		RejectDuplicateTracks:   session.RejectDuplicateTracks,
+		HeadStartedAt:           sql.NullTime{Time: session.HeadStartedAt, Valid: !session.HeadStartedAt.IsZero()},
+		RadioMode:               session.RadioMode,
+		MaxVolume:               session.MaxVolume,
+		DeviceFallback:          session.DeviceFallback,
+		RepeatQueue:             session.RepeatQueue,
+		SleepAfterTracks:        session.SleepAfterTracks,
+		SleepAt:                 sql.NullTime{Time: session.SleepAt, Valid: !session.SleepAt.IsZero()},
+		ScheduledStartAt:        sql.NullTime{Time: session.ScheduledStartAt, Valid: !session.ScheduledStartAt.IsZero()},
 	}
 }
 
 type sessionDTO struct {
-	ID                     string    `db:"id"`
-	Name                   string    `db:"name"`
-	CreatorID              string    `db:"creator_id"`
-	QueueHead              int       `db:"queue_head"`
-	StateType              string    `db:"state_type"`
-	DeviceID               string    `db:"device_id"`
-	ExpiredAt              time.Time `db:"expired_at"`
-	AllowToControlByOthers bool      `db:"allow_to_control_by_others"`
-	ProgressWhenPaused     int64     `db:"progress_when_paused"`
+	ID                      string       `db:"id"`
+	Name                    string       `db:"name"`
+	CreatorID               string       `db:"creator_id"`
+	QueueHead               int          `db:"queue_head"`
+	StateType               string       `db:"state_type"`
+	DeviceID                string       `db:"device_id"`
+	ExpiredAt               time.Time    `db:"expired_at"`
+	AllowToControlByOthers  bool         `db:"allow_to_control_by_others"`
+	ProgressWhenPaused      int64        `db:"progress_when_paused"`
+	QueueMode               string       `db:"queue_mode"`
+	MaxPendingTracksPerUser int          `db:"max_pending_tracks_per_user"`
+	EnqueueRateLimit        int          `db:"enqueue_rate_limit"`
+	EnqueueRateWindow       int64        `db:"enqueue_rate_window"`
+	RejectDuplicateTracks   bool         `db:"reject_duplicate_tracks"`
+	HeadStartedAt           sql.NullTime `db:"head_started_at"`
+	RadioMode               bool         `db:"radio_mode"`
+	MaxVolume               int          `db:"max_volume"`
+	DeviceFallback          bool         `db:"device_fallback"`
+	RepeatQueue             bool         `db:"repeat_queue"`
+	SleepAfterTracks        int          `db:"sleep_after_tracks"`
+	SleepAt                 sql.NullTime `db:"sleep_at"`
+	ScheduledStartAt        sql.NullTime `db:"scheduled_start_at"`
 }
 
 type queueTrackDTO struct {
-	Index     int    `db:"index"`
-	URI       string `db:"uri"`
-	SessionID string `db:"session_id"`
+	ID              int64     `db:"id"`
+	Index           int       `db:"index"`
+	URI             string    `db:"uri"`
+	SessionID       string    `db:"session_id"`
+	AddedByUserID   string    `db:"added_by_user_id"`
+	AddedByNickname string    `db:"added_by_nickname"`
+	AddedBySystem   bool      `db:"added_by_system"`
+	AddedAt         time.Time `db:"added_at"`
+}
+
+type queueTrackVoteDTO struct {
+	QueueTrackID int64  `db:"queue_track_id"`
+	UserID       string `db:"user_id"`
+	Value        int    `db:"value"`
+}
+
+type playedTrackDTO struct {
+	ID        int64     `db:"id"`
+	SessionID string    `db:"session_id"`
+	URI       string    `db:"uri"`
+	StartedAt time.Time `db:"started_at"`
+	EndedAt   time.Time `db:"ended_at"`
+	EndReason string    `db:"end_reason"`
+}
+
+type queueTrackScoreDTO struct {
+	QueueTrackID int64 `db:"queue_track_id"`
+	Score        int   `db:"score"`
 }
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"errors"
 	"testing"
 	"time"
@@ -22,6 +23,7 @@ func TestSessionRepository_FindByID(t *testing.T) {
 	dbMap.AddTableWithName(sessionDTO{}, "sessions")
 	dbMap.AddTableWithName(userDTO{}, "users")
 	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks")
+	dbMap.AddTableWithName(queueTrackVoteDTO{}, "queue_track_votes")
 	truncateTable(t, dbMap)
 	user := &userDTO{
 		ID:            "existing_user",
@@ -38,8 +40,10 @@ func TestSessionRepository_FindByID(t *testing.T) {
 		ExpiredAt:              time.Date(2020, time.December, 1, 12, 0, 0, 0, time.UTC),
 		AllowToControlByOthers: true,
 		ProgressWhenPaused:     1 * time.Second.Milliseconds(),
+		QueueMode:              "FIFO",
 	}
 	queueTrack := &queueTrackDTO{
+		ID:        1,
 		Index:     0,
 		URI:       "existing_uri",
 		SessionID: "existing_session_id",
@@ -66,6 +70,7 @@ func TestSessionRepository_FindByID(t *testing.T) {
 				QueueHead: 0,
 				QueueTracks: []*entity.QueueTrack{
 					{
+						ID:        1,
 						Index:     0,
 						URI:       "existing_uri",
 						SessionID: "existing_session_id",
@@ -74,6 +79,7 @@ func TestSessionRepository_FindByID(t *testing.T) {
 				ExpiredAt:              time.Date(2020, time.December, 1, 12, 0, 0, 0, time.UTC),
 				AllowToControlByOthers: true,
 				ProgressWhenPaused:     1 * time.Second,
+				QueueMode:              entity.QueueModeFIFO,
 			},
 			wantErr: nil,
 		},
@@ -109,6 +115,7 @@ func TestSessionRepository_FindByIDForUpdate(t *testing.T) {
 	dbMap.AddTableWithName(sessionDTO{}, "sessions")
 	dbMap.AddTableWithName(userDTO{}, "users")
 	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks")
+	dbMap.AddTableWithName(queueTrackVoteDTO{}, "queue_track_votes")
 	truncateTable(t, dbMap)
 	user := &userDTO{
 		ID:            "existing_user",
@@ -125,8 +132,10 @@ func TestSessionRepository_FindByIDForUpdate(t *testing.T) {
 		ExpiredAt:              time.Date(2020, time.December, 1, 12, 0, 0, 0, time.UTC),
 		AllowToControlByOthers: true,
 		ProgressWhenPaused:     1 * time.Second.Milliseconds(),
+		QueueMode:              "FIFO",
 	}
 	queueTrack := &queueTrackDTO{
+		ID:        1,
 		Index:     0,
 		URI:       "existing_uri",
 		SessionID: "existing_session_id",
@@ -153,6 +162,7 @@ func TestSessionRepository_FindByIDForUpdate(t *testing.T) {
 				QueueHead: 0,
 				QueueTracks: []*entity.QueueTrack{
 					{
+						ID:        1,
 						Index:     0,
 						URI:       "existing_uri",
 						SessionID: "existing_session_id",
@@ -161,6 +171,7 @@ func TestSessionRepository_FindByIDForUpdate(t *testing.T) {
 				ExpiredAt:              time.Date(2020, time.December, 1, 12, 0, 0, 0, time.UTC),
 				AllowToControlByOthers: true,
 				ProgressWhenPaused:     1 * time.Second,
+				QueueMode:              entity.QueueModeFIFO,
 			},
 			wantErr: nil,
 		},
@@ -212,6 +223,7 @@ func TestSessionRepository_StoreSession(t *testing.T) {
 		ExpiredAt:              time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
 		AllowToControlByOthers: true,
 		ProgressWhenPaused:     (1 * time.Second).Milliseconds(),
+		QueueMode:              "FIFO",
 	}
 	if err := dbMap.Insert(user, session); err != nil {
 		t.Fatal(err)
@@ -235,6 +247,7 @@ func TestSessionRepository_StoreSession(t *testing.T) {
 				ExpiredAt:              time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
 				AllowToControlByOthers: true,
 				ProgressWhenPaused:     1 * time.Second,
+				QueueMode:              entity.QueueModeFIFO,
 			},
 			wantErr: nil,
 		},
@@ -251,6 +264,7 @@ func TestSessionRepository_StoreSession(t *testing.T) {
 				ExpiredAt:              time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC),
 				AllowToControlByOthers: true,
 				ProgressWhenPaused:     1 * time.Second,
+				QueueMode:              entity.QueueModeFIFO,
 			},
 			wantErr: entity.ErrSessionAlreadyExisted,
 		},
@@ -276,6 +290,7 @@ func TestSessionRepository_Update(t *testing.T) {
 	dbMap.AddTableWithName(sessionDTO{}, "sessions")
 	dbMap.AddTableWithName(userDTO{}, "users")
 	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks")
+	dbMap.AddTableWithName(queueTrackVoteDTO{}, "queue_track_votes")
 	truncateTable(t, dbMap)
 	user := &userDTO{
 		ID:            "existing_user",
@@ -292,6 +307,7 @@ func TestSessionRepository_Update(t *testing.T) {
 		ExpiredAt:              time.Date(2020, time.December, 1, 12, 0, 0, 0, time.UTC),
 		AllowToControlByOthers: false,
 		ProgressWhenPaused:     1 * time.Second.Milliseconds(),
+		QueueMode:              "FIFO",
 	}
 	sameFieldSession := &sessionDTO{
 		ID:                     "same_field_session_id",
@@ -302,6 +318,7 @@ func TestSessionRepository_Update(t *testing.T) {
 		ExpiredAt:              time.Date(2020, time.December, 1, 12, 0, 0, 0, time.UTC),
 		AllowToControlByOthers: true,
 		ProgressWhenPaused:     1 * time.Second.Milliseconds(),
+		QueueMode:              "FIFO",
 	}
 	if err := dbMap.Insert(user, session, sameFieldSession); err != nil {
 		t.Fatal(err)
@@ -325,6 +342,7 @@ func TestSessionRepository_Update(t *testing.T) {
 				ExpiredAt:              time.Date(2020, time.December, 1, 12, 0, 0, 0, time.UTC),
 				AllowToControlByOthers: true,
 				ProgressWhenPaused:     2 * time.Second,
+				QueueMode:              entity.QueueModeFIFO,
 			},
 			wantErr: false,
 		},
@@ -340,6 +358,7 @@ func TestSessionRepository_Update(t *testing.T) {
 				ExpiredAt:              time.Date(2020, time.December, 1, 12, 0, 0, 0, time.UTC),
 				AllowToControlByOthers: true,
 				ProgressWhenPaused:     1 * time.Second,
+				QueueMode:              entity.QueueModeFIFO,
 			},
 			wantErr: false,
 		},
@@ -376,6 +395,7 @@ func TestSessionRepository_StoreQueueTrack(t *testing.T) {
 	dbMap.AddTableWithName(userDTO{}, "users")
 	dbMap.AddTableWithName(sessionDTO{}, "sessions")
 	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks")
+	dbMap.AddTableWithName(queueTrackVoteDTO{}, "queue_track_votes")
 	truncateTable(t, dbMap)
 	user := &userDTO{
 		ID:            "existing_user",
@@ -390,6 +410,7 @@ func TestSessionRepository_StoreQueueTrack(t *testing.T) {
 		StateType:              "PLAY",
 		ExpiredAt:              time.Now(),
 		AllowToControlByOthers: true,
+		QueueMode:              "FIFO",
 	}
 	sessionHasNoQueueTrack := &sessionDTO{
 		ID:                     "session_with_no_queue_track_id",
@@ -399,6 +420,7 @@ func TestSessionRepository_StoreQueueTrack(t *testing.T) {
 		StateType:              "PLAY",
 		ExpiredAt:              time.Now(),
 		AllowToControlByOthers: true,
+		QueueMode:              "FIFO",
 	}
 	queueTracks := &queueTrackDTO{
 		Index:     0,
@@ -427,8 +449,10 @@ func TestSessionRepository_StoreQueueTrack(t *testing.T) {
 		{
 			name: "ひも付いているqueue_tracksが1つも存在しないsessionsに新規queue_tracksを正しく紐づけて保存できる",
 			queueTrack: &entity.QueueTrackToStore{
-				URI:       "new_uri",
-				SessionID: "session_with_no_queue_track_id",
+				URI:             "new_uri",
+				SessionID:       "session_with_no_queue_track_id",
+				AddedByUserID:   "existing_user",
+				AddedByNickname: "existing_user_display_name",
 			},
 			wantIndex: 0,
 			wantErr:   nil,
@@ -445,10 +469,11 @@ func TestSessionRepository_StoreQueueTrack(t *testing.T) {
 			}
 
 			if tt.wantErr == nil {
-				queueTracks, _ := r.getQueueTracksBySessionID(tt.queueTrack.SessionID)
+				queueTracks, _ := r.getQueueTracksBySessionID(context.TODO(), tt.queueTrack.SessionID)
 				queueTrack, notFound := findQueueTrackByIndexAndSessionID(queueTracks, tt.wantIndex, tt.queueTrack.SessionID)
 
-				if (notFound != nil) || (queueTrack.URI != tt.queueTrack.URI) {
+				if (notFound != nil) || (queueTrack.URI != tt.queueTrack.URI) ||
+					(queueTrack.AddedByUserID != tt.queueTrack.AddedByUserID) || (queueTrack.AddedByNickname != tt.queueTrack.AddedByNickname) {
 					t.Errorf("SessionRepository.StoreQueueTrack() queue_track not found. wantIndex %v, wantSessionID %v", tt.wantIndex, tt.queueTrack.SessionID)
 				}
 			}
@@ -456,6 +481,560 @@ func TestSessionRepository_StoreQueueTrack(t *testing.T) {
 	}
 }
 
+func TestSessionRepository_DeleteQueueTrack(t *testing.T) {
+	// Prepare
+	dbMap, err := NewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbMap.AddTableWithName(userDTO{}, "users")
+	dbMap.AddTableWithName(sessionDTO{}, "sessions")
+	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks")
+	dbMap.AddTableWithName(queueTrackVoteDTO{}, "queue_track_votes")
+	truncateTable(t, dbMap)
+	user := &userDTO{
+		ID:            "existing_user",
+		SpotifyUserID: "existing_user_spotify",
+		DisplayName:   "existing_user_display_name",
+	}
+	session := &sessionDTO{
+		ID:        "existing_session_id",
+		Name:      "existing_session_name",
+		CreatorID: "existing_user",
+		QueueHead: 0,
+		StateType: "PLAY",
+		ExpiredAt: time.Now(),
+		QueueMode: "FIFO",
+	}
+	queueTrack1 := &queueTrackDTO{
+		ID:        1,
+		Index:     0,
+		URI:       "existing_uri1",
+		SessionID: "existing_session_id",
+	}
+	queueTrack2 := &queueTrackDTO{
+		ID:        2,
+		Index:     1,
+		URI:       "existing_uri2",
+		SessionID: "existing_session_id",
+	}
+	if err := dbMap.Insert(user, session, queueTrack1, queueTrack2); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		queueTrack *entity.QueueTrack
+		want       []*entity.QueueTrack
+		wantErr    bool
+	}{
+		{
+			name: "指定したqueue_trackを削除できる",
+			queueTrack: &entity.QueueTrack{
+				ID:        2,
+				Index:     1,
+				URI:       "existing_uri2",
+				SessionID: "existing_session_id",
+			},
+			want: []*entity.QueueTrack{
+				{
+					ID:        1,
+					Index:     0,
+					URI:       "existing_uri1",
+					SessionID: "existing_session_id",
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SessionRepository{
+				dbMap: dbMap,
+			}
+			if err := r.DeleteQueueTrack(context.TODO(), tt.queueTrack); (err != nil) != tt.wantErr {
+				t.Errorf("SessionRepository.DeleteQueueTrack() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			got, err := r.getQueueTracksBySessionID(context.TODO(), tt.queueTrack.SessionID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("SessionRepository.DeleteQueueTrack() diff = %v", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func TestSessionRepository_UpdateQueueTrackIndexes(t *testing.T) {
+	// Prepare
+	dbMap, err := NewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbMap.AddTableWithName(userDTO{}, "users")
+	dbMap.AddTableWithName(sessionDTO{}, "sessions")
+	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks")
+	dbMap.AddTableWithName(queueTrackVoteDTO{}, "queue_track_votes")
+	truncateTable(t, dbMap)
+	user := &userDTO{
+		ID:            "existing_user",
+		SpotifyUserID: "existing_user_spotify",
+		DisplayName:   "existing_user_display_name",
+	}
+	session := &sessionDTO{
+		ID:        "existing_session_id",
+		Name:      "existing_session_name",
+		CreatorID: "existing_user",
+		QueueHead: 0,
+		StateType: "PLAY",
+		ExpiredAt: time.Now(),
+		QueueMode: "FIFO",
+	}
+	queueTrack1 := &queueTrackDTO{
+		ID:        1,
+		Index:     0,
+		URI:       "existing_uri1",
+		SessionID: "existing_session_id",
+	}
+	queueTrack2 := &queueTrackDTO{
+		ID:        2,
+		Index:     1,
+		URI:       "existing_uri2",
+		SessionID: "existing_session_id",
+	}
+	queueTrack3 := &queueTrackDTO{
+		ID:        3,
+		Index:     2,
+		URI:       "existing_uri3",
+		SessionID: "existing_session_id",
+	}
+	if err := dbMap.Insert(user, session, queueTrack1, queueTrack2, queueTrack3); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		sessionID   string
+		queueTracks []*entity.QueueTrack
+		want        []*entity.QueueTrack
+		wantErr     bool
+	}{
+		{
+			name:      "queue_tracksのindexを入れ替えられる",
+			sessionID: "existing_session_id",
+			queueTracks: []*entity.QueueTrack{
+				{
+					ID:        3,
+					Index:     1,
+					URI:       "existing_uri3",
+					SessionID: "existing_session_id",
+				},
+				{
+					ID:        2,
+					Index:     2,
+					URI:       "existing_uri2",
+					SessionID: "existing_session_id",
+				},
+			},
+			want: []*entity.QueueTrack{
+				{
+					ID:        1,
+					Index:     0,
+					URI:       "existing_uri1",
+					SessionID: "existing_session_id",
+				},
+				{
+					ID:        3,
+					Index:     1,
+					URI:       "existing_uri3",
+					SessionID: "existing_session_id",
+				},
+				{
+					ID:        2,
+					Index:     2,
+					URI:       "existing_uri2",
+					SessionID: "existing_session_id",
+				},
+			},
+			wantErr: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SessionRepository{
+				dbMap: dbMap,
+			}
+			if err := r.UpdateQueueTrackIndexes(context.TODO(), tt.queueTracks); (err != nil) != tt.wantErr {
+				t.Errorf("SessionRepository.UpdateQueueTrackIndexes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			got, err := r.getQueueTracksBySessionID(context.TODO(), tt.sessionID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("SessionRepository.UpdateQueueTrackIndexes() diff = %v", cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
+
+func TestSessionRepository_StoreQueueTrackVote(t *testing.T) {
+	// Prepare
+	dbMap, err := NewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbMap.AddTableWithName(userDTO{}, "users")
+	dbMap.AddTableWithName(sessionDTO{}, "sessions")
+	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks")
+	dbMap.AddTableWithName(queueTrackVoteDTO{}, "queue_track_votes")
+	truncateTable(t, dbMap)
+	user1 := &userDTO{
+		ID:            "existing_user1",
+		SpotifyUserID: "existing_user_spotify1",
+		DisplayName:   "existing_user_display_name1",
+	}
+	user2 := &userDTO{
+		ID:            "existing_user2",
+		SpotifyUserID: "existing_user_spotify2",
+		DisplayName:   "existing_user_display_name2",
+	}
+	session := &sessionDTO{
+		ID:        "existing_session_id",
+		Name:      "existing_session_name",
+		CreatorID: "existing_user1",
+		QueueHead: 0,
+		StateType: "STOP",
+		ExpiredAt: time.Now(),
+		QueueMode: "VOTE",
+	}
+	queueTrack := &queueTrackDTO{
+		ID:        1,
+		Index:     0,
+		URI:       "existing_uri",
+		SessionID: "existing_session_id",
+	}
+	vote := &queueTrackVoteDTO{
+		QueueTrackID: 1,
+		UserID:       "existing_user2",
+		Value:        1,
+	}
+	if err := dbMap.Insert(user1, user2, session, queueTrack, vote); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		vote      *entity.QueueTrackVote
+		wantScore int
+		wantErr   bool
+	}{
+		{
+			name: "まだ投票していないユーザの投票が保存されスコアに反映される",
+			vote: &entity.QueueTrackVote{
+				QueueTrackID: 1,
+				UserID:       "existing_user1",
+				Value:        1,
+			},
+			wantScore: 2,
+			wantErr:   false,
+		},
+		{
+			name: "既に投票しているユーザの投票は上書きされる",
+			vote: &entity.QueueTrackVote{
+				QueueTrackID: 1,
+				UserID:       "existing_user2",
+				Value:        -1,
+			},
+			wantScore: 0,
+			wantErr:   false,
+		},
+		{
+			name: "存在しないqueue_trackへの投票はエラー",
+			vote: &entity.QueueTrackVote{
+				QueueTrackID: 2,
+				UserID:       "existing_user1",
+				Value:        1,
+			},
+			wantScore: 0,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SessionRepository{
+				dbMap: dbMap,
+			}
+			if err := r.StoreQueueTrackVote(context.TODO(), tt.vote); (err != nil) != tt.wantErr {
+				t.Errorf("SessionRepository.StoreQueueTrackVote() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			got, err := r.getQueueTracksBySessionID(context.TODO(), "existing_session_id")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got[0].Score != tt.wantScore {
+				t.Errorf("SessionRepository.StoreQueueTrackVote() score = %d, want %d", got[0].Score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestSessionRepository_DeleteQueueTrackVote(t *testing.T) {
+	// Prepare
+	dbMap, err := NewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbMap.AddTableWithName(userDTO{}, "users")
+	dbMap.AddTableWithName(sessionDTO{}, "sessions")
+	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks")
+	dbMap.AddTableWithName(queueTrackVoteDTO{}, "queue_track_votes")
+	truncateTable(t, dbMap)
+	user := &userDTO{
+		ID:            "existing_user",
+		SpotifyUserID: "existing_user_spotify",
+		DisplayName:   "existing_user_display_name",
+	}
+	session := &sessionDTO{
+		ID:        "existing_session_id",
+		Name:      "existing_session_name",
+		CreatorID: "existing_user",
+		QueueHead: 0,
+		StateType: "STOP",
+		ExpiredAt: time.Now(),
+		QueueMode: "VOTE",
+	}
+	queueTrack := &queueTrackDTO{
+		ID:        1,
+		Index:     0,
+		URI:       "existing_uri",
+		SessionID: "existing_session_id",
+	}
+	vote := &queueTrackVoteDTO{
+		QueueTrackID: 1,
+		UserID:       "existing_user",
+		Value:        -1,
+	}
+	if err := dbMap.Insert(user, session, queueTrack, vote); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		queueTrackID int64
+		userID       string
+		wantScore    int
+		wantErr      bool
+	}{
+		{
+			name:         "投票していないユーザの投票を削除しても何も起きない",
+			queueTrackID: 1,
+			userID:       "not_voted_user",
+			wantScore:    -1,
+			wantErr:      false,
+		},
+		{
+			name:         "投票が削除されスコアに反映される",
+			queueTrackID: 1,
+			userID:       "existing_user",
+			wantScore:    0,
+			wantErr:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SessionRepository{
+				dbMap: dbMap,
+			}
+			if err := r.DeleteQueueTrackVote(context.TODO(), tt.queueTrackID, tt.userID); (err != nil) != tt.wantErr {
+				t.Errorf("SessionRepository.DeleteQueueTrackVote() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+
+			got, err := r.getQueueTracksBySessionID(context.TODO(), "existing_session_id")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got[0].Score != tt.wantScore {
+				t.Errorf("SessionRepository.DeleteQueueTrackVote() score = %d, want %d", got[0].Score, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestSessionRepository_StorePlayedTrack(t *testing.T) {
+	// Prepare
+	dbMap, err := NewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbMap.AddTableWithName(userDTO{}, "users")
+	dbMap.AddTableWithName(sessionDTO{}, "sessions")
+	dbMap.AddTableWithName(playedTrackDTO{}, "played_tracks").SetKeys(true, "ID")
+	truncateTable(t, dbMap)
+	user := &userDTO{
+		ID:            "existing_user",
+		SpotifyUserID: "existing_user_spotify",
+		DisplayName:   "existing_user_display_name",
+	}
+	session := &sessionDTO{
+		ID:        "existing_session_id",
+		Name:      "existing_session_name",
+		CreatorID: "existing_user",
+		QueueHead: 0,
+		StateType: "PLAY",
+		ExpiredAt: time.Now(),
+		QueueMode: "FIFO",
+	}
+	if err := dbMap.Insert(user, session); err != nil {
+		t.Fatal(err)
+	}
+
+	startedAt := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	endedAt := time.Date(2020, 1, 1, 12, 3, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		playedTrack *entity.PlayedTrack
+		wantErr     bool
+	}{
+		{
+			name: "再生された曲の履歴を保存できる",
+			playedTrack: &entity.PlayedTrack{
+				SessionID: "existing_session_id",
+				URI:       "spotify:track:played",
+				StartedAt: startedAt,
+				EndedAt:   endedAt,
+				EndReason: entity.TrackEndReasonSkipped,
+			},
+			wantErr: false,
+		},
+		{
+			name: "存在しないセッションの履歴はエラー",
+			playedTrack: &entity.PlayedTrack{
+				SessionID: "not_found_session_id",
+				URI:       "spotify:track:played",
+				StartedAt: startedAt,
+				EndedAt:   endedAt,
+				EndReason: entity.TrackEndReasonFinished,
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SessionRepository{
+				dbMap: dbMap,
+			}
+			if err := r.StorePlayedTrack(context.TODO(), tt.playedTrack); (err != nil) != tt.wantErr {
+				t.Errorf("SessionRepository.StorePlayedTrack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSessionRepository_FindPlayedTracksBySessionID(t *testing.T) {
+	// Prepare
+	dbMap, err := NewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbMap.AddTableWithName(userDTO{}, "users")
+	dbMap.AddTableWithName(sessionDTO{}, "sessions")
+	dbMap.AddTableWithName(playedTrackDTO{}, "played_tracks").SetKeys(true, "ID")
+	truncateTable(t, dbMap)
+	user := &userDTO{
+		ID:            "existing_user",
+		SpotifyUserID: "existing_user_spotify",
+		DisplayName:   "existing_user_display_name",
+	}
+	session := &sessionDTO{
+		ID:        "existing_session_id",
+		Name:      "existing_session_name",
+		CreatorID: "existing_user",
+		QueueHead: 2,
+		StateType: "STOP",
+		ExpiredAt: time.Now(),
+		QueueMode: "FIFO",
+	}
+	startedAt := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	played1 := &playedTrackDTO{
+		SessionID: "existing_session_id",
+		URI:       "spotify:track:played1",
+		StartedAt: startedAt,
+		EndedAt:   startedAt.Add(3 * time.Minute),
+		EndReason: "FINISHED",
+	}
+	played2 := &playedTrackDTO{
+		SessionID: "existing_session_id",
+		URI:       "spotify:track:played2",
+		StartedAt: startedAt.Add(3 * time.Minute),
+		EndedAt:   startedAt.Add(4 * time.Minute),
+		EndReason: "INTERRUPTED",
+	}
+	if err := dbMap.Insert(user, session, played1, played2); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		sessionID string
+		want      []*entity.PlayedTrack
+		wantErr   bool
+	}{
+		{
+			name:      "再生された順に履歴を取得できる",
+			sessionID: "existing_session_id",
+			want: []*entity.PlayedTrack{
+				{
+					SessionID: "existing_session_id",
+					URI:       "spotify:track:played1",
+					StartedAt: startedAt,
+					EndedAt:   startedAt.Add(3 * time.Minute),
+					EndReason: entity.TrackEndReasonFinished,
+				},
+				{
+					SessionID: "existing_session_id",
+					URI:       "spotify:track:played2",
+					StartedAt: startedAt.Add(3 * time.Minute),
+					EndedAt:   startedAt.Add(4 * time.Minute),
+					EndReason: entity.TrackEndReasonInterrupted,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name:      "履歴が無いときは空",
+			sessionID: "not_played_session_id",
+			want:      []*entity.PlayedTrack{},
+			wantErr:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SessionRepository{
+				dbMap: dbMap,
+			}
+			got, err := r.FindPlayedTracksBySessionID(context.TODO(), tt.sessionID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SessionRepository.FindPlayedTracksBySessionID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			opt := cmpopts.IgnoreFields(entity.PlayedTrack{}, "ID")
+			if !cmp.Equal(got, tt.want, opt) {
+				t.Errorf("SessionRepository.FindPlayedTracksBySessionID() diff = %v", cmp.Diff(got, tt.want, opt))
+			}
+		})
+	}
+}
+
 func TestSessionRepository_getQueueTrackBySessionID(t *testing.T) {
 	// Prepare
 	dbMap, err := NewDB()
@@ -465,6 +1044,7 @@ func TestSessionRepository_getQueueTrackBySessionID(t *testing.T) {
 	dbMap.AddTableWithName(userDTO{}, "users")
 	dbMap.AddTableWithName(sessionDTO{}, "sessions")
 	dbMap.AddTableWithName(queueTrackDTO{}, "queue_tracks")
+	dbMap.AddTableWithName(queueTrackVoteDTO{}, "queue_track_votes")
 	truncateTable(t, dbMap)
 	user := &userDTO{
 		ID:            "existing_user",
@@ -479,6 +1059,7 @@ func TestSessionRepository_getQueueTrackBySessionID(t *testing.T) {
 		QueueHead: 0,
 		StateType: "PLAY",
 		ExpiredAt: time.Now(),
+		QueueMode: "FIFO",
 	}
 	sessionHasManyQueueTracks := &sessionDTO{
 		ID:        "session_has_many_queue_tracks_id",
@@ -487,24 +1068,29 @@ func TestSessionRepository_getQueueTrackBySessionID(t *testing.T) {
 		QueueHead: 0,
 		StateType: "PLAY",
 		ExpiredAt: time.Now(),
+		QueueMode: "FIFO",
 	}
 
 	queueTrack1 := &queueTrackDTO{
+		ID:        1,
 		Index:     0,
 		URI:       "existing_uri1",
 		SessionID: "existing_session_id",
 	}
 	queueTrack2 := &queueTrackDTO{
+		ID:        2,
 		Index:     0,
 		URI:       "existing_uri2",
 		SessionID: "session_has_many_queue_tracks_id",
 	}
 	queueTrack3 := &queueTrackDTO{
+		ID:        3,
 		Index:     1,
 		URI:       "existing_uri3",
 		SessionID: "session_has_many_queue_tracks_id",
 	}
 	queueTrack4 := &queueTrackDTO{
+		ID:        4,
 		Index:     2,
 		URI:       "existing_uri4",
 		SessionID: "session_has_many_queue_tracks_id",
@@ -524,6 +1110,7 @@ func TestSessionRepository_getQueueTrackBySessionID(t *testing.T) {
 			id:   "existing_session_id",
 			want: []*entity.QueueTrack{
 				{
+					ID:        1,
 					Index:     0,
 					URI:       "existing_uri1",
 					SessionID: "existing_session_id",
@@ -536,16 +1123,19 @@ func TestSessionRepository_getQueueTrackBySessionID(t *testing.T) {
 			id:   "session_has_many_queue_tracks_id",
 			want: []*entity.QueueTrack{
 				{
+					ID:        2,
 					Index:     0,
 					URI:       "existing_uri2",
 					SessionID: "session_has_many_queue_tracks_id",
 				},
 				{
+					ID:        3,
 					Index:     1,
 					URI:       "existing_uri3",
 					SessionID: "session_has_many_queue_tracks_id",
 				},
 				{
+					ID:        4,
 					Index:     2,
 					URI:       "existing_uri4",
 					SessionID: "session_has_many_queue_tracks_id",
@@ -565,7 +1155,7 @@ func TestSessionRepository_getQueueTrackBySessionID(t *testing.T) {
 			r := &SessionRepository{
 				dbMap: dbMap,
 			}
-			queueTracks, err := r.getQueueTracksBySessionID(tt.id)
+			queueTracks, err := r.getQueueTracksBySessionID(context.TODO(), tt.id)
 
 			if !errors.Is(err, tt.wantErr) {
 				t.Errorf("SessionRepository.getQueueTrackBySessionID() error = %v, wantErr %v", err, tt.wantErr)
@@ -616,6 +1206,7 @@ func TestSessionRepository_FindCreatorTokenBySessionID(t *testing.T) {
 		StateType: "STOP",
 		DeviceID:  "device_id",
 		ExpiredAt: time.Now(),
+		QueueMode: "FIFO",
 	}); err != nil {
 		t.Fatal(err)
 	}
@@ -693,6 +1284,7 @@ func TestSessionRepository_ArchiveSessionsForBatch(t *testing.T) {
 		DeviceID:               "device_id",
 		ExpiredAt:              time.Now().Add(-1 * 24 * time.Hour),
 		AllowToControlByOthers: true,
+		QueueMode:              "FIFO",
 	}
 
 	newSession := &sessionDTO{
@@ -704,6 +1296,7 @@ func TestSessionRepository_ArchiveSessionsForBatch(t *testing.T) {
 		DeviceID:               "device_id",
 		ExpiredAt:              time.Now().Add(1 * 24 * time.Hour),
 		AllowToControlByOthers: true,
+		QueueMode:              "FIFO",
 	}
 
 	notAllowedSessions := &sessionDTO{
@@ -715,6 +1308,7 @@ func TestSessionRepository_ArchiveSessionsForBatch(t *testing.T) {
 		DeviceID:               "device_id",
 		ExpiredAt:              time.Now().Add(-1 * 24 * time.Hour),
 		AllowToControlByOthers: false,
+		QueueMode:              "FIFO",
 	}
 
 	tests := []struct {
@@ -775,3 +1369,116 @@ func TestSessionRepository_ArchiveSessionsForBatch(t *testing.T) {
 		})
 	}
 }
+
+func TestSessionRepository_FindIDsScheduledToStart(t *testing.T) {
+	dbMap, err := NewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbMap.AddTableWithName(userDTO{}, "users")
+	dbMap.AddTableWithName(sessionDTO{}, "sessions")
+	truncateTable(t, dbMap)
+
+	now := time.Date(2020, time.December, 1, 12, 0, 0, 0, time.UTC)
+	user := &userDTO{
+		ID:            "existing_user",
+		SpotifyUserID: "existing_user_spotify",
+		DisplayName:   "existing_user_display_name",
+	}
+	newSessionDTO := func(id string, scheduledStartAt sql.NullTime) *sessionDTO {
+		return &sessionDTO{
+			ID:               id,
+			Name:             "existing_session_name",
+			CreatorID:        "existing_user",
+			StateType:        "STOP",
+			ExpiredAt:        now.Add(24 * time.Hour),
+			QueueMode:        "FIFO",
+			ScheduledStartAt: scheduledStartAt,
+		}
+	}
+	if err := dbMap.Insert(
+		user,
+		newSessionDTO("not_scheduled", sql.NullTime{}),
+		newSessionDTO("scheduled_later", sql.NullTime{Time: now.Add(time.Minute), Valid: true}),
+		newSessionDTO("scheduled_now", sql.NullTime{Time: now, Valid: true}),
+		newSessionDTO("scheduled_before", sql.NullTime{Time: now.Add(-time.Minute), Valid: true}),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	r := &SessionRepository{dbMap: dbMap}
+	got, err := r.FindIDsScheduledToStart(context.Background(), now)
+	if err != nil {
+		t.Errorf("FindIDsScheduledToStart() error = %v", err)
+		return
+	}
+	want := []string{"scheduled_before", "scheduled_now"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("FindIDsScheduledToStart() diff = %v", cmp.Diff(want, got))
+	}
+}
+
+func TestSessionRepository_FindIDsByState(t *testing.T) {
+	dbMap, err := NewDB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dbMap.AddTableWithName(userDTO{}, "users")
+	dbMap.AddTableWithName(sessionDTO{}, "sessions")
+	truncateTable(t, dbMap)
+
+	user := &userDTO{
+		ID:            "existing_user",
+		SpotifyUserID: "existing_user_spotify",
+		DisplayName:   "existing_user_display_name",
+	}
+	newSessionDTO := func(id, stateType string) *sessionDTO {
+		return &sessionDTO{
+			ID:        id,
+			Name:      "existing_session_name",
+			CreatorID: "existing_user",
+			StateType: stateType,
+			ExpiredAt: time.Date(2020, time.December, 1, 12, 0, 0, 0, time.UTC),
+			QueueMode: "FIFO",
+		}
+	}
+	if err := dbMap.Insert(
+		user,
+		newSessionDTO("play_session1", "PLAY"),
+		newSessionDTO("pause_session", "PAUSE"),
+		newSessionDTO("play_session2", "PLAY"),
+		newSessionDTO("stop_session", "STOP"),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		stateType entity.StateType
+		want      []string
+	}{
+		{
+			name:      "PLAYのsessionのIDを取得できる",
+			stateType: entity.Play,
+			want:      []string{"play_session1", "play_session2"},
+		},
+		{
+			name:      "該当するsessionが無いときは空",
+			stateType: entity.Archived,
+			want:      []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &SessionRepository{dbMap: dbMap}
+			got, err := r.FindIDsByState(context.Background(), tt.stateType)
+			if err != nil {
+				t.Errorf("FindIDsByState() error = %v", err)
+				return
+			}
+			if !cmp.Equal(got, tt.want, cmpopts.EquateEmpty(), cmpopts.SortSlices(func(a, b string) bool { return a < b })) {
+				t.Errorf("FindIDsByState() diff = %v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
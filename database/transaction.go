@@ -11,6 +11,7 @@ var txKey = struct{}{}
 
 type TransactionDAO interface {
 	SelectOne(holder interface{}, query string, args ...interface{}) error
+	Select(i interface{}, query string, args ...interface{}) ([]interface{}, error)
 	Insert(list ...interface{}) error
 	Update(list ...interface{}) (int64, error)
 	Exec(query string, args ...interface{}) (sql.Result, error)
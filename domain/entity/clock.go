@@ -0,0 +1,176 @@
+package entity
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock は現在時刻とタイマーを提供します。
+// テストでは FakeClock に差し替えることで、実際に待たずに時間の経過を再現できます。
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer は Clock が生成するタイマーです。time.Timer と同じように使います。
+// ResetAt は発火日時を直接指定して再設定します。待ち時間を計算してから Reset するまでに時間が進んでも発火が遅れません。
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+	ResetAt(at time.Time) bool
+}
+
+// NewSystemClock は実際の時刻を使う Clock を生成します。
+func NewSystemClock() Clock {
+	return systemClock{}
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) NewTimer(d time.Duration) Timer {
+	return &systemTimer{timer: time.NewTimer(d)}
+}
+
+type systemTimer struct {
+	timer *time.Timer
+}
+
+func (t *systemTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+func (t *systemTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+func (t *systemTimer) Reset(d time.Duration) bool {
+	return t.timer.Reset(d)
+}
+
+func (t *systemTimer) ResetAt(at time.Time) bool {
+	return t.timer.Reset(time.Until(at))
+}
+
+// FakeClock はテスト用の Clock です。Advance を呼び出したときだけ時間が進みます。
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// NewFakeClock は now を現在時刻とする FakeClock のポインタを生成します。
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now は FakeClock の現在時刻を返します。
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer は FakeClock の時間で d が経過すると発火するタイマーを生成します。
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{clock: c, ch: make(chan time.Time, 1)}
+	c.startTimer(t, c.now.Add(d))
+	return t
+}
+
+// Advance は時間を d だけ進め、発火日時になったタイマーを発火日時の早い順に発火させます。
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	var fired, waiting []*fakeTimer
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			waiting = append(waiting, t)
+			continue
+		}
+		fired = append(fired, t)
+	}
+	c.timers = waiting
+
+	sort.Slice(fired, func(i, j int) bool { return fired[i].deadline.Before(fired[j].deadline) })
+	for _, t := range fired {
+		t.active = false
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+	}
+}
+
+// startTimer は発火日時が at のタイマーを登録します。ロックを取得してから呼び出す必要があります。
+func (c *FakeClock) startTimer(t *fakeTimer, at time.Time) {
+	t.deadline = at
+	if !at.After(c.now) {
+		select {
+		case t.ch <- c.now:
+		default:
+		}
+		return
+	}
+	t.active = true
+	c.timers = append(c.timers, t)
+}
+
+// stopTimer はタイマーの登録を解除し、発火前だったかどうか返します。ロックを取得してから呼び出す必要があります。
+func (c *FakeClock) stopTimer(t *fakeTimer) bool {
+	if !t.active {
+		return false
+	}
+	t.active = false
+	for i, timer := range c.timers {
+		if timer == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			break
+		}
+	}
+	return true
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	ch       chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	return t.clock.stopTimer(t)
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.stopTimer(t)
+	t.clock.startTimer(t, t.clock.now.Add(d))
+	return active
+}
+
+func (t *fakeTimer) ResetAt(at time.Time) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	active := t.clock.stopTimer(t)
+	t.clock.startTimer(t, at)
+	return active
+}
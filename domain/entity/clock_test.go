@@ -0,0 +1,93 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFakeClock_Advance(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		prepare   func(c *FakeClock) Timer
+		advance   time.Duration
+		wantFired bool
+	}{
+		{
+			name:      "発火日時になったタイマーは発火する",
+			prepare:   func(c *FakeClock) Timer { return c.NewTimer(5 * time.Second) },
+			advance:   5 * time.Second,
+			wantFired: true,
+		},
+		{
+			name:      "発火日時になっていないタイマーは発火しない",
+			prepare:   func(c *FakeClock) Timer { return c.NewTimer(5 * time.Second) },
+			advance:   4 * time.Second,
+			wantFired: false,
+		},
+		{
+			name: "止めたタイマーは発火しない",
+			prepare: func(c *FakeClock) Timer {
+				timer := c.NewTimer(5 * time.Second)
+				timer.Stop()
+				return timer
+			},
+			advance:   time.Minute,
+			wantFired: false,
+		},
+		{
+			name: "Resetしたタイマーは新しい発火日時に発火する",
+			prepare: func(c *FakeClock) Timer {
+				timer := c.NewTimer(time.Second)
+				timer.Reset(10 * time.Second)
+				return timer
+			},
+			advance:   5 * time.Second,
+			wantFired: false,
+		},
+		{
+			name: "ResetAtで過去の日時を指定したタイマーはすぐに発火する",
+			prepare: func(c *FakeClock) Timer {
+				timer := c.NewTimer(time.Minute)
+				timer.ResetAt(start.Add(-time.Second))
+				return timer
+			},
+			advance:   0,
+			wantFired: true,
+		},
+		{
+			name: "ResetAtしたタイマーは指定した日時に発火する",
+			prepare: func(c *FakeClock) Timer {
+				timer := c.NewTimer(time.Minute)
+				timer.ResetAt(start.Add(5 * time.Second))
+				return timer
+			},
+			advance:   5 * time.Second,
+			wantFired: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewFakeClock(start)
+			timer := tt.prepare(c)
+
+			c.Advance(tt.advance)
+			if got := c.Now(); !got.Equal(start.Add(tt.advance)) {
+				t.Errorf("Now() = %v, want %v", got, start.Add(tt.advance))
+			}
+			select {
+			case <-timer.C():
+				if !tt.wantFired {
+					t.Errorf("timer fired, want not fired")
+				}
+			default:
+				if tt.wantFired {
+					t.Errorf("timer not fired, want fired")
+				}
+			}
+		})
+	}
+}
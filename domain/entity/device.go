@@ -5,4 +5,5 @@ type Device struct {
 	ID           string
 	IsRestricted bool
 	Name         string
+	Volume       int // 音量 (%)
 }
@@ -27,6 +27,40 @@ var (
 
 	// ErrNextQueueTrackNotFound は次に再生すべきQueueTrackが存在しないエラーを表します。
 	ErrNextQueueTrackNotFound = errors.New("next queue track not found")
+	// ErrPrevQueueTrackNotFound は前に再生したQueueTrackが存在しないエラーを表します。
+	ErrPrevQueueTrackNotFound = errors.New("prev queue track not found")
+
+	// ErrQueueTrackNotEditable は再生済みもしくは再生中のQueueTrackを削除・移動しようとしたときのエラーを表します。
+	ErrQueueTrackNotEditable = errors.New("queue track is not editable")
+
+	// ErrQueueTrackNotVotable はSpotifyのキューに先読みして積まれているQueueTrackに投票しようとしたときのエラーを表します。
+	ErrQueueTrackNotVotable = errors.New("queue track is already queued in spotify")
+
+	// ErrQueueTrackNotMovable はキューのモードが曲の移動を許可していないときに曲を移動しようとしたときのエラーを表します。
+	ErrQueueTrackNotMovable = errors.New("queue track is not movable in this queue mode")
+
+	// ErrInvalidQueueMode は不正なqueue modeであるというエラーを表します。
+	ErrInvalidQueueMode = errors.New("invalid queue mode")
+	// ErrQueueModeIsNotVote は投票モードでないセッションの曲に投票しようとしたときのエラーを表します。
+	ErrQueueModeIsNotVote = errors.New("queue mode is not vote")
+	// ErrInvalidVote は不正な投票であるというエラーを表します。
+	ErrInvalidVote = errors.New("invalid vote")
+
+	// ErrInvalidSessionSettings は不正なセッションの設定であるというエラーを表します。
+	ErrInvalidSessionSettings = errors.New("invalid session settings")
+	// ErrTooManyPendingQueueTracks はユーザが追加したまだ再生されていない曲の数が上限に達しているエラーを表します。
+	ErrTooManyPendingQueueTracks = errors.New("too many pending queue tracks")
+	// ErrEnqueueRateLimitExceeded は一定時間内にユーザが追加できる曲の数の上限に達しているエラーを表します。
+	ErrEnqueueRateLimitExceeded = errors.New("enqueue rate limit exceeded")
+	// ErrDuplicateQueueTrack は既にキューにある未再生の曲と同じ曲を追加しようとしたときのエラーを表します。
+	ErrDuplicateQueueTrack = errors.New("duplicate queue track")
+
+	// ErrInvalidTrackURI はキューに追加できない種類のURIが指定されたエラーを表します。
+	ErrInvalidTrackURI = errors.New("invalid track uri")
+	// ErrTrackNotFound は指定された曲がSpotifyに存在しないエラーを表します。
+	ErrTrackNotFound = errors.New("track not found")
+	// ErrTrackNotPlayable は指定された曲がセッションの作成者の国で再生できないエラーを表します。
+	ErrTrackNotPlayable = errors.New("track is not playable in the market")
 
 	// ErrTokenNotFound はSpotifyのアクセストークンが存在しないエラーを表します。
 	ErrTokenNotFound = errors.New("token not found")
@@ -39,6 +73,15 @@ var (
 	// ErrInvalidStateType は不正なstate typeであるというエラーを表します。
 	ErrInvalidStateType = errors.New("invalid state type")
 
+	// ErrInvalidTrackEndReason は不正な曲の再生が終わった理由であるというエラーを表します。
+	ErrInvalidTrackEndReason = errors.New("invalid track end reason")
+
+	// ErrInvalidSeekPosition は曲の長さの範囲外の再生位置が指定されたエラーを表します。
+	ErrInvalidSeekPosition = errors.New("invalid seek position")
+
+	// ErrInvalidVolume は0%から100%の範囲外の音量が指定されたエラーを表します。
+	ErrInvalidVolume = errors.New("invalid volume")
+
 	// ErrChangeSessionStateNotPermit はセッションのステートの状態遷移が許可されていない場合のエラーを表します。
 	ErrChangeSessionStateNotPermit = errors.New("requested state is not allowed")
 
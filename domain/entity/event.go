@@ -1,17 +1,28 @@
 package entity
 
+import "time"
+
 // Event はクライアントに送信するイベントを表します。
 type Event struct {
-	Type string `json:"type"`
-	Head *int   `json:"head,omitempty"`
+	Type    string        `json:"type"`
+	Head    *int          `json:"head,omitempty"`
+	AddedBy *EventAddedBy `json:"added_by,omitempty"`
+	Count   *int          `json:"count,omitempty"`
+	// Position は再生位置 (ms) です。
+	Position *int64 `json:"position,omitempty"`
+	// Volume は音量 (%) です。
+	Volume *int `json:"volume,omitempty"`
+	// Reason はイベントが発された理由です。
+	Reason string `json:"reason,omitempty"`
 }
 
-var (
-	// EventAddTrack はセッションに曲が追加された際に発されるイベントです。
-	EventAddTrack = &Event{
-		Type: "ADDTRACK",
-	}
+// EventAddedBy は曲を追加したユーザを表します。
+type EventAddedBy struct {
+	UserID   string `json:"user_id"`
+	Nickname string `json:"nickname"`
+}
 
+var (
 	// EventPlay はセッションの再生が開始された際に発されるイベントです。
 	EventPlay = &Event{
 		Type: "PLAY",
@@ -42,6 +53,26 @@ var (
 	EventUnarchive = &Event{
 		Type: "UNARCHIVE",
 	}
+
+	// EventQueueChanged はキューの曲が削除されたり並び替えられたり投票された際に発されるイベントです。
+	EventQueueChanged = &Event{
+		Type: "QUEUE_CHANGED",
+	}
+
+	// EventDeviceChanged はセッションの再生に使うデバイスが変更された際に発されるイベントです。
+	EventDeviceChanged = &Event{
+		Type: "DEVICE_CHANGED",
+	}
+
+	// EventSettingsChanged はセッションの設定が変更された際に発されるイベントです。
+	EventSettingsChanged = &Event{
+		Type: "SETTINGS_CHANGED",
+	}
+
+	// EventScheduledStart は予約された日時にセッションの再生が開始された際に発されるイベントです。
+	EventScheduledStart = &Event{
+		Type: "SCHEDULED_START",
+	}
 )
 
 // NewEventNextTrack はセッションの曲の再生が (正常に) 次の曲に移った際に発されるイベントを生成します。
@@ -52,3 +83,62 @@ func NewEventNextTrack(head int) *Event {
 		Head: &head,
 	}
 }
+
+// NewEventPrevTrack はセッションの曲の再生が前の曲に戻った際に発されるイベントを生成します。
+// キューの現在再生している曲の位置が含まれます。
+func NewEventPrevTrack(head int) *Event {
+	return &Event{
+		Type: "PREVTRACK",
+		Head: &head,
+	}
+}
+
+// NewEventAddTrack はセッションに曲が追加された際に発されるイベントを生成します。
+// 曲を追加したユーザと追加された曲数が含まれます。
+func NewEventAddTrack(userID, nickname string, count int) *Event {
+	return &Event{
+		Type: "ADDTRACK",
+		AddedBy: &EventAddedBy{
+			UserID:   userID,
+			Nickname: nickname,
+		},
+		Count: &count,
+	}
+}
+
+// NewEventSeek はセッションの再生位置が変更された際に発されるイベントを生成します。
+// 変更後の再生位置 (ms) が含まれます。
+func NewEventSeek(position time.Duration) *Event {
+	ms := position.Milliseconds()
+	return &Event{
+		Type:     "SEEK",
+		Position: &ms,
+	}
+}
+
+// NewEventVolume はセッションの音量が変更された際に発されるイベントを生成します。
+// 変更後の音量 (%) が含まれます。
+func NewEventVolume(volume int) *Event {
+	return &Event{
+		Type:   "VOLUME",
+		Volume: &volume,
+	}
+}
+
+// NewEventSleep はスリープタイマーでセッションの再生が止められた際に発されるイベントを生成します。
+// 再生を止めた理由が含まれます。セッションは一時停止状態になります。
+func NewEventSleep(reason SleepReason) *Event {
+	return &Event{
+		Type:   "SLEEP",
+		Reason: string(reason),
+	}
+}
+
+// NewEventScheduledStartFailed は予約された日時にセッションの再生を開始できなかった際に発されるイベントを生成します。
+// 失敗した理由が含まれます。
+func NewEventScheduledStartFailed(reason ScheduledStartFailure) *Event {
+	return &Event{
+		Type:   "SCHEDULED_START_FAILED",
+		Reason: string(reason),
+	}
+}
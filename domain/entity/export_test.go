@@ -0,0 +1,20 @@
+package entity
+
+import "context"
+
+// RunDue は実行日時になった処理を、呼び出し元のgoroutineで実行日時の早い順に実行し、実行した数を返します。
+// handlerが実行日時になった処理を返した場合は続けて実行します。FakeClockと組み合わせて使います。
+func (s *PlaybackScheduler) RunDue(ctx context.Context, handler PlaybackJobHandler) int {
+	count := 0
+	for {
+		due, _ := s.popDueTasks(s.clock.Now())
+		if len(due) == 0 {
+			return count
+		}
+		for _, task := range due {
+			next := handler(ctx, task.job)
+			s.finish(task.entry, next)
+			count++
+		}
+	}
+}
@@ -0,0 +1,362 @@
+package entity
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/camphor-/relaym-server/log"
+)
+
+// PlaybackJobType はPlaybackSchedulerで実行する処理の種類です。
+type PlaybackJobType string
+
+const (
+	// PlaybackJobCheckAfterPlay は再生を始めた後にSpotifyと同期が取れているか確認する処理です。
+	PlaybackJobCheckAfterPlay PlaybackJobType = "CHECK_AFTER_PLAY"
+	// PlaybackJobCheckAfterSkip は曲をスキップした後にSpotifyと同期が取れているか確認する処理です。
+	PlaybackJobCheckAfterSkip PlaybackJobType = "CHECK_AFTER_SKIP"
+	// PlaybackJobCheckAfterTrackEnd は曲の再生が終わった後にSpotifyと同期が取れているか確認する処理です。
+	PlaybackJobCheckAfterTrackEnd PlaybackJobType = "CHECK_AFTER_TRACK_END"
+	// PlaybackJobSyncCheck は曲の再生中にSpotifyと同期が取れているか定期的に確認する処理です。
+	PlaybackJobSyncCheck PlaybackJobType = "SYNC_CHECK"
+	// PlaybackJobTrackEnd は曲の終了を検知したときの処理です。
+	PlaybackJobTrackEnd PlaybackJobType = "TRACK_END"
+	// PlaybackJobNextTrack は次の曲へのスキップが指示されたときの処理です。
+	PlaybackJobNextTrack PlaybackJobType = "NEXT_TRACK"
+)
+
+// maxPendingNextRequests は実行を待っているスキップの指示の上限です。
+// 上限を超えた指示は捨てられるので、API Rate Limitの役割を果たしています。
+const maxPendingNextRequests = 10
+
+// PlaybackJob はセッションごとに予定されている再生に関する処理です。
+type PlaybackJob struct {
+	SessionID string
+	Type      PlaybackJobType
+	At        time.Time
+	// TrackEndAt と SyncInterval は PlaybackJobSyncCheck のときだけ使います。
+	// 曲の終了を検知する予定の日時と、次に同期チェックを行うまでの間隔です。
+	TrackEndAt   time.Time
+	SyncInterval time.Duration
+	// TokenRetryCount はセッションの作成者のトークンを取得できずに処理をやり直した回数です。
+	TokenRetryCount int
+}
+
+// PlaybackJobHandler はPlaybackJobを実行し、そのセッションで次に予定する処理を返します。
+// nilを返すとセッションの予定を削除します。ctxはRunに渡されたものです。
+type PlaybackJobHandler func(ctx context.Context, job PlaybackJob) *PlaybackJob
+
+// PlaybackScheduler はセッションごとの再生に関する処理の予定を一括して管理するスケジューラです。
+// 予定は実行日時の早い順にヒープで管理し、決まった数のワーカーで実行します。
+// 一つのセッションが持つ予定は一つだけで、同じセッションの処理が同時に実行されることはありません。
+type PlaybackScheduler struct {
+	mu      sync.Mutex
+	entries map[string]*playbackEntry
+	queue   playbackQueue
+	workers int
+	wakeCh  chan struct{}
+	clock   Clock
+}
+
+// NewPlaybackScheduler はPlaybackSchedulerのポインタを生成します。workersは同時に処理を実行するワーカーの数です。
+func NewPlaybackScheduler(workers int, clock Clock) *PlaybackScheduler {
+	if workers < 1 {
+		workers = 1
+	}
+	return &PlaybackScheduler{
+		entries: map[string]*playbackEntry{},
+		workers: workers,
+		wakeCh:  make(chan struct{}, 1),
+		clock:   clock,
+	}
+}
+
+type playbackEntry struct {
+	job          *PlaybackJob
+	index        int  // ヒープ上の位置。ヒープに無いときは-1
+	running      bool // 処理を実行中かどうか
+	changed      bool // 実行中に外部から予定が変更されたかどうか
+	nextRequests int  // 実行を待っているスキップの指示の数
+}
+
+type playbackTask struct {
+	entry *playbackEntry
+	job   PlaybackJob
+}
+
+// Run はスケジューラを動かし、予定の日時になった処理をhandlerで実行します。ctxがキャンセルされるまで処理を続けます。
+// goroutineで実行されることを想定しています。
+func (s *PlaybackScheduler) Run(ctx context.Context, handler PlaybackJobHandler) {
+	tasks := make(chan playbackTask)
+	var wg sync.WaitGroup
+	for i := 0; i < s.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range tasks {
+				next := handler(ctx, task.job)
+				s.finish(task.entry, next)
+			}
+		}()
+	}
+	defer func() {
+		close(tasks)
+		wg.Wait()
+	}()
+
+	timer := s.clock.NewTimer(0)
+	for {
+		due, nextAt := s.popDueTasks(s.clock.Now())
+		for _, task := range due {
+			select {
+			case tasks <- task:
+			case <-ctx.Done():
+				return
+			}
+		}
+		if len(due) > 0 {
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C():
+			default:
+			}
+		}
+		timer.ResetAt(nextAt)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C():
+		case <-s.wakeCh:
+		}
+	}
+}
+
+// Add はセッションの処理を予定します。既に予定がある場合は置き換えます。
+// 処理はリクエストのctxから切り離して実行されるので、必要なトークンはhandlerで取得する必要があります。
+func (s *PlaybackScheduler) Add(sessionID string, jobType PlaybackJobType, at time.Time) {
+	s.AddJob(PlaybackJob{SessionID: sessionID, Type: jobType, At: at})
+}
+
+// AddJob はjobをセッションの処理として予定します。既に予定がある場合は置き換えます。
+func (s *PlaybackScheduler) AddJob(job PlaybackJob) {
+	logger := log.New()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logger.Debugj(map[string]interface{}{"message": "add playback job", "sessionID": job.SessionID, "type": job.Type, "at": job.At})
+
+	e, ok := s.entries[job.SessionID]
+	if !ok {
+		e = &playbackEntry{index: -1}
+		s.entries[job.SessionID] = e
+	}
+	e.nextRequests = 0
+	s.setJob(e, &job)
+}
+
+// Cancel はセッションの予定を削除します。実行中の処理の結果も捨てられます。
+func (s *PlaybackScheduler) Cancel(sessionID string) {
+	logger := log.New()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[sessionID]
+	if !ok {
+		logger.Debugj(map[string]interface{}{"message": "playback job not existed on Cancel", "sessionID": sessionID})
+		return
+	}
+
+	logger.Debugj(map[string]interface{}{"message": "cancel playback job", "sessionID": sessionID})
+	if e.index >= 0 {
+		heap.Remove(&s.queue, e.index)
+	}
+	delete(s.entries, sessionID)
+}
+
+// Reschedule はセッションがwaitingTypesのいずれかの処理の実行を待っている場合、その予定をjobに置き換えます。
+// 別の処理を待っているときや処理を実行中のときは何もしません。
+func (s *PlaybackScheduler) Reschedule(job PlaybackJob, waitingTypes ...PlaybackJobType) error {
+	logger := log.New()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[job.SessionID]
+	if !ok {
+		logger.Debugj(map[string]interface{}{"message": "playback job not existed on Reschedule", "sessionID": job.SessionID})
+		return fmt.Errorf("playback job not existed")
+	}
+	if e.running || !e.job.isOneOf(waitingTypes) {
+		return nil
+	}
+
+	s.setJob(e, &job)
+	return nil
+}
+
+// RequestNext はセッションに次の曲へのスキップを指示します。指示は予定より優先して順番に実行されます。
+func (s *PlaybackScheduler) RequestNext(sessionID string) error {
+	logger := log.New()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	logger.Debugj(map[string]interface{}{"message": "request next", "sessionID": sessionID})
+
+	e, ok := s.entries[sessionID]
+	if !ok {
+		logger.Debugj(map[string]interface{}{"message": "playback job not existed on RequestNext", "sessionID": sessionID})
+		return fmt.Errorf("playback job not existed")
+	}
+	if e.nextRequests >= maxPendingNextRequests {
+		return nil
+	}
+
+	e.nextRequests++
+	if !e.running && e.job.Type != PlaybackJobNextTrack {
+		s.setJob(e, &PlaybackJob{SessionID: sessionID, Type: PlaybackJobNextTrack, At: s.clock.Now()})
+	}
+	return nil
+}
+
+// Exists はセッションの予定が存在するかどうか返します。
+func (s *PlaybackScheduler) Exists(sessionID string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.entries[sessionID]
+	return ok
+}
+
+// IsWaiting はセッションがjobTypesのいずれかの処理の実行を待っているかどうか返します。
+func (s *PlaybackScheduler) IsWaiting(sessionID string, jobTypes ...PlaybackJobType) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[sessionID]
+	return ok && !e.running && e.job.isOneOf(jobTypes)
+}
+
+// IsRunning はセッションがjobTypeの処理を実行中で、その間に予定が削除も変更もされていないかどうか返します。
+// 処理の結果を反映する前に、他の操作と競合していないか確認するために使います。
+func (s *PlaybackScheduler) IsRunning(sessionID string, jobType PlaybackJobType) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[sessionID]
+	return ok && e.running && !e.changed && e.job.Type == jobType
+}
+
+func (j *PlaybackJob) isOneOf(jobTypes []PlaybackJobType) bool {
+	for _, t := range jobTypes {
+		if j.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+// setJob はエントリの予定を変更します。ロックを取得してから呼び出す必要があります。
+func (s *PlaybackScheduler) setJob(e *playbackEntry, job *PlaybackJob) {
+	e.job = job
+	if e.running {
+		e.changed = true
+		return
+	}
+	if e.index >= 0 {
+		heap.Fix(&s.queue, e.index)
+	} else {
+		heap.Push(&s.queue, e)
+	}
+	s.wake()
+}
+
+// popDueTasks はnowまでに実行すべき処理をヒープから取り出し、実行中にします。
+// 実行すべき処理が無い場合は、次の処理の実行日時を返します。
+func (s *PlaybackScheduler) popDueTasks(now time.Time) ([]playbackTask, time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var due []playbackTask
+	for len(s.queue) > 0 && !s.queue[0].job.At.After(now) {
+		e := heap.Pop(&s.queue).(*playbackEntry)
+		e.running = true
+		e.changed = false
+		if e.job.Type == PlaybackJobNextTrack && e.nextRequests > 0 {
+			e.nextRequests--
+		}
+		due = append(due, playbackTask{entry: e, job: *e.job})
+	}
+	if len(due) > 0 || len(s.queue) == 0 {
+		return due, now.Add(time.Hour)
+	}
+	return nil, s.queue[0].job.At
+}
+
+// finish は処理の実行が終わったエントリに次の予定をセットします。
+// 実行中に予定が削除されたり置き換えられたりした場合は、処理の結果を捨てます。
+func (s *PlaybackScheduler) finish(e *playbackEntry, next *PlaybackJob) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.running = false
+	if current, ok := s.entries[e.job.SessionID]; !ok || current != e {
+		return
+	}
+	if e.changed {
+		e.changed = false
+		heap.Push(&s.queue, e)
+		s.wake()
+		return
+	}
+	if next == nil {
+		delete(s.entries, e.job.SessionID)
+		return
+	}
+	if e.nextRequests > 0 {
+		next = &PlaybackJob{SessionID: e.job.SessionID, Type: PlaybackJobNextTrack, At: s.clock.Now()}
+	}
+	s.setJob(e, next)
+}
+
+// wake はRunのループを起こして次の処理までの待ち時間を計算し直させます。
+func (s *PlaybackScheduler) wake() {
+	select {
+	case s.wakeCh <- struct{}{}:
+	default:
+	}
+}
+
+// playbackQueue は実行日時の早い順に予定を取り出すヒープです。
+type playbackQueue []*playbackEntry
+
+func (q playbackQueue) Len() int { return len(q) }
+
+func (q playbackQueue) Less(i, j int) bool { return q[i].job.At.Before(q[j].job.At) }
+
+func (q playbackQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *playbackQueue) Push(x interface{}) {
+	e := x.(*playbackEntry)
+	e.index = len(*q)
+	*q = append(*q, e)
+}
+
+func (q *playbackQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	e.index = -1
+	*q = old[:n-1]
+	return e
+}
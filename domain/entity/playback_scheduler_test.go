@@ -0,0 +1,380 @@
+package entity
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPlaybackScheduler_Run(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		prepare func(s *PlaybackScheduler)
+		next    map[PlaybackJobType]PlaybackJobType
+		want    []PlaybackJob
+	}{
+		{
+			name: "実行日時の早い順に実行される",
+			prepare: func(s *PlaybackScheduler) {
+				s.Add("session2", PlaybackJobTrackEnd, now.Add(-1*time.Second))
+				s.Add("session1", PlaybackJobTrackEnd, now.Add(-2*time.Second))
+				s.Add("session3", PlaybackJobTrackEnd, now)
+			},
+			want: []PlaybackJob{
+				{SessionID: "session1", Type: PlaybackJobTrackEnd},
+				{SessionID: "session2", Type: PlaybackJobTrackEnd},
+				{SessionID: "session3", Type: PlaybackJobTrackEnd},
+			},
+		},
+		{
+			name: "handlerが返した処理が続けて実行される",
+			prepare: func(s *PlaybackScheduler) {
+				s.Add("session1", PlaybackJobCheckAfterPlay, now)
+			},
+			next: map[PlaybackJobType]PlaybackJobType{
+				PlaybackJobCheckAfterPlay:     PlaybackJobTrackEnd,
+				PlaybackJobTrackEnd:           PlaybackJobCheckAfterTrackEnd,
+				PlaybackJobCheckAfterTrackEnd: "",
+			},
+			want: []PlaybackJob{
+				{SessionID: "session1", Type: PlaybackJobCheckAfterPlay},
+				{SessionID: "session1", Type: PlaybackJobTrackEnd},
+				{SessionID: "session1", Type: PlaybackJobCheckAfterTrackEnd},
+			},
+		},
+		{
+			name: "削除された予定は実行されない",
+			prepare: func(s *PlaybackScheduler) {
+				s.Add("session1", PlaybackJobTrackEnd, now)
+				s.Add("session2", PlaybackJobTrackEnd, now.Add(-1*time.Second))
+				s.Cancel("session2")
+			},
+			want: []PlaybackJob{
+				{SessionID: "session1", Type: PlaybackJobTrackEnd},
+			},
+		},
+		{
+			name: "スキップの指示は予定より先に指示された数だけ実行される",
+			prepare: func(s *PlaybackScheduler) {
+				s.Add("session1", PlaybackJobTrackEnd, now.Add(time.Hour))
+				if err := s.RequestNext("session1"); err != nil {
+					t.Fatal(err)
+				}
+				if err := s.RequestNext("session1"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			next: map[PlaybackJobType]PlaybackJobType{
+				PlaybackJobNextTrack:      PlaybackJobCheckAfterSkip,
+				PlaybackJobCheckAfterSkip: "",
+			},
+			want: []PlaybackJob{
+				{SessionID: "session1", Type: PlaybackJobNextTrack},
+				{SessionID: "session1", Type: PlaybackJobNextTrack},
+				{SessionID: "session1", Type: PlaybackJobCheckAfterSkip},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPlaybackScheduler(1, NewSystemClock())
+			tt.prepare(s)
+
+			got := make(chan PlaybackJob, 10)
+			nextTypes := tt.next
+			handler := func(ctx context.Context, job PlaybackJob) *PlaybackJob {
+				got <- PlaybackJob{SessionID: job.SessionID, Type: job.Type}
+				if next := nextTypes[job.Type]; next != "" {
+					return &PlaybackJob{SessionID: job.SessionID, Type: next, At: time.Now()}
+				}
+				return nil
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go s.Run(ctx, handler)
+
+			var gotJobs []PlaybackJob
+			for range tt.want {
+				select {
+				case job := <-got:
+					gotJobs = append(gotJobs, job)
+				case <-time.After(time.Second):
+					t.Fatalf("Run() executed %v, want %v", gotJobs, tt.want)
+				}
+			}
+			if !cmp.Equal(gotJobs, tt.want) {
+				t.Errorf("Run() diff = %v", cmp.Diff(tt.want, gotJobs))
+			}
+
+			select {
+			case job := <-got:
+				t.Errorf("Run() executed unexpected job %v", job)
+			case <-time.After(50 * time.Millisecond):
+			}
+		})
+	}
+}
+
+func TestPlaybackScheduler_RunDue(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    []PlaybackJob
+	}{
+		{
+			name:    "実行日時になっていない処理は実行されない",
+			advance: 2 * time.Second,
+			want:    nil,
+		},
+		{
+			name:    "実行日時になった処理が早い順に実行される",
+			advance: 5 * time.Second,
+			want: []PlaybackJob{
+				{SessionID: "session1", Type: PlaybackJobCheckAfterPlay, At: start.Add(3 * time.Second)},
+				{SessionID: "session2", Type: PlaybackJobCheckAfterPlay, At: start.Add(5 * time.Second)},
+			},
+		},
+		{
+			name:    "handlerが返した処理も実行日時になっていれば続けて実行される",
+			advance: 13 * time.Second,
+			want: []PlaybackJob{
+				{SessionID: "session1", Type: PlaybackJobCheckAfterPlay, At: start.Add(3 * time.Second)},
+				{SessionID: "session2", Type: PlaybackJobCheckAfterPlay, At: start.Add(5 * time.Second)},
+				{SessionID: "session1", Type: PlaybackJobTrackEnd, At: start.Add(13 * time.Second)},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := NewFakeClock(start)
+			s := NewPlaybackScheduler(1, clock)
+			s.Add("session2", PlaybackJobCheckAfterPlay, start.Add(5*time.Second))
+			s.Add("session1", PlaybackJobCheckAfterPlay, start.Add(3*time.Second))
+
+			var got []PlaybackJob
+			handler := func(ctx context.Context, job PlaybackJob) *PlaybackJob {
+				got = append(got, job)
+				if job.Type == PlaybackJobCheckAfterPlay {
+					return &PlaybackJob{SessionID: job.SessionID, Type: PlaybackJobTrackEnd, At: job.At.Add(10 * time.Second)}
+				}
+				return nil
+			}
+
+			clock.Advance(tt.advance)
+			if n := s.RunDue(context.Background(), handler); n != len(tt.want) {
+				t.Errorf("RunDue() = %d, want %d", n, len(tt.want))
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("RunDue() diff = %v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestPlaybackScheduler_Run_ChangedWhileRunning(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		change      func(s *PlaybackScheduler)
+		wantRunning bool
+		wantExists  bool
+		wantType    PlaybackJobType
+	}{
+		{
+			name:        "実行中に削除されると処理の結果は捨てられる",
+			change:      func(s *PlaybackScheduler) { s.Cancel("session1") },
+			wantRunning: false,
+			wantExists:  false,
+		},
+		{
+			name: "実行中に予定が置き換えられると処理の結果より優先される",
+			change: func(s *PlaybackScheduler) {
+				s.Add("session1", PlaybackJobCheckAfterPlay, time.Now().Add(time.Hour))
+			},
+			wantRunning: false,
+			wantExists:  true,
+			wantType:    PlaybackJobCheckAfterPlay,
+		},
+		{
+			name:        "何も変更されなければ処理の結果が次の予定になる",
+			change:      func(s *PlaybackScheduler) {},
+			wantRunning: true,
+			wantExists:  true,
+			wantType:    PlaybackJobCheckAfterTrackEnd,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPlaybackScheduler(1, NewSystemClock())
+			s.Add("session1", PlaybackJobTrackEnd, time.Now())
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			done := make(chan struct{})
+			handler := func(ctx context.Context, job PlaybackJob) *PlaybackJob {
+				close(started)
+				<-release
+				defer close(done)
+				return &PlaybackJob{SessionID: job.SessionID, Type: PlaybackJobCheckAfterTrackEnd, At: time.Now().Add(time.Hour)}
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go s.Run(ctx, handler)
+
+			<-started
+			if s.IsWaiting("session1", PlaybackJobTrackEnd) {
+				t.Errorf("IsWaiting() = true while running")
+			}
+			tt.change(s)
+			if got := s.IsRunning("session1", PlaybackJobTrackEnd); got != tt.wantRunning {
+				t.Errorf("IsRunning() = %v, want %v", got, tt.wantRunning)
+			}
+			close(release)
+			<-done
+
+			// finishがロックを取るまで待つ
+			deadline := time.Now().Add(time.Second)
+			for time.Now().Before(deadline) {
+				if s.Exists("session1") == tt.wantExists && (!tt.wantExists || s.IsWaiting("session1", tt.wantType)) {
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+			t.Errorf("Exists() = %v, want %v, IsWaiting(%s) = %v", s.Exists("session1"), tt.wantExists, tt.wantType, s.IsWaiting("session1", tt.wantType))
+		})
+	}
+}
+
+func TestPlaybackScheduler_Reschedule(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+
+	tests := []struct {
+		name         string
+		job          PlaybackJob
+		waitingTypes []PlaybackJobType
+		wantJob      PlaybackJob
+		wantErr      bool
+	}{
+		{
+			name:         "待っている処理と同じ種類のときは予定が置き換えられる",
+			job:          PlaybackJob{SessionID: "session1", Type: PlaybackJobTrackEnd, At: now.Add(time.Minute)},
+			waitingTypes: []PlaybackJobType{PlaybackJobTrackEnd},
+			wantJob:      PlaybackJob{SessionID: "session1", Type: PlaybackJobTrackEnd, At: now.Add(time.Minute)},
+			wantErr:      false,
+		},
+		{
+			name:         "待っている処理がいずれかの種類に含まれるときは別の種類の処理に置き換えられる",
+			job:          PlaybackJob{SessionID: "session1", Type: PlaybackJobSyncCheck, At: now.Add(time.Minute), TrackEndAt: now.Add(2 * time.Minute), SyncInterval: time.Minute},
+			waitingTypes: []PlaybackJobType{PlaybackJobSyncCheck, PlaybackJobTrackEnd},
+			wantJob:      PlaybackJob{SessionID: "session1", Type: PlaybackJobSyncCheck, At: now.Add(time.Minute), TrackEndAt: now.Add(2 * time.Minute), SyncInterval: time.Minute},
+			wantErr:      false,
+		},
+		{
+			name:         "待っている処理と異なる種類のときは何もしない",
+			job:          PlaybackJob{SessionID: "session1", Type: PlaybackJobCheckAfterSkip, At: now.Add(time.Minute)},
+			waitingTypes: []PlaybackJobType{PlaybackJobCheckAfterSkip},
+			wantJob:      PlaybackJob{SessionID: "session1", Type: PlaybackJobTrackEnd, At: now.Add(time.Hour)},
+			wantErr:      false,
+		},
+		{
+			name:         "予定が存在しないときはエラー",
+			job:          PlaybackJob{SessionID: "not_exists", Type: PlaybackJobTrackEnd, At: now.Add(time.Minute)},
+			waitingTypes: []PlaybackJobType{PlaybackJobTrackEnd},
+			wantJob:      PlaybackJob{SessionID: "session1", Type: PlaybackJobTrackEnd, At: now.Add(time.Hour)},
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPlaybackScheduler(1, NewSystemClock())
+			s.Add("session1", PlaybackJobTrackEnd, now.Add(time.Hour))
+			s.Add("session2", PlaybackJobTrackEnd, now.Add(30*time.Minute))
+
+			if err := s.Reschedule(tt.job, tt.waitingTypes...); (err != nil) != tt.wantErr {
+				t.Errorf("Reschedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := *s.entries["session1"].job; !cmp.Equal(got, tt.wantJob) {
+				t.Errorf("Reschedule() diff = %v", cmp.Diff(tt.wantJob, got))
+			}
+			if got := s.queue[0].job.At; !got.Equal(minTime(tt.wantJob.At, now.Add(30*time.Minute))) {
+				t.Errorf("Reschedule() heap top at = %v, want the earliest", got)
+			}
+		})
+	}
+}
+
+func TestPlaybackScheduler_RequestNext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name             string
+		sessionID        string
+		requests         int
+		wantNextRequests int
+		wantErr          bool
+	}{
+		{
+			name:             "スキップの指示が予定を置き換える",
+			sessionID:        "session1",
+			requests:         1,
+			wantNextRequests: 1,
+			wantErr:          false,
+		},
+		{
+			name:             "上限を超えたスキップの指示は捨てられる",
+			sessionID:        "session1",
+			requests:         maxPendingNextRequests + 5,
+			wantNextRequests: maxPendingNextRequests,
+			wantErr:          false,
+		},
+		{
+			name:      "予定が存在しないときはエラー",
+			sessionID: "not_exists",
+			requests:  1,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewPlaybackScheduler(1, NewSystemClock())
+			s.Add("session1", PlaybackJobTrackEnd, time.Now().Add(time.Hour))
+
+			for i := 0; i < tt.requests; i++ {
+				if err := s.RequestNext(tt.sessionID); (err != nil) != tt.wantErr {
+					t.Errorf("RequestNext() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+			if !s.IsWaiting(tt.sessionID, PlaybackJobNextTrack) {
+				t.Errorf("RequestNext() should replace the job with %s", PlaybackJobNextTrack)
+			}
+			if got := s.entries[tt.sessionID].nextRequests; got != tt.wantNextRequests {
+				t.Errorf("RequestNext() nextRequests = %d, want %d", got, tt.wantNextRequests)
+			}
+		})
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,45 @@
+package entity
+
+import (
+	"fmt"
+	"time"
+)
+
+// PlayedTrack はセッションで実際に再生された曲の履歴を表します。
+type PlayedTrack struct {
+	ID        int64
+	SessionID string
+	URI       string
+	StartedAt time.Time
+	EndedAt   time.Time
+	EndReason TrackEndReason
+}
+
+// TrackEndReason は曲の再生が終わった理由を表します。
+type TrackEndReason string
+
+const (
+	// TrackEndReasonFinished は曲が最後まで再生されたことを表します。
+	TrackEndReasonFinished TrackEndReason = "FINISHED"
+	// TrackEndReasonSkipped は曲が次の曲へスキップされたことを表します。
+	TrackEndReasonSkipped TrackEndReason = "SKIPPED"
+	// TrackEndReasonInterrupted はSpotifyとの同期が取れなくなって曲の再生が中断されたことを表します。
+	TrackEndReasonInterrupted TrackEndReason = "INTERRUPTED"
+)
+
+var trackEndReasons = []TrackEndReason{TrackEndReasonFinished, TrackEndReasonSkipped, TrackEndReasonInterrupted}
+
+// NewTrackEndReason はstringから対応するTrackEndReasonを生成します。
+func NewTrackEndReason(reason string) (TrackEndReason, error) {
+	for _, r := range trackEndReasons {
+		if r.String() == reason {
+			return r, nil
+		}
+	}
+	return "", fmt.Errorf("reason = %s: %w", reason, ErrInvalidTrackEndReason)
+}
+
+// String はfmt.Stringerを満たすメソッドです。
+func (r TrackEndReason) String() string {
+	return string(r)
+}
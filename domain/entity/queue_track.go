@@ -1,14 +1,71 @@
 package entity
 
+import (
+	"fmt"
+	"time"
+)
+
 // QueueTrackToStore はsessionに属するqueue内に曲を挿入する際に使用します
 type QueueTrackToStore struct {
-	URI       string
-	SessionID string
+	URI             string
+	SessionID       string
+	AddedByUserID   string
+	AddedByNickname string
+	AddedBySystem   bool // ラジオモードで自動で追加された場合はtrue
+	AddedAt         time.Time
 }
 
 // QueueTrack はsessionに属するqueue内の曲を表します。
 type QueueTrack struct {
-	Index     int
-	URI       string
-	SessionID string
+	ID              int64
+	Index           int
+	URI             string
+	SessionID       string
+	AddedByUserID   string // ゲストが追加した場合は空
+	AddedByNickname string
+	AddedBySystem   bool // ラジオモードで自動で追加された場合はtrue
+	Score           int  // 投票の合計
+	AddedAt         time.Time
+}
+
+// IsAddedBy は曲が指定されたユーザによって追加されたかどうか返します。
+// ログインしているユーザはユーザIDで、ゲストはニックネームで識別します。ラジオモードで自動で追加された曲は誰にも属しません。
+func (qt *QueueTrack) IsAddedBy(userID, nickname string) bool {
+	if qt.AddedBySystem {
+		return false
+	}
+	if userID != "" {
+		return qt.AddedByUserID == userID
+	}
+	return qt.AddedByUserID == "" && qt.AddedByNickname == nickname
+}
+
+// contributor は曲を追加した人を識別する文字列を返します。
+func (qt *QueueTrack) contributor() string {
+	if qt.AddedBySystem {
+		return "system"
+	}
+	if qt.AddedByUserID != "" {
+		return "user:" + qt.AddedByUserID
+	}
+	return "guest:" + qt.AddedByNickname
+}
+
+// QueueTrackVote はqueue内の曲へのユーザの投票を表します。
+type QueueTrackVote struct {
+	QueueTrackID int64
+	UserID       string
+	Value        int // 1: upvote, -1: downvote
+}
+
+// NewQueueTrackVote はQueueTrackVoteのポインタを生成する関数です。
+func NewQueueTrackVote(queueTrackID int64, userID string, value int) (*QueueTrackVote, error) {
+	if value != 1 && value != -1 {
+		return nil, fmt.Errorf("value = %d: %w", value, ErrInvalidVote)
+	}
+	return &QueueTrackVote{
+		QueueTrackID: queueTrackID,
+		UserID:       userID,
+		Value:        value,
+	}, nil
 }
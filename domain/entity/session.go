@@ -2,6 +2,8 @@ package entity
 
 import (
 	"fmt"
+	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/camphor-/relaym-server/log"
@@ -11,16 +13,45 @@ import (
 
 // Session はセッションを表します。
 type Session struct {
-	ID                     string
-	Name                   string
-	CreatorID              string
-	DeviceID               string
-	StateType              StateType
-	QueueHead              int
-	QueueTracks            []*QueueTrack
-	ExpiredAt              time.Time
-	AllowToControlByOthers bool
-	ProgressWhenPaused     time.Duration
+	ID                      string
+	Name                    string
+	CreatorID               string
+	DeviceID                string
+	StateType               StateType
+	QueueHead               int
+	QueueTracks             []*QueueTrack
+	ExpiredAt               time.Time
+	AllowToControlByOthers  bool
+	ProgressWhenPaused      time.Duration
+	QueueMode               QueueMode
+	MaxPendingTracksPerUser int // 0のときは無制限
+	EnqueueRateLimit        int // EnqueueRateWindowの間に追加できる曲数。0のときは無制限
+	EnqueueRateWindow       time.Duration
+	RejectDuplicateTracks   bool
+	HeadStartedAt           time.Time // headの曲の再生が始まった日時。再生が始まっていないときはゼロ値
+	RadioMode               bool      // 未再生の曲が少なくなったときにおすすめの曲を自動で追加するかどうか
+	MaxVolume               int       // APIから設定できる音量の上限 (%)。0のときは無制限
+	DeviceFallback          bool      // 再生を始めるときにデバイスが見つからなければ別のデバイスで再生し直すかどうか
+	RepeatQueue             bool      // キューの最後の曲の再生が終わったときに最初の曲に戻って再生を続けるかどうか
+	SleepAfterTracks        int       // スリープタイマーで再生を止めるまでに再生し終える残りの曲数。0のときは無効
+	SleepAt                 time.Time // スリープタイマーで再生を止める日時。ゼロ値のときは無効
+	ScheduledStartAt        time.Time // 再生を開始する予約日時。ゼロ値のときは予約していない
+}
+
+// SessionSettingsToUpdate はセッションの設定を変更する際に使用します。nilの項目は変更しません。
+type SessionSettingsToUpdate struct {
+	QueueMode               *QueueMode
+	MaxPendingTracksPerUser *int
+	EnqueueRateLimit        *int
+	EnqueueRateWindow       *time.Duration
+	RejectDuplicateTracks   *bool
+	RadioMode               *bool
+	MaxVolume               *int
+	DeviceFallback          *bool
+	RepeatQueue             *bool
+	SleepAfterTracks        *int
+	SleepAt                 *time.Time // ゼロ値のときはスリープタイマーの日時を解除します
+	ScheduledStartAt        *time.Time // ゼロ値のときは再生開始の予約を解除します
 }
 
 type SessionWithUser struct {
@@ -28,8 +59,8 @@ type SessionWithUser struct {
 	Creator *User
 }
 
-// NewSession はSessionのポインタを生成する関数です。
-func NewSession(name string, creatorID string, allowToControlByOthers bool) (*Session, error) {
+// NewSession はSessionのポインタを生成する関数です。有効期限はnowから3日後になります。
+func NewSession(name string, creatorID string, allowToControlByOthers bool, now time.Time) (*Session, error) {
 	return &Session{
 		ID:                     uuid.New().String(),
 		Name:                   name,
@@ -38,9 +69,10 @@ func NewSession(name string, creatorID string, allowToControlByOthers bool) (*Se
 		StateType:              Stop,
 		QueueHead:              0,
 		QueueTracks:            nil,
-		ExpiredAt:              time.Now().AddDate(0, 0, 3).UTC(),
+		ExpiredAt:              now.AddDate(0, 0, 3).UTC(),
 		AllowToControlByOthers: allowToControlByOthers,
 		ProgressWhenPaused:     0 * time.Second,
+		QueueMode:              QueueModeFIFO,
 	}, nil
 }
 
@@ -52,9 +84,9 @@ func NewSessionWithUser(session *Session, creator *User) *SessionWithUser {
 	}
 }
 
-// UpdateExpiredAt はexpired_atを現在の時刻から3日後に設定します
-func (s *Session) UpdateExpiredAt() {
-	threeDaysAfter := time.Now().AddDate(0, 0, 3).UTC()
+// UpdateExpiredAt はexpired_atをnowから3日後に設定します
+func (s *Session) UpdateExpiredAt(now time.Time) {
+	threeDaysAfter := now.AddDate(0, 0, 3).UTC()
 	s.ExpiredAt = threeDaysAfter
 }
 
@@ -82,12 +114,14 @@ func (s *Session) MoveToPause() error {
 func (s *Session) MoveToStop() {
 	s.StateType = Stop
 	s.SetProgressWhenPaused(0 * time.Second)
+	s.HeadStartedAt = time.Time{}
 }
 
 // MoveToArchived はセッションのStateTypeをArchivedに状態遷移します。
 func (s *Session) MoveToArchived() {
 	s.StateType = Archived
 	s.SetProgressWhenPaused(0 * time.Second)
+	s.HeadStartedAt = time.Time{}
 }
 
 // IsCreator は指定されたユーザがセッションの作成者かどうか返します。
@@ -96,8 +130,13 @@ func (s *Session) IsCreator(userID string) bool {
 }
 
 // GoNextTrack 次の曲の状態に進めます。
+// RepeatQueueが有効なときは、キューの最後の曲の次はキューの最初の曲に戻ります。
 func (s *Session) GoNextTrack() error {
 	s.SetProgressWhenPaused(0 * time.Second)
+	if s.RepeatQueue && len(s.QueueTracks) > 0 && len(s.QueueTracks) <= s.QueueHead+1 {
+		s.QueueHead = 0
+		return nil
+	}
 	if len(s.QueueTracks) <= s.QueueHead+1 {
 		s.QueueHead = len(s.QueueTracks) // https://github.com/camphor-/relaym-server/blob/master/docs/definition.md#%E7%8F%BE%E5%9C%A8%E5%AF%BE%E8%B1%A1%E3%81%AE%E6%9B%B2%E3%81%AE%E3%82%A4%E3%83%B3%E3%83%87%E3%83%83%E3%82%AF%E3%82%B9-head
 		s.StateType = Stop
@@ -107,6 +146,32 @@ func (s *Session) GoNextTrack() error {
 	return nil
 }
 
+// ShouldGoPrevTrack は前の曲に戻る操作で、前の曲に戻るべきかどうかを返します。
+// 曲の再生が始まってすぐで前の曲が存在するときはtrue、それ以外は現在の曲を最初から再生し直すのでfalseを返します。
+func (s *Session) ShouldGoPrevTrack(progress time.Duration) bool {
+	return s.QueueHead > 0 && progress < prevTrackThreshold
+}
+
+// JumpToTrack はキューのindex番目の曲の状態に移動します。再生済みの曲にも未再生の曲にも移動できます。
+func (s *Session) JumpToTrack(index int) error {
+	if index < 0 || len(s.QueueTracks) <= index {
+		return fmt.Errorf("index=%d: %w", index, ErrQueueTrackNotFound)
+	}
+	s.SetProgressWhenPaused(0 * time.Second)
+	s.QueueHead = index
+	return nil
+}
+
+// GoPrevTrack は前の曲の状態に戻します。
+func (s *Session) GoPrevTrack() error {
+	if s.QueueHead <= 0 {
+		return ErrPrevQueueTrackNotFound
+	}
+	s.SetProgressWhenPaused(0 * time.Second)
+	s.QueueHead--
+	return nil
+}
+
 // IsPlayingCorrectTrack は現在の再生状況がセッションの状況と一致しているかチェックします。
 func (s *Session) IsPlayingCorrectTrack(playingInfo *CurrentPlayingInfo) error {
 	logger := log.New()
@@ -150,6 +215,32 @@ func (s *Session) ShouldCallEnqueueAPINow() bool {
 	return ((len(s.QueueTracks) - s.QueueHead) < 3) && (s.StateType == Play || s.StateType == Pause)
 }
 
+// StartHeadTrack はheadの曲の再生が始まった日時を記録します。
+// 一時停止からの再開のように既に再生が始まっている場合は何もしません。
+func (s *Session) StartHeadTrack(now time.Time) {
+	if s.HeadStartedAt.IsZero() {
+		s.HeadStartedAt = now
+	}
+}
+
+// EndHeadTrack はheadの曲の再生が終わったことを記録し、再生履歴を返します。
+// headの曲の再生が始まっていない場合はnilを返します。
+func (s *Session) EndHeadTrack(reason TrackEndReason, now time.Time) *PlayedTrack {
+	if s.HeadStartedAt.IsZero() || s.QueueHead >= len(s.QueueTracks) {
+		return nil
+	}
+
+	played := &PlayedTrack{
+		SessionID: s.ID,
+		URI:       s.HeadTrack().URI,
+		StartedAt: s.HeadStartedAt,
+		EndedAt:   now,
+		EndReason: reason,
+	}
+	s.HeadStartedAt = time.Time{}
+	return played
+}
+
 // IsResume は次のStateTypeへの移行がポーズからの再開かどうかを返します。
 func (s *Session) IsResume(nextState StateType) bool {
 	return s.StateType == Pause && nextState == Play
@@ -161,24 +252,479 @@ func (s *Session) TrackURIsShouldBeAddedWhenStopToPlay() ([]string, error) {
 		return []string{}, fmt.Errorf("can not to move to play: %w", err)
 	}
 
-	var uris []string
-	for i := 0; i < 3; i++ {
-		trackIndex := i + s.QueueHead
-		uris = append(uris, s.QueueTracks[trackIndex].URI)
-		if (len(s.QueueTracks) - s.QueueHead) == i+1 {
-			break
-		}
+	uris := []string{s.QueueTracks[s.QueueHead].URI}
+	for _, index := range s.lookaheadIndexes() {
+		uris = append(uris, s.QueueTracks[index].URI)
 	}
 	return uris, nil
 }
 
 // TrackURIShouldBeAddedWhenHandleTrackEnd はある一曲の再生が終わったときにSpotifyのキューに追加するTrackURIを抽出します。
 func (s *Session) TrackURIShouldBeAddedWhenHandleTrackEnd() string {
-	if (len(s.QueueTracks) - s.QueueHead) < 3 {
+	indexes := s.lookaheadIndexes()
+	if len(indexes) < spotifyLookaheadTracks {
 		return ""
 	}
-	index := s.QueueHead + 2
-	return s.QueueTracks[index].URI
+	return s.QueueTracks[indexes[spotifyLookaheadTracks-1]].URI
+}
+
+// TrackURIsInSpotifyQueue は現在Spotifyのキューに先読みして積まれているはずのTrackURIを返します。
+// PLAYとPAUSEのときはheadの次の曲から最大2曲がSpotifyのキューに積まれています。
+func (s *Session) TrackURIsInSpotifyQueue() []string {
+	if s.StateType != Play && s.StateType != Pause {
+		return []string{}
+	}
+
+	uris := []string{}
+	for _, index := range s.lookaheadIndexes() {
+		uris = append(uris, s.QueueTracks[index].URI)
+	}
+	return uris
+}
+
+// lookaheadIndexes はheadの次からSpotifyのキューに先読みして積む最大spotifyLookaheadTracks曲のindexを返します。
+// RepeatQueueが有効なときは、キューの最後まで来たら最初の曲に戻って数えます。
+func (s *Session) lookaheadIndexes() []int {
+	indexes := []int{}
+	for i := 1; i <= spotifyLookaheadTracks; i++ {
+		index := s.QueueHead + i
+		if index >= len(s.QueueTracks) {
+			if !s.RepeatQueue || len(s.QueueTracks) == 0 {
+				break
+			}
+			index %= len(s.QueueTracks)
+		}
+		indexes = append(indexes, index)
+	}
+	return indexes
+}
+
+// RemoveQueueTrack はキューのindex番目の曲を削除し、後ろの曲のindexを詰めます。
+// 再生済みの曲と再生中の曲は削除できません。
+func (s *Session) RemoveQueueTrack(index int) (*QueueTrack, error) {
+	if err := s.canEditQueueTrack(index); err != nil {
+		return nil, fmt.Errorf("remove queue track index=%d: %w", index, err)
+	}
+
+	removed := s.QueueTracks[index]
+	s.QueueTracks = append(s.QueueTracks[:index:index], s.QueueTracks[index+1:]...)
+	s.reindexQueueTracks()
+	return removed, nil
+}
+
+// MoveQueueTrack はキューのfrom番目の曲をto番目に移動し、間の曲のindexをずらします。
+// 再生済みの曲と再生中の曲は移動できず、それらの位置に移動することもできません。
+// 投票モードと公平モードのときは曲の並びはモードによって決まるので移動できません。
+func (s *Session) MoveQueueTrack(from, to int) error {
+	if s.QueueMode.IsAutoArranged() {
+		return ErrQueueTrackNotMovable
+	}
+	if err := s.canEditQueueTrack(from); err != nil {
+		return fmt.Errorf("move queue track from=%d: %w", from, err)
+	}
+	if err := s.canEditQueueTrack(to); err != nil {
+		return fmt.Errorf("move queue track to=%d: %w", to, err)
+	}
+
+	moved := s.QueueTracks[from]
+	if from < to {
+		copy(s.QueueTracks[from:to], s.QueueTracks[from+1:to+1])
+	} else {
+		copy(s.QueueTracks[to+1:from+1], s.QueueTracks[to:from])
+	}
+	s.QueueTracks[to] = moved
+	s.reindexQueueTracks()
+	return nil
+}
+
+// ShuffleQueueTracks はキューのまだ再生されていない曲をrndを使ってランダムに並び替えます。
+// indexが変わった範囲の先頭のindexを返します。
+// 投票モードと公平モードのときは曲の並びはモードによって決まるので並び替えられません。
+func (s *Session) ShuffleQueueTracks(rnd *rand.Rand) (int, error) {
+	if s.QueueMode.IsAutoArranged() {
+		return 0, ErrQueueTrackNotMovable
+	}
+
+	start := s.firstPendingIndex()
+	pending := s.QueueTracks[start:]
+	rnd.Shuffle(len(pending), func(i, j int) {
+		pending[i], pending[j] = pending[j], pending[i]
+	})
+	s.reindexQueueTracks()
+	return start, nil
+}
+
+// MoveQueueTracksToNext はキューの末尾のcount曲を、次に再生されるように未再生の曲の先頭に移動します。
+// indexが変わった範囲の先頭のindexを返します。
+// 投票モードと公平モードのときは曲の並びはモードによって決まるので移動できません。
+func (s *Session) MoveQueueTracksToNext(count int) (int, error) {
+	if s.QueueMode.IsAutoArranged() {
+		return 0, ErrQueueTrackNotMovable
+	}
+	start := s.firstPendingIndex()
+	end := len(s.QueueTracks) - count
+	if count < 0 || end < start {
+		return 0, fmt.Errorf("move queue tracks to next count=%d: %w", count, ErrQueueTrackNotEditable)
+	}
+
+	moved := append([]*QueueTrack{}, s.QueueTracks[end:]...)
+	copy(s.QueueTracks[start+count:], s.QueueTracks[start:end])
+	copy(s.QueueTracks[start:], moved)
+	s.reindexQueueTracks()
+	return start, nil
+}
+
+// VotableQueueTrack は投票の対象となるキューのindex番目の曲を返します。
+// 再生済みの曲と再生中の曲には投票できません。
+// Spotifyのキューに先読みして積まれている曲は並び替えられないので、投票もできません。
+func (s *Session) VotableQueueTrack(index int) (*QueueTrack, error) {
+	if s.QueueMode != QueueModeVote {
+		return nil, ErrQueueModeIsNotVote
+	}
+	if err := s.canEditQueueTrack(index); err != nil {
+		return nil, fmt.Errorf("vote queue track index=%d: %w", index, err)
+	}
+	if index < s.firstArrangeableIndex() {
+		return nil, fmt.Errorf("vote queue track index=%d: %w", index, ErrQueueTrackNotVotable)
+	}
+	return s.QueueTracks[index], nil
+}
+
+// ArrangeQueueTracks はキューのモードに従って未再生の曲を並び替え、並びが変わりうる範囲の先頭のindexを返します。
+// 投票モードのときはスコアの高い順に並び替え、スコアが同じ曲は追加された順に並べます。
+// 公平モードのときは曲を追加した人ごとに、追加された順で1曲ずつ交互に再生されるように並び替えます。
+// いずれのモードでもラジオモードで自動で追加された曲は、ユーザが追加した曲の後ろに並べます。
+// Spotifyのキューに先読みして積まれている曲は並び替えの対象外です。
+func (s *Session) ArrangeQueueTracks() int {
+	start := s.firstArrangeableIndex()
+	tracks := s.QueueTracks[start:]
+
+	switch s.QueueMode {
+	case QueueModeVote:
+		sort.SliceStable(tracks, func(i, j int) bool {
+			if tracks[i].AddedBySystem != tracks[j].AddedBySystem {
+				return !tracks[i].AddedBySystem
+			}
+			if tracks[i].Score != tracks[j].Score {
+				return tracks[i].Score > tracks[j].Score
+			}
+			return tracks[i].ID < tracks[j].ID
+		})
+	case QueueModeFair:
+		rounds := s.fairRounds()
+		sort.SliceStable(tracks, func(i, j int) bool {
+			if tracks[i].AddedBySystem != tracks[j].AddedBySystem {
+				return !tracks[i].AddedBySystem
+			}
+			if rounds[tracks[i]] != rounds[tracks[j]] {
+				return rounds[tracks[i]] < rounds[tracks[j]]
+			}
+			// 同じ巡目の中では今の並び順を保つことで、再生が進んでも人の順番が入れ替わらないようにする
+			return tracks[i].Index < tracks[j].Index
+		})
+	default:
+		return len(s.QueueTracks)
+	}
+
+	s.reindexQueueTracks()
+	return start
+}
+
+// UpdateSettings はセッションの設定を変更します。
+func (s *Session) UpdateSettings(settings *SessionSettingsToUpdate) error {
+	if (settings.MaxPendingTracksPerUser != nil && *settings.MaxPendingTracksPerUser < 0) ||
+		(settings.EnqueueRateLimit != nil && *settings.EnqueueRateLimit < 0) ||
+		(settings.EnqueueRateWindow != nil && *settings.EnqueueRateWindow < 0) ||
+		(settings.MaxVolume != nil && !isValidVolume(*settings.MaxVolume)) ||
+		(settings.SleepAfterTracks != nil && *settings.SleepAfterTracks < 0) {
+		return ErrInvalidSessionSettings
+	}
+
+	if settings.QueueMode != nil {
+		s.QueueMode = *settings.QueueMode
+	}
+	if settings.MaxPendingTracksPerUser != nil {
+		s.MaxPendingTracksPerUser = *settings.MaxPendingTracksPerUser
+	}
+	if settings.EnqueueRateLimit != nil {
+		s.EnqueueRateLimit = *settings.EnqueueRateLimit
+	}
+	if settings.EnqueueRateWindow != nil {
+		s.EnqueueRateWindow = *settings.EnqueueRateWindow
+	}
+	if settings.RejectDuplicateTracks != nil {
+		s.RejectDuplicateTracks = *settings.RejectDuplicateTracks
+	}
+	if settings.RadioMode != nil {
+		s.RadioMode = *settings.RadioMode
+	}
+	if settings.MaxVolume != nil {
+		s.MaxVolume = *settings.MaxVolume
+	}
+	if settings.DeviceFallback != nil {
+		s.DeviceFallback = *settings.DeviceFallback
+	}
+	if settings.RepeatQueue != nil {
+		s.RepeatQueue = *settings.RepeatQueue
+	}
+	if settings.SleepAfterTracks != nil {
+		s.SleepAfterTracks = *settings.SleepAfterTracks
+	}
+	if settings.SleepAt != nil {
+		s.SleepAt = *settings.SleepAt
+	}
+	if settings.ScheduledStartAt != nil {
+		s.ScheduledStartAt = *settings.ScheduledStartAt
+	}
+	return nil
+}
+
+// SleepOnTrackEnd は曲の再生が終わったときやスキップされたときに、スリープタイマーで再生を止めるべきかどうかとその理由を返します。
+// 曲数が指定されているときは残りの曲数を1曲減らします。再生を止めるときはスリープタイマーを解除します。
+func (s *Session) SleepOnTrackEnd(now time.Time) (SleepReason, bool) {
+	if s.SleepAfterTracks > 0 {
+		s.SleepAfterTracks--
+		if s.SleepAfterTracks == 0 {
+			s.SleepAt = time.Time{}
+			return SleepReasonTrackCount, true
+		}
+	}
+	if !s.SleepAt.IsZero() && !now.Before(s.SleepAt) {
+		s.SleepAfterTracks = 0
+		s.SleepAt = time.Time{}
+		return SleepReasonTime, true
+	}
+	return "", false
+}
+
+// NextSleepAt は"15:04Z07:00"形式の時刻から、now以降で最初にその時刻になる日時を返します。
+func NextSleepAt(clock string, now time.Time) (time.Time, error) {
+	t, err := time.Parse("15:04Z07:00", clock)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse sleep at clock=%s: %v: %w", clock, err, ErrInvalidSessionSettings)
+	}
+
+	local := now.In(t.Location())
+	at := time.Date(local.Year(), local.Month(), local.Day(), t.Hour(), t.Minute(), 0, 0, t.Location())
+	if !at.After(now) {
+		at = at.AddDate(0, 0, 1)
+	}
+	return at.UTC(), nil
+}
+
+// NewScheduledStartAt はRFC3339形式の日時から再生を開始する予約日時を生成します。now以前の日時は予約できません。
+func NewScheduledStartAt(value string, now time.Time) (time.Time, error) {
+	at, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse scheduled start at value=%s: %v: %w", value, err, ErrInvalidSessionSettings)
+	}
+	if !at.After(now) {
+		return time.Time{}, fmt.Errorf("scheduled start at %s is not after %s: %w", at, now, ErrInvalidSessionSettings)
+	}
+	return at.UTC(), nil
+}
+
+// IsScheduledToStart は再生開始の予約日時になっているかどうか返します。
+func (s *Session) IsScheduledToStart(now time.Time) bool {
+	return !s.ScheduledStartAt.IsZero() && !now.Before(s.ScheduledStartAt)
+}
+
+// FallbackDeviceID は再生するデバイスが見つからなかったときに代わりに使うデバイスのIDを返します。
+// 前回使っていたデバイスが使えればそれを、そうでなければ使えるデバイスが1台だけのときにそのデバイスを選びます。
+func (s *Session) FallbackDeviceID(devices []*Device) (string, bool) {
+	var usable []*Device
+	for _, d := range devices {
+		if d.IsRestricted {
+			continue
+		}
+		if s.DeviceID != "" && d.ID == s.DeviceID {
+			return d.ID, true
+		}
+		usable = append(usable, d)
+	}
+	if len(usable) == 1 {
+		return usable[0].ID, true
+	}
+	return "", false
+}
+
+// VolumeToSet はAPIから指定された音量を、セッションの音量の上限を超えないように丸めて返します。
+func (s *Session) VolumeToSet(volume int) (int, error) {
+	if !isValidVolume(volume) {
+		return 0, fmt.Errorf("volume=%d: %w", volume, ErrInvalidVolume)
+	}
+	if s.MaxVolume > 0 && volume > s.MaxVolume {
+		return s.MaxVolume, nil
+	}
+	return volume, nil
+}
+
+// isValidVolume は音量が0%から100%の範囲内かどうかを返します。
+func isValidVolume(volume int) bool {
+	return 0 <= volume && volume <= 100
+}
+
+// CanEnqueue はユーザが曲をキューに追加しても良いかどうか返します。
+// ユーザはログインしている場合はユーザID、ゲストの場合はニックネームで識別します。
+func (s *Session) CanEnqueue(uri, userID, nickname string, now time.Time) error {
+	start := s.firstPendingIndex()
+
+	if s.RejectDuplicateTracks {
+		for _, qt := range s.QueueTracks[start:] {
+			if qt.URI == uri {
+				return fmt.Errorf("uri=%s: %w", uri, ErrDuplicateQueueTrack)
+			}
+		}
+	}
+
+	if s.MaxPendingTracksPerUser > 0 {
+		pending := 0
+		for _, qt := range s.QueueTracks[start:] {
+			if qt.IsAddedBy(userID, nickname) {
+				pending++
+			}
+		}
+		if pending >= s.MaxPendingTracksPerUser {
+			return fmt.Errorf("pending=%d: %w", pending, ErrTooManyPendingQueueTracks)
+		}
+	}
+
+	if s.EnqueueRateLimit > 0 && s.EnqueueRateWindow > 0 {
+		recent := 0
+		for _, qt := range s.QueueTracks {
+			if qt.IsAddedBy(userID, nickname) && now.Sub(qt.AddedAt) < s.EnqueueRateWindow {
+				recent++
+			}
+		}
+		if recent >= s.EnqueueRateLimit {
+			return fmt.Errorf("recent=%d: %w", recent, ErrEnqueueRateLimitExceeded)
+		}
+	}
+	return nil
+}
+
+// AppendQueueTrack はキューの末尾に曲を追加します。
+// 複数の曲をまとめて追加する際に、追加済みの曲を考慮してCanEnqueueで判定するために使います。
+func (s *Session) AppendQueueTrack(qt *QueueTrackToStore) {
+	s.QueueTracks = append(s.QueueTracks, &QueueTrack{
+		Index:           len(s.QueueTracks),
+		URI:             qt.URI,
+		SessionID:       qt.SessionID,
+		AddedByUserID:   qt.AddedByUserID,
+		AddedByNickname: qt.AddedByNickname,
+		AddedBySystem:   qt.AddedBySystem,
+		AddedAt:         qt.AddedAt,
+	})
+}
+
+// RadioTrackCountToFill はラジオモードのときに、未再生の曲がradioMinPendingTracks曲になるまでに追加すべき曲数を返します。
+// ラジオモードでないときや未再生の曲が十分にあるときは0を返します。
+// キューを繰り返すときは再生する曲が無くならないので、ラジオモードでも0を返します。
+func (s *Session) RadioTrackCountToFill() int {
+	if !s.RadioMode || s.RepeatQueue {
+		return 0
+	}
+	pending := len(s.QueueTracks) - s.firstPendingIndex()
+	if pending >= radioMinPendingTracks {
+		return 0
+	}
+	return radioMinPendingTracks - pending
+}
+
+// RadioSeedTrackURIs はおすすめの曲を取得する際の元にする、最近再生された曲のTrackURIを最大radioMaxSeedTracks曲返します。
+func (s *Session) RadioSeedTrackURIs() []string {
+	played := s.QueueTracks[:s.firstPendingIndex()]
+	if len(played) > radioMaxSeedTracks {
+		played = played[len(played)-radioMaxSeedTracks:]
+	}
+
+	uris := make([]string, len(played))
+	for i, qt := range played {
+		uris[i] = qt.URI
+	}
+	return uris
+}
+
+// TrackURIsNotInQueue は与えられたTrackURIのうち、キューに含まれていない曲のTrackURIを重複を除いて返します。
+func (s *Session) TrackURIsNotInQueue(uris []string) []string {
+	exists := make(map[string]bool, len(s.QueueTracks)+len(uris))
+	for _, qt := range s.QueueTracks {
+		exists[qt.URI] = true
+	}
+
+	notInQueue := make([]string, 0, len(uris))
+	for _, uri := range uris {
+		if exists[uri] {
+			continue
+		}
+		exists[uri] = true
+		notInQueue = append(notInQueue, uri)
+	}
+	return notInQueue
+}
+
+// firstPendingIndex はまだ再生されていない曲のうち先頭の曲のindexを返します。
+// STOPのときはheadの曲はまだ再生されていませんが、PLAYとPAUSEのときはheadの曲は再生中です。
+func (s *Session) firstPendingIndex() int {
+	start := s.QueueHead + 1
+	if s.StateType == Stop || s.StateType == Archived {
+		start = s.QueueHead
+	}
+	if start > len(s.QueueTracks) {
+		return len(s.QueueTracks)
+	}
+	return start
+}
+
+// firstArrangeableIndex はキューの並び替えの対象となる範囲の先頭のindexを返します。
+// PLAYとPAUSEのときはheadの曲とSpotifyのキューに積まれている曲を、STOPのときはheadより前の再生済みの曲を除きます。
+func (s *Session) firstArrangeableIndex() int {
+	start := s.QueueHead
+	if s.StateType == Play || s.StateType == Pause {
+		start = s.QueueHead + 3
+	}
+	if start > len(s.QueueTracks) {
+		return len(s.QueueTracks)
+	}
+	return start
+}
+
+// canEditQueueTrack はキューのindex番目の曲を削除・移動しても良いかどうか返します。
+// まだ再生されていない曲のみ編集できます。
+func (s *Session) canEditQueueTrack(index int) error {
+	if index < 0 || len(s.QueueTracks) <= index {
+		return ErrQueueTrackNotFound
+	}
+
+	if index < s.firstPendingIndex() {
+		return ErrQueueTrackNotEditable
+	}
+	return nil
+}
+
+// fairRounds は公平モードでまだ再生されていない曲がそれぞれ何巡目に再生されるべきかを返します。
+// 曲を追加した人ごとに、まだ再生されていない曲のうち追加された順で何曲目にあたるかを巡目とします。
+func (s *Session) fairRounds() map[*QueueTrack]int {
+	pending := make([]*QueueTrack, len(s.QueueTracks)-s.firstPendingIndex())
+	copy(pending, s.QueueTracks[s.firstPendingIndex():])
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].ID < pending[j].ID
+	})
+
+	rounds := make(map[*QueueTrack]int, len(pending))
+	counts := map[string]int{}
+	for _, qt := range pending {
+		rounds[qt] = counts[qt.contributor()]
+		counts[qt.contributor()]++
+	}
+	return rounds
+}
+
+// reindexQueueTracks はキューの曲のIndexを現在の並び順に合わせます。
+func (s *Session) reindexQueueTracks() {
+	for i, qt := range s.QueueTracks {
+		qt.Index = i
+	}
 }
 
 // canMoveFromStopToPlay はセッションのStateTypeをStopからPlayに状態遷移しても良いかどうか返します。
@@ -267,3 +813,72 @@ func NewStateType(stateType string) (StateType, error) {
 func (st StateType) String() string {
 	return string(st)
 }
+
+// QueueMode はキューの曲を再生する順番の決め方を表します。
+type QueueMode string
+
+const (
+	// QueueModeFIFO は追加された順に曲を再生するモードです。
+	QueueModeFIFO QueueMode = "FIFO"
+	// QueueModeVote は参加者の投票によるスコアの高い順に曲を再生するモードです。
+	QueueModeVote QueueMode = "VOTE"
+	// QueueModeFair は曲を追加した人ごとに順番に曲を再生するモードです。
+	QueueModeFair QueueMode = "FAIR"
+)
+
+var queueModes = []QueueMode{QueueModeFIFO, QueueModeVote, QueueModeFair}
+
+const (
+	// radioMinPendingTracks はラジオモードのときに保つ未再生の曲数です。
+	radioMinPendingTracks = 3
+	// radioMaxSeedTracks はおすすめの曲を取得する際の元にできる曲数の上限です。Spotify APIの制限に合わせています。
+	radioMaxSeedTracks = 5
+	// prevTrackThreshold は前の曲に戻る操作で、前の曲に戻るか現在の曲を最初から再生し直すかを分ける再生位置です。
+	prevTrackThreshold = 3 * time.Second
+	// spotifyLookaheadTracks はheadの曲の次からSpotifyのキューに先読みして積んでおく曲数です。
+	spotifyLookaheadTracks = 2
+)
+
+// SleepReason はスリープタイマーで再生を止めた理由を表します。
+type SleepReason string
+
+const (
+	// SleepReasonTrackCount は指定された曲数を再生し終えたことを表します。
+	SleepReasonTrackCount SleepReason = "TRACK_COUNT"
+	// SleepReasonTime は指定された時刻を過ぎたことを表します。
+	SleepReasonTime SleepReason = "TIME"
+)
+
+// ScheduledStartFailure は予約された再生の開始に失敗した理由を表します。
+type ScheduledStartFailure string
+
+const (
+	// ScheduledStartFailureActiveDeviceNotFound は再生するデバイスが見つからなかったことを表します。
+	ScheduledStartFailureActiveDeviceNotFound ScheduledStartFailure = "ACTIVE_DEVICE_NOT_FOUND"
+	// ScheduledStartFailureQueueTrackNotFound は再生する曲がキューに無かったことを表します。
+	ScheduledStartFailureQueueTrackNotFound ScheduledStartFailure = "QUEUE_TRACK_NOT_FOUND"
+	// ScheduledStartFailureStateNotPermit はセッションの状態から再生を開始できなかったことを表します。
+	ScheduledStartFailureStateNotPermit ScheduledStartFailure = "STATE_NOT_PERMIT"
+	// ScheduledStartFailureUnknown はその他の理由を表します。
+	ScheduledStartFailureUnknown ScheduledStartFailure = "UNKNOWN"
+)
+
+// NewQueueMode はstringから対応するQueueModeを生成します。
+func NewQueueMode(queueMode string) (QueueMode, error) {
+	for _, qm := range queueModes {
+		if qm.String() == queueMode {
+			return qm, nil
+		}
+	}
+	return "", fmt.Errorf("queueMode = %s:%w", queueMode, ErrInvalidQueueMode)
+}
+
+// String はfmt.Stringerを満たすメソッドです。
+func (qm QueueMode) String() string {
+	return string(qm)
+}
+
+// IsAutoArranged はキューの曲の並びがモードによって自動で決まるかどうか返します。
+func (qm QueueMode) IsAutoArranged() bool {
+	return qm == QueueModeVote || qm == QueueModeFair
+}
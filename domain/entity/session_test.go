@@ -1,7 +1,10 @@
 package entity
 
 import (
+	"errors"
+	"math/rand"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -10,6 +13,7 @@ import (
 func TestNewSession(t *testing.T) {
 	t.Parallel()
 
+	now := time.Date(2020, 1, 1, 9, 0, 0, 0, time.FixedZone("Asia/Tokyo", 9*60*60))
 	session := &Session{
 		ID:                     "ID",
 		Name:                   "VeryGoodSession",
@@ -17,7 +21,9 @@ func TestNewSession(t *testing.T) {
 		StateType:              Stop,
 		QueueHead:              0,
 		QueueTracks:            nil,
+		ExpiredAt:              time.Date(2020, 1, 4, 0, 0, 0, 0, time.UTC),
 		AllowToControlByOthers: true,
+		QueueMode:              QueueModeFIFO,
 	}
 
 	tests := []struct {
@@ -37,11 +43,11 @@ func TestNewSession(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := NewSession(tt.sessionName, tt.creatorID, tt.allowToCOntrolByOthers)
+			got, err := NewSession(tt.sessionName, tt.creatorID, tt.allowToCOntrolByOthers, now)
 			if err != nil {
 				t.Fatal(err)
 			}
-			opts := []cmp.Option{cmpopts.IgnoreFields(Session{}, "ID"), cmpopts.IgnoreFields(Session{}, "ExpiredAt")}
+			opts := []cmp.Option{cmpopts.IgnoreFields(Session{}, "ID")}
 			if !cmp.Equal(got, tt.want, opts...) {
 				t.Errorf("NewSession() diff = %v", cmp.Diff(got, tt.want, opts...))
 			}
@@ -97,6 +103,54 @@ func TestNewStateType(t *testing.T) {
 	}
 }
 
+func TestNewQueueMode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		queueMode string
+		want      QueueMode
+		wantErr   bool
+	}{
+		{
+			name:      "FIFO",
+			queueMode: "FIFO",
+			want:      QueueModeFIFO,
+			wantErr:   false,
+		},
+		{
+			name:      "Vote",
+			queueMode: "VOTE",
+			want:      QueueModeVote,
+			wantErr:   false,
+		},
+		{
+			name:      "Fair",
+			queueMode: "FAIR",
+			want:      QueueModeFair,
+			wantErr:   false,
+		},
+		{
+			name:      "無効なqueue mode",
+			queueMode: "invalid",
+			want:      "",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewQueueMode(tt.queueMode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewQueueMode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("NewQueueMode() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestStateType_String(t *testing.T) {
 	t.Parallel()
 
@@ -251,9 +305,10 @@ func TestSession_GoNextTrack(t *testing.T) {
 	t.Parallel()
 
 	tests := []struct {
-		name    string
-		s       *Session
-		wantErr bool
+		name          string
+		s             *Session
+		wantQueueHead int
+		wantErr       bool
 	}{
 		{
 			name: "一つも曲が追加されてないときはエラー",
@@ -261,7 +316,8 @@ func TestSession_GoNextTrack(t *testing.T) {
 				QueueHead:   0,
 				QueueTracks: nil,
 			},
-			wantErr: true,
+			wantQueueHead: 0,
+			wantErr:       true,
 		},
 		{
 			name: "最後の曲を再生していたときはエラー",
@@ -273,7 +329,8 @@ func TestSession_GoNextTrack(t *testing.T) {
 					{}, // 再生中
 				},
 			},
-			wantErr: true,
+			wantQueueHead: 3,
+			wantErr:       true,
 		},
 		{
 			name: "次の曲が存在するときはエラーにならない",
@@ -286,7 +343,33 @@ func TestSession_GoNextTrack(t *testing.T) {
 					{},
 				},
 			},
-			wantErr: false,
+			wantQueueHead: 3,
+			wantErr:       false,
+		},
+		{
+			name: "キューのリピートが有効なときは最後の曲の次は最初の曲に戻る",
+			s: &Session{
+				QueueHead: 2,
+				QueueTracks: []*QueueTrack{
+					{},
+					{},
+					{}, // 再生中
+				},
+				StateType:   Play,
+				RepeatQueue: true,
+			},
+			wantQueueHead: 0,
+			wantErr:       false,
+		},
+		{
+			name: "キューのリピートが有効でも一つも曲が追加されてないときはエラー",
+			s: &Session{
+				QueueHead:   0,
+				QueueTracks: nil,
+				RepeatQueue: true,
+			},
+			wantQueueHead: 0,
+			wantErr:       true,
 		},
 	}
 	for _, tt := range tests {
@@ -294,6 +377,169 @@ func TestSession_GoNextTrack(t *testing.T) {
 			if err := tt.s.GoNextTrack(); (err != nil) != tt.wantErr {
 				t.Errorf("GoNextTrack() error = %v, wantErr %v", err, tt.wantErr)
 			}
+			if tt.s.QueueHead != tt.wantQueueHead {
+				t.Errorf("GoNextTrack() QueueHead = %d, want %d", tt.s.QueueHead, tt.wantQueueHead)
+			}
+		})
+	}
+}
+
+func TestSession_JumpToTrack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                   string
+		s                      *Session
+		index                  int
+		wantQueueHead          int
+		wantProgressWhenPaused time.Duration
+		wantErr                error
+	}{
+		{
+			name: "後ろの曲に移動できる",
+			s: &Session{
+				QueueHead:          0,
+				QueueTracks:        []*QueueTrack{{}, {}, {}, {}},
+				ProgressWhenPaused: 10 * time.Second,
+			},
+			index:                  3,
+			wantQueueHead:          3,
+			wantProgressWhenPaused: 0,
+			wantErr:                nil,
+		},
+		{
+			name: "前の曲に移動できる",
+			s: &Session{
+				QueueHead:          3,
+				QueueTracks:        []*QueueTrack{{}, {}, {}, {}},
+				ProgressWhenPaused: 10 * time.Second,
+			},
+			index:                  1,
+			wantQueueHead:          1,
+			wantProgressWhenPaused: 0,
+			wantErr:                nil,
+		},
+		{
+			name: "存在しないindexのときはErrQueueTrackNotFound",
+			s: &Session{
+				QueueHead:          0,
+				QueueTracks:        []*QueueTrack{{}, {}},
+				ProgressWhenPaused: 10 * time.Second,
+			},
+			index:                  2,
+			wantQueueHead:          0,
+			wantProgressWhenPaused: 10 * time.Second,
+			wantErr:                ErrQueueTrackNotFound,
+		},
+		{
+			name: "負のindexのときはErrQueueTrackNotFound",
+			s: &Session{
+				QueueHead:          0,
+				QueueTracks:        []*QueueTrack{{}, {}},
+				ProgressWhenPaused: 10 * time.Second,
+			},
+			index:                  -1,
+			wantQueueHead:          0,
+			wantProgressWhenPaused: 10 * time.Second,
+			wantErr:                ErrQueueTrackNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.s.JumpToTrack(tt.index); !errors.Is(err, tt.wantErr) {
+				t.Errorf("JumpToTrack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.s.QueueHead != tt.wantQueueHead {
+				t.Errorf("JumpToTrack() QueueHead = %d, want %d", tt.s.QueueHead, tt.wantQueueHead)
+			}
+			if tt.s.ProgressWhenPaused != tt.wantProgressWhenPaused {
+				t.Errorf("JumpToTrack() ProgressWhenPaused = %v, want %v", tt.s.ProgressWhenPaused, tt.wantProgressWhenPaused)
+			}
+		})
+	}
+}
+
+func TestSession_GoPrevTrack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                   string
+		s                      *Session
+		wantQueueHead          int
+		wantProgressWhenPaused time.Duration
+		wantErr                bool
+	}{
+		{
+			name: "最初の曲を再生していたときはエラー",
+			s: &Session{
+				QueueHead:          0,
+				QueueTracks:        []*QueueTrack{{}, {}},
+				ProgressWhenPaused: 10 * time.Second,
+			},
+			wantQueueHead:          0,
+			wantProgressWhenPaused: 10 * time.Second,
+			wantErr:                true,
+		},
+		{
+			name: "前の曲が存在するときはheadが一つ戻り、再生位置がリセットされる",
+			s: &Session{
+				QueueHead:          1,
+				QueueTracks:        []*QueueTrack{{}, {}},
+				ProgressWhenPaused: 10 * time.Second,
+			},
+			wantQueueHead:          0,
+			wantProgressWhenPaused: 0,
+			wantErr:                false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.s.GoPrevTrack(); (err != nil) != tt.wantErr {
+				t.Errorf("GoPrevTrack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.s.QueueHead != tt.wantQueueHead {
+				t.Errorf("GoPrevTrack() QueueHead = %d, want %d", tt.s.QueueHead, tt.wantQueueHead)
+			}
+			if tt.s.ProgressWhenPaused != tt.wantProgressWhenPaused {
+				t.Errorf("GoPrevTrack() ProgressWhenPaused = %v, want %v", tt.s.ProgressWhenPaused, tt.wantProgressWhenPaused)
+			}
+		})
+	}
+}
+
+func TestSession_ShouldGoPrevTrack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		s        *Session
+		progress time.Duration
+		want     bool
+	}{
+		{
+			name:     "再生が始まってすぐで前の曲が存在するときはtrue",
+			s:        &Session{QueueHead: 1, QueueTracks: []*QueueTrack{{}, {}}},
+			progress: 2 * time.Second,
+			want:     true,
+		},
+		{
+			name:     "再生が始まってから時間が経っているときはfalse",
+			s:        &Session{QueueHead: 1, QueueTracks: []*QueueTrack{{}, {}}},
+			progress: 3 * time.Second,
+			want:     false,
+		},
+		{
+			name:     "前の曲が存在しないときはfalse",
+			s:        &Session{QueueHead: 0, QueueTracks: []*QueueTrack{{}, {}}},
+			progress: 0,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.ShouldGoPrevTrack(tt.progress); got != tt.want {
+				t.Errorf("ShouldGoPrevTrack() = %v, want %v", got, tt.want)
+			}
 		})
 	}
 }
@@ -357,6 +603,17 @@ func TestSession_TrackURIsShouldBeAddedWhenStopToPlay(t *testing.T) {
 			want:    []string{"0", "1", "2"},
 			wantErr: false,
 		},
+		{
+			name: "キューのリピートが有効なときは最後の曲の次にキューの最初の曲が続く",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}},
+				QueueHead:   2,
+				StateType:   Stop,
+				RepeatQueue: true,
+			},
+			want:    []string{"2", "0", "1"},
+			wantErr: false,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -399,6 +656,16 @@ func TestSession_TrackURIShouldBeAddedWhenHandleTrackEnd(t *testing.T) {
 			},
 			want: "",
 		},
+		{
+			name: "キューのリピートが有効なときはキューの最初に戻って二曲先のTrackのURIが返る",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}, {URI: "3"}},
+				QueueHead:   3,
+				StateType:   Play,
+				RepeatQueue: true,
+			},
+			want: "1",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -412,6 +679,1420 @@ func TestSession_TrackURIShouldBeAddedWhenHandleTrackEnd(t *testing.T) {
 	}
 }
 
+func TestSession_TrackURIsInSpotifyQueue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    *Session
+		want []string
+	}{
+		{
+			name: "PLAYのときはheadの後ろ二曲のURIが返る",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}, {URI: "3"}},
+				QueueHead:   0,
+				StateType:   Play,
+			},
+			want: []string{"1", "2"},
+		},
+		{
+			name: "PAUSEのときに後ろに一曲しか無いときは一曲だけ返る",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}, {URI: "3"}},
+				QueueHead:   2,
+				StateType:   Pause,
+			},
+			want: []string{"3"},
+		},
+		{
+			name: "キューのリピートが有効なときはキューの最初に戻って二曲返る",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}, {URI: "3"}},
+				QueueHead:   2,
+				StateType:   Play,
+				RepeatQueue: true,
+			},
+			want: []string{"3", "0"},
+		},
+		{
+			name: "STOPのときはSpotifyのキューに曲は積まれていないので空",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}, {URI: "3"}},
+				QueueHead:   0,
+				StateType:   Stop,
+			},
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.TrackURIsInSpotifyQueue(); !cmp.Equal(got, tt.want) {
+				t.Errorf("TrackURIsInSpotifyQueue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSession_RemoveQueueTrack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		s       *Session
+		index   int
+		want    *QueueTrack
+		wantQT  []*QueueTrack
+		wantErr error
+	}{
+		{
+			name: "PLAYのときにheadより後ろの曲を削除すると後ろの曲が詰められる",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}, {ID: 4, Index: 3}},
+				QueueHead:   0,
+				StateType:   Play,
+			},
+			index:   1,
+			want:    &QueueTrack{ID: 2, Index: 1},
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}, {ID: 3, Index: 1}, {ID: 4, Index: 2}},
+			wantErr: nil,
+		},
+		{
+			name: "STOPのときはheadの曲を削除できる",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}},
+				QueueHead:   1,
+				StateType:   Stop,
+			},
+			index:   1,
+			want:    &QueueTrack{ID: 2, Index: 1},
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}},
+			wantErr: nil,
+		},
+		{
+			name: "PLAYのときは再生中の曲は削除できない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}},
+				QueueHead:   0,
+				StateType:   Play,
+			},
+			index:   0,
+			want:    nil,
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}},
+			wantErr: ErrQueueTrackNotEditable,
+		},
+		{
+			name: "存在しないindexを指定するとErrQueueTrackNotFound",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}},
+				QueueHead:   0,
+				StateType:   Play,
+			},
+			index:   2,
+			want:    nil,
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}},
+			wantErr: ErrQueueTrackNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.s.RemoveQueueTrack(tt.index)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("RemoveQueueTrack() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("RemoveQueueTrack() diff = %v", cmp.Diff(tt.want, got))
+			}
+			if !cmp.Equal(tt.s.QueueTracks, tt.wantQT) {
+				t.Errorf("RemoveQueueTrack() QueueTracks diff = %v", cmp.Diff(tt.wantQT, tt.s.QueueTracks))
+			}
+		})
+	}
+}
+
+func TestSession_MoveQueueTrack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		s       *Session
+		from    int
+		to      int
+		wantQT  []*QueueTrack
+		wantErr error
+	}{
+		{
+			name: "後ろに移動すると間の曲が前に詰められる",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}, {ID: 4, Index: 3}},
+				QueueHead:   0,
+				StateType:   Play,
+			},
+			from:    1,
+			to:      3,
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}, {ID: 3, Index: 1}, {ID: 4, Index: 2}, {ID: 2, Index: 3}},
+			wantErr: nil,
+		},
+		{
+			name: "前に移動すると間の曲が後ろにずれる",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}, {ID: 4, Index: 3}},
+				QueueHead:   0,
+				StateType:   Pause,
+			},
+			from:    3,
+			to:      1,
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}, {ID: 4, Index: 1}, {ID: 2, Index: 2}, {ID: 3, Index: 3}},
+			wantErr: nil,
+		},
+		{
+			name: "再生中の曲の位置には移動できない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}},
+				QueueHead:   1,
+				StateType:   Play,
+			},
+			from:    2,
+			to:      1,
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}},
+			wantErr: ErrQueueTrackNotEditable,
+		},
+		{
+			name: "投票モードのときは移動できない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}},
+				QueueHead:   0,
+				StateType:   Play,
+				QueueMode:   QueueModeVote,
+			},
+			from:    2,
+			to:      1,
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}},
+			wantErr: ErrQueueTrackNotMovable,
+		},
+		{
+			name: "公平モードのときは移動できない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}},
+				QueueHead:   0,
+				StateType:   Stop,
+				QueueMode:   QueueModeFair,
+			},
+			from:    2,
+			to:      1,
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}},
+			wantErr: ErrQueueTrackNotMovable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.s.MoveQueueTrack(tt.from, tt.to)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("MoveQueueTrack() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(tt.s.QueueTracks, tt.wantQT) {
+				t.Errorf("MoveQueueTrack() QueueTracks diff = %v", cmp.Diff(tt.wantQT, tt.s.QueueTracks))
+			}
+		})
+	}
+}
+
+func TestSession_MoveQueueTracksToNext(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		s         *Session
+		count     int
+		wantStart int
+		wantQT    []*QueueTrack
+		wantErr   error
+	}{
+		{
+			name: "PLAYのときは再生中の曲の次に移動され、後ろの曲がずれる",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}, {ID: 4, Index: 3}, {ID: 5, Index: 4}},
+				QueueHead:   1,
+				StateType:   Play,
+			},
+			count:     2,
+			wantStart: 2,
+			wantQT:    []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 4, Index: 2}, {ID: 5, Index: 3}, {ID: 3, Index: 4}},
+			wantErr:   nil,
+		},
+		{
+			name: "STOPのときはheadの位置に移動される",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}},
+				QueueHead:   1,
+				StateType:   Stop,
+			},
+			count:     1,
+			wantStart: 1,
+			wantQT:    []*QueueTrack{{ID: 1, Index: 0}, {ID: 3, Index: 1}, {ID: 2, Index: 2}},
+			wantErr:   nil,
+		},
+		{
+			name: "未再生の曲より多くは移動できない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}},
+				QueueHead:   0,
+				StateType:   Play,
+			},
+			count:   2,
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}},
+			wantErr: ErrQueueTrackNotEditable,
+		},
+		{
+			name: "投票モードのときは移動できない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}},
+				QueueHead:   0,
+				StateType:   Play,
+				QueueMode:   QueueModeVote,
+			},
+			count:   1,
+			wantQT:  []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}},
+			wantErr: ErrQueueTrackNotMovable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, err := tt.s.MoveQueueTracksToNext(tt.count)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("MoveQueueTracksToNext() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if start != tt.wantStart {
+				t.Errorf("MoveQueueTracksToNext() start = %d, want %d", start, tt.wantStart)
+			}
+			if !cmp.Equal(tt.s.QueueTracks, tt.wantQT) {
+				t.Errorf("MoveQueueTracksToNext() QueueTracks diff = %v", cmp.Diff(tt.wantQT, tt.s.QueueTracks))
+			}
+		})
+	}
+}
+
+func TestSession_ShuffleQueueTracks(t *testing.T) {
+	t.Parallel()
+
+	newQueueTracks := func(n int) []*QueueTrack {
+		qts := make([]*QueueTrack, n)
+		for i := range qts {
+			qts[i] = &QueueTrack{ID: int64(i + 1), Index: i}
+		}
+		return qts
+	}
+
+	tests := []struct {
+		name      string
+		s         *Session
+		wantStart int
+		wantErr   error
+	}{
+		{
+			name: "PLAYのときは再生中の曲より後ろの曲だけが並び替えられる",
+			s: &Session{
+				QueueTracks: newQueueTracks(20),
+				QueueHead:   3,
+				StateType:   Play,
+			},
+			wantStart: 4,
+			wantErr:   nil,
+		},
+		{
+			name: "STOPのときはheadの曲から並び替えられる",
+			s: &Session{
+				QueueTracks: newQueueTracks(20),
+				QueueHead:   3,
+				StateType:   Stop,
+			},
+			wantStart: 3,
+			wantErr:   nil,
+		},
+		{
+			name: "未再生の曲が無いときは何も変わらない",
+			s: &Session{
+				QueueTracks: newQueueTracks(3),
+				QueueHead:   2,
+				StateType:   Play,
+			},
+			wantStart: 3,
+			wantErr:   nil,
+		},
+		{
+			name: "投票モードのときは並び替えられない",
+			s: &Session{
+				QueueTracks: newQueueTracks(20),
+				QueueHead:   3,
+				StateType:   Play,
+				QueueMode:   QueueModeVote,
+			},
+			wantErr: ErrQueueTrackNotMovable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := make([]*QueueTrack, len(tt.s.QueueTracks))
+			copy(before, tt.s.QueueTracks)
+			same := &Session{QueueTracks: make([]*QueueTrack, len(before)), QueueHead: tt.s.QueueHead, StateType: tt.s.StateType, QueueMode: tt.s.QueueMode}
+			copy(same.QueueTracks, before)
+
+			start, err := tt.s.ShuffleQueueTracks(rand.New(rand.NewSource(1)))
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ShuffleQueueTracks() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if err != nil {
+				if !cmp.Equal(tt.s.QueueTracks, before) {
+					t.Errorf("ShuffleQueueTracks() QueueTracks diff = %v", cmp.Diff(before, tt.s.QueueTracks))
+				}
+				return
+			}
+			if start != tt.wantStart {
+				t.Errorf("ShuffleQueueTracks() start = %d, want %d", start, tt.wantStart)
+			}
+
+			if !cmp.Equal(tt.s.QueueTracks[:start], before[:start]) {
+				t.Errorf("ShuffleQueueTracks() changed tracks before start: diff = %v", cmp.Diff(before[:start], tt.s.QueueTracks[:start]))
+			}
+			ids := map[int64]bool{}
+			for i, qt := range tt.s.QueueTracks {
+				if qt.Index != i {
+					t.Errorf("ShuffleQueueTracks() QueueTracks[%d].Index = %d", i, qt.Index)
+				}
+				ids[qt.ID] = true
+			}
+			if len(ids) != len(before) {
+				t.Errorf("ShuffleQueueTracks() QueueTracks has %d unique tracks, want %d", len(ids), len(before))
+			}
+
+			// 同じseedなら同じ並びになる
+			if _, err := same.ShuffleQueueTracks(rand.New(rand.NewSource(1))); err != nil {
+				t.Fatalf("ShuffleQueueTracks() error = %v", err)
+			}
+			for i := range same.QueueTracks {
+				if same.QueueTracks[i].ID != tt.s.QueueTracks[i].ID {
+					t.Errorf("ShuffleQueueTracks() with same seed QueueTracks[%d].ID = %d, want %d", i, same.QueueTracks[i].ID, tt.s.QueueTracks[i].ID)
+				}
+			}
+		})
+	}
+}
+
+func TestSession_VotableQueueTrack(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		s       *Session
+		index   int
+		want    *QueueTrack
+		wantErr error
+	}{
+		{
+			name: "投票モードのときに未再生の曲を返す",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}, {ID: 4, Index: 3}},
+				QueueHead:   0,
+				StateType:   Play,
+				QueueMode:   QueueModeVote,
+			},
+			index:   3,
+			want:    &QueueTrack{ID: 4, Index: 3},
+			wantErr: nil,
+		},
+		{
+			name: "STOPのときはheadの曲にも投票できる",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}},
+				QueueHead:   1,
+				StateType:   Stop,
+				QueueMode:   QueueModeVote,
+			},
+			index:   1,
+			want:    &QueueTrack{ID: 2, Index: 1},
+			wantErr: nil,
+		},
+		{
+			name: "Spotifyのキューに積まれている曲には投票できない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}, {ID: 3, Index: 2}, {ID: 4, Index: 3}},
+				QueueHead:   0,
+				StateType:   Pause,
+				QueueMode:   QueueModeVote,
+			},
+			index:   2,
+			want:    nil,
+			wantErr: ErrQueueTrackNotVotable,
+		},
+		{
+			name: "投票モードでないときはErrQueueModeIsNotVote",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}},
+				QueueHead:   0,
+				StateType:   Play,
+				QueueMode:   QueueModeFIFO,
+			},
+			index:   1,
+			want:    nil,
+			wantErr: ErrQueueModeIsNotVote,
+		},
+		{
+			name: "再生中の曲には投票できない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Index: 0}, {ID: 2, Index: 1}},
+				QueueHead:   0,
+				StateType:   Play,
+				QueueMode:   QueueModeVote,
+			},
+			index:   0,
+			want:    nil,
+			wantErr: ErrQueueTrackNotEditable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.s.VotableQueueTrack(tt.index)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VotableQueueTrack() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("VotableQueueTrack() diff = %v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestSession_ArrangeQueueTracks(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		s         *Session
+		wantStart int
+		wantIDs   []int64
+	}{
+		{
+			name: "FIFOのときは並び替えない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Score: 0}, {ID: 2, Score: 1}, {ID: 3, Score: 2}},
+				QueueHead:   0,
+				StateType:   Stop,
+				QueueMode:   QueueModeFIFO,
+			},
+			wantStart: 3,
+			wantIDs:   []int64{1, 2, 3},
+		},
+		{
+			name: "STOPのときはheadからスコアの高い順に並び、同じスコアの曲は追加された順になる",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Score: 5}, {ID: 2, Score: 0}, {ID: 4, Score: 1}, {ID: 3, Score: 0}, {ID: 5, Score: -1}, {ID: 6, Score: 1}},
+				QueueHead:   1,
+				StateType:   Stop,
+				QueueMode:   QueueModeVote,
+			},
+			wantStart: 1,
+			wantIDs:   []int64{1, 4, 6, 2, 3, 5},
+		},
+		{
+			name: "PLAYのときは再生中の曲とSpotifyのキューに積まれている曲は並び替えない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Score: 0}, {ID: 2, Score: -1}, {ID: 3, Score: -1}, {ID: 4, Score: 0}, {ID: 5, Score: 2}},
+				QueueHead:   0,
+				StateType:   Play,
+				QueueMode:   QueueModeVote,
+			},
+			wantStart: 3,
+			wantIDs:   []int64{1, 2, 3, 5, 4},
+		},
+		{
+			name: "公平モードのときは追加した人ごとに追加された順で交互に並び替える",
+			s: &Session{
+				QueueTracks: []*QueueTrack{
+					{ID: 1, Index: 0, AddedByUserID: "a"},
+					{ID: 2, Index: 1, AddedByUserID: "a"},
+					{ID: 3, Index: 2, AddedByUserID: "a"},
+					{ID: 4, Index: 3, AddedByUserID: "b"},
+					{ID: 5, Index: 4, AddedByNickname: "guest"},
+					{ID: 6, Index: 5, AddedByUserID: "b"},
+				},
+				QueueHead: 0,
+				StateType: Stop,
+				QueueMode: QueueModeFair,
+			},
+			wantStart: 0,
+			wantIDs:   []int64{1, 4, 5, 2, 6, 3},
+		},
+		{
+			name: "公平モードでPLAYのときはSpotifyのキューに積まれている曲も含めて何巡目かを数える",
+			s: &Session{
+				QueueTracks: []*QueueTrack{
+					{ID: 1, Index: 0, AddedByUserID: "a"},
+					{ID: 2, Index: 1, AddedByUserID: "a"},
+					{ID: 3, Index: 2, AddedByUserID: "a"},
+					{ID: 4, Index: 3, AddedByUserID: "a"},
+					{ID: 5, Index: 4, AddedByUserID: "b"},
+				},
+				QueueHead: 0,
+				StateType: Play,
+				QueueMode: QueueModeFair,
+			},
+			wantStart: 3,
+			wantIDs:   []int64{1, 2, 3, 5, 4},
+		},
+		{
+			name: "公平モードで再生が進んでも同じ巡目の中の順番は変わらない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{
+					{ID: 1, Index: 0, AddedByUserID: "a"},
+					{ID: 3, Index: 1, AddedByUserID: "b"},
+					{ID: 2, Index: 2, AddedByUserID: "a"},
+					{ID: 4, Index: 3, AddedByUserID: "b"},
+				},
+				QueueHead: 1,
+				StateType: Stop,
+				QueueMode: QueueModeFair,
+			},
+			wantStart: 1,
+			wantIDs:   []int64{1, 3, 2, 4},
+		},
+		{
+			name: "投票モードではラジオモードで追加された曲はスコアに関わらずユーザが追加した曲の後ろに並ぶ",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{ID: 1, Score: 2, AddedBySystem: true}, {ID: 2, Score: 0}, {ID: 3, Score: 1}},
+				QueueHead:   0,
+				StateType:   Stop,
+				QueueMode:   QueueModeVote,
+			},
+			wantStart: 0,
+			wantIDs:   []int64{3, 2, 1},
+		},
+		{
+			name: "公平モードではラジオモードで追加された曲はユーザが追加した曲の後ろに並ぶ",
+			s: &Session{
+				QueueTracks: []*QueueTrack{
+					{ID: 1, Index: 0, AddedBySystem: true},
+					{ID: 2, Index: 1, AddedByUserID: "a"},
+					{ID: 3, Index: 2, AddedByUserID: "a"},
+				},
+				QueueHead: 0,
+				StateType: Stop,
+				QueueMode: QueueModeFair,
+			},
+			wantStart: 0,
+			wantIDs:   []int64{2, 3, 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.ArrangeQueueTracks(); got != tt.wantStart {
+				t.Errorf("ArrangeQueueTracks() = %d, want %d", got, tt.wantStart)
+			}
+
+			gotIDs := make([]int64, len(tt.s.QueueTracks))
+			for i, qt := range tt.s.QueueTracks {
+				gotIDs[i] = qt.ID
+				if qt.Index != i && tt.s.QueueMode != QueueModeFIFO {
+					t.Errorf("ArrangeQueueTracks() QueueTracks[%d].Index = %d", i, qt.Index)
+				}
+			}
+			if !cmp.Equal(gotIDs, tt.wantIDs) {
+				t.Errorf("ArrangeQueueTracks() diff = %v", cmp.Diff(tt.wantIDs, gotIDs))
+			}
+		})
+	}
+}
+
+func TestSession_CanEnqueue(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		s        *Session
+		uri      string
+		userID   string
+		nickname string
+		wantErr  error
+	}{
+		{
+			name: "制限が設定されていないときは同じ曲を何曲でも追加できる",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0", AddedByUserID: "user"}, {URI: "1", AddedByUserID: "user"}, {URI: "1", AddedByUserID: "user"}},
+				QueueHead:   0,
+				StateType:   Play,
+			},
+			uri:     "1",
+			userID:  "user",
+			wantErr: nil,
+		},
+		{
+			name: "重複が拒否されるときに未再生の曲と同じ曲を追加するとErrDuplicateQueueTrack",
+			s: &Session{
+				QueueTracks:           []*QueueTrack{{URI: "0"}, {URI: "1"}},
+				QueueHead:             0,
+				StateType:             Play,
+				RejectDuplicateTracks: true,
+			},
+			uri:     "1",
+			userID:  "user",
+			wantErr: ErrDuplicateQueueTrack,
+		},
+		{
+			name: "重複が拒否されるときでも再生中の曲と同じ曲は追加できる",
+			s: &Session{
+				QueueTracks:           []*QueueTrack{{URI: "0"}, {URI: "1"}},
+				QueueHead:             0,
+				StateType:             Play,
+				RejectDuplicateTracks: true,
+			},
+			uri:     "0",
+			userID:  "user",
+			wantErr: nil,
+		},
+		{
+			name: "ユーザが追加した未再生の曲数が上限に達しているとErrTooManyPendingQueueTracks",
+			s: &Session{
+				QueueTracks:             []*QueueTrack{{URI: "0", AddedByUserID: "user"}, {URI: "1", AddedByUserID: "user"}, {URI: "2", AddedByUserID: "other"}},
+				QueueHead:               0,
+				StateType:               Play,
+				MaxPendingTracksPerUser: 1,
+			},
+			uri:     "3",
+			userID:  "user",
+			wantErr: ErrTooManyPendingQueueTracks,
+		},
+		{
+			name: "再生済みの曲は未再生の曲数に数えない",
+			s: &Session{
+				QueueTracks:             []*QueueTrack{{URI: "0", AddedByNickname: "guest"}, {URI: "1", AddedByNickname: "guest"}, {URI: "2", AddedByUserID: "user", AddedByNickname: "guest"}},
+				QueueHead:               1,
+				StateType:               Play,
+				MaxPendingTracksPerUser: 1,
+			},
+			uri:      "3",
+			nickname: "guest",
+			wantErr:  nil,
+		},
+		{
+			name: "一定時間内に追加した曲数が上限に達しているとErrEnqueueRateLimitExceeded",
+			s: &Session{
+				QueueTracks: []*QueueTrack{
+					{URI: "0", AddedByUserID: "user", AddedAt: now.Add(-20 * time.Minute)},
+					{URI: "1", AddedByUserID: "user", AddedAt: now.Add(-9 * time.Minute)},
+					{URI: "2", AddedByUserID: "user", AddedAt: now.Add(-1 * time.Minute)},
+				},
+				QueueHead:         0,
+				StateType:         Play,
+				EnqueueRateLimit:  2,
+				EnqueueRateWindow: 10 * time.Minute,
+			},
+			uri:     "3",
+			userID:  "user",
+			wantErr: ErrEnqueueRateLimitExceeded,
+		},
+		{
+			name: "一定時間より前に追加した曲は数えない",
+			s: &Session{
+				QueueTracks: []*QueueTrack{
+					{URI: "0", AddedByUserID: "user", AddedAt: now.Add(-20 * time.Minute)},
+					{URI: "1", AddedByUserID: "user", AddedAt: now.Add(-10 * time.Minute)},
+					{URI: "2", AddedByUserID: "user", AddedAt: now.Add(-1 * time.Minute)},
+				},
+				QueueHead:         0,
+				StateType:         Play,
+				EnqueueRateLimit:  2,
+				EnqueueRateWindow: 10 * time.Minute,
+			},
+			uri:     "3",
+			userID:  "user",
+			wantErr: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.s.CanEnqueue(tt.uri, tt.userID, tt.nickname, now); !errors.Is(err, tt.wantErr) {
+				t.Errorf("CanEnqueue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSession_AppendQueueTrack(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now().UTC()
+	s := &Session{
+		StateType:               Stop,
+		MaxPendingTracksPerUser: 2,
+		QueueTracks:             []*QueueTrack{{Index: 0, URI: "0", AddedByNickname: "guest"}},
+	}
+
+	s.AppendQueueTrack(&QueueTrackToStore{URI: "1", SessionID: "sessionID", AddedByNickname: "guest", AddedAt: now})
+
+	want := []*QueueTrack{
+		{Index: 0, URI: "0", AddedByNickname: "guest"},
+		{Index: 1, URI: "1", SessionID: "sessionID", AddedByNickname: "guest", AddedAt: now},
+	}
+	if !cmp.Equal(s.QueueTracks, want) {
+		t.Errorf("AppendQueueTrack() diff = %v", cmp.Diff(s.QueueTracks, want))
+	}
+	if err := s.CanEnqueue("2", "", "guest", now); !errors.Is(err, ErrTooManyPendingQueueTracks) {
+		t.Errorf("CanEnqueue() after AppendQueueTrack error = %v, want %v", err, ErrTooManyPendingQueueTracks)
+	}
+}
+
+func TestSession_EndHeadTrack(t *testing.T) {
+	t.Parallel()
+
+	startedAt := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	endedAt := startedAt.Add(3 * time.Minute)
+
+	tests := []struct {
+		name string
+		s    *Session
+		want *PlayedTrack
+	}{
+		{
+			name: "再生が始まっているheadの曲の再生履歴が返る",
+			s: &Session{
+				ID:            "sessionID",
+				QueueHead:     1,
+				QueueTracks:   []*QueueTrack{{Index: 0, URI: "0"}, {Index: 1, URI: "1"}},
+				HeadStartedAt: startedAt,
+			},
+			want: &PlayedTrack{
+				SessionID: "sessionID",
+				URI:       "1",
+				StartedAt: startedAt,
+				EndedAt:   endedAt,
+				EndReason: TrackEndReasonSkipped,
+			},
+		},
+		{
+			name: "headの曲の再生が始まっていないときはnil",
+			s: &Session{
+				ID:          "sessionID",
+				QueueHead:   0,
+				QueueTracks: []*QueueTrack{{Index: 0, URI: "0"}},
+			},
+			want: nil,
+		},
+		{
+			name: "全ての曲を再生し終わっているときはnil",
+			s: &Session{
+				ID:            "sessionID",
+				QueueHead:     1,
+				QueueTracks:   []*QueueTrack{{Index: 0, URI: "0"}},
+				HeadStartedAt: startedAt,
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.s.EndHeadTrack(TrackEndReasonSkipped, endedAt)
+			if !cmp.Equal(got, tt.want) {
+				t.Errorf("EndHeadTrack() diff = %v", cmp.Diff(got, tt.want))
+			}
+			if got != nil && !tt.s.HeadStartedAt.IsZero() {
+				t.Errorf("EndHeadTrack() HeadStartedAt = %v, want zero", tt.s.HeadStartedAt)
+			}
+		})
+	}
+}
+
+func TestSession_StartHeadTrack(t *testing.T) {
+	t.Parallel()
+
+	startedAt := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	resumedAt := startedAt.Add(time.Minute)
+
+	s := &Session{}
+	s.StartHeadTrack(startedAt)
+	if !s.HeadStartedAt.Equal(startedAt) {
+		t.Errorf("StartHeadTrack() HeadStartedAt = %v, want %v", s.HeadStartedAt, startedAt)
+	}
+
+	// 一時停止からの再開では再生が始まった日時は変わらない
+	s.StartHeadTrack(resumedAt)
+	if !s.HeadStartedAt.Equal(startedAt) {
+		t.Errorf("StartHeadTrack() after resume HeadStartedAt = %v, want %v", s.HeadStartedAt, startedAt)
+	}
+}
+
+func TestSession_RadioTrackCountToFill(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    *Session
+		want int
+	}{
+		{
+			name: "ラジオモードでないときは0",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}},
+				QueueHead:   0,
+				StateType:   Play,
+			},
+			want: 0,
+		},
+		{
+			name: "PLAYのときは再生中の曲より後ろの曲が3曲になるまでの曲数",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}},
+				QueueHead:   1,
+				StateType:   Play,
+				RadioMode:   true,
+			},
+			want: 2,
+		},
+		{
+			name: "未再生の曲が十分にあるときは0",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}},
+				QueueHead:   0,
+				StateType:   Stop,
+				RadioMode:   true,
+			},
+			want: 0,
+		},
+		{
+			name: "キューを繰り返すときは未再生の曲が少なくても0",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}},
+				QueueHead:   1,
+				StateType:   Play,
+				RadioMode:   true,
+				RepeatQueue: true,
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.RadioTrackCountToFill(); got != tt.want {
+				t.Errorf("RadioTrackCountToFill() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSession_RadioSeedTrackURIs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		s    *Session
+		want []string
+	}{
+		{
+			name: "PLAYのときは再生中の曲までの最大5曲",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}, {URI: "3"}, {URI: "4"}, {URI: "5"}, {URI: "6"}},
+				QueueHead:   5,
+				StateType:   Play,
+			},
+			want: []string{"1", "2", "3", "4", "5"},
+		},
+		{
+			name: "STOPのときはheadより前の曲",
+			s: &Session{
+				QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}, {URI: "2"}},
+				QueueHead:   1,
+				StateType:   Stop,
+			},
+			want: []string{"0"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.RadioSeedTrackURIs(); !cmp.Equal(got, tt.want) {
+				t.Errorf("RadioSeedTrackURIs() diff = %v", cmp.Diff(tt.want, got))
+			}
+		})
+	}
+}
+
+func TestSession_TrackURIsNotInQueue(t *testing.T) {
+	t.Parallel()
+
+	s := &Session{
+		QueueTracks: []*QueueTrack{{URI: "0"}, {URI: "1"}},
+	}
+	got := s.TrackURIsNotInQueue([]string{"1", "2", "3", "2"})
+	want := []string{"2", "3"}
+	if !cmp.Equal(got, want) {
+		t.Errorf("TrackURIsNotInQueue() diff = %v", cmp.Diff(want, got))
+	}
+}
+
+func TestSession_UpdateSettings(t *testing.T) {
+	t.Parallel()
+
+	vote := QueueModeVote
+	three := 3
+	minus := -1
+	tenMinutes := 10 * time.Minute
+	reject := true
+	eighty := 80
+	overMaxVolume := 101
+	noSchedule := time.Time{}
+
+	tests := []struct {
+		name     string
+		s        *Session
+		settings *SessionSettingsToUpdate
+		want     *Session
+		wantErr  error
+	}{
+		{
+			name: "指定された項目のみ変更される",
+			s: &Session{
+				QueueMode:               QueueModeFIFO,
+				MaxPendingTracksPerUser: 1,
+			},
+			settings: &SessionSettingsToUpdate{
+				QueueMode:             &vote,
+				EnqueueRateLimit:      &three,
+				EnqueueRateWindow:     &tenMinutes,
+				RejectDuplicateTracks: &reject,
+				DeviceFallback:        &reject,
+			},
+			want: &Session{
+				QueueMode:               QueueModeVote,
+				MaxPendingTracksPerUser: 1,
+				EnqueueRateLimit:        3,
+				EnqueueRateWindow:       10 * time.Minute,
+				RejectDuplicateTracks:   true,
+				DeviceFallback:          true,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "負の値が指定されるとErrInvalidSessionSettings",
+			s: &Session{
+				QueueMode:               QueueModeFIFO,
+				MaxPendingTracksPerUser: 1,
+			},
+			settings: &SessionSettingsToUpdate{
+				QueueMode:               &vote,
+				MaxPendingTracksPerUser: &minus,
+			},
+			want: &Session{
+				QueueMode:               QueueModeFIFO,
+				MaxPendingTracksPerUser: 1,
+			},
+			wantErr: ErrInvalidSessionSettings,
+		},
+		{
+			name: "スリープタイマーの曲数に負の値が指定されるとErrInvalidSessionSettings",
+			s: &Session{
+				QueueMode: QueueModeFIFO,
+			},
+			settings: &SessionSettingsToUpdate{
+				SleepAfterTracks: &minus,
+			},
+			want: &Session{
+				QueueMode: QueueModeFIFO,
+			},
+			wantErr: ErrInvalidSessionSettings,
+		},
+		{
+			name: "ゼロ値が指定されると再生開始の予約が解除される",
+			s: &Session{
+				QueueMode:        QueueModeFIFO,
+				ScheduledStartAt: time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC),
+			},
+			settings: &SessionSettingsToUpdate{
+				ScheduledStartAt: &noSchedule,
+			},
+			want: &Session{
+				QueueMode: QueueModeFIFO,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "音量の上限が変更される",
+			s: &Session{
+				QueueMode: QueueModeFIFO,
+			},
+			settings: &SessionSettingsToUpdate{
+				MaxVolume: &eighty,
+			},
+			want: &Session{
+				QueueMode: QueueModeFIFO,
+				MaxVolume: 80,
+			},
+			wantErr: nil,
+		},
+		{
+			name: "100%を超える音量の上限が指定されるとErrInvalidSessionSettings",
+			s: &Session{
+				QueueMode: QueueModeFIFO,
+			},
+			settings: &SessionSettingsToUpdate{
+				MaxVolume: &overMaxVolume,
+			},
+			want: &Session{
+				QueueMode: QueueModeFIFO,
+			},
+			wantErr: ErrInvalidSessionSettings,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.s.UpdateSettings(tt.settings); !errors.Is(err, tt.wantErr) {
+				t.Errorf("UpdateSettings() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !cmp.Equal(tt.s, tt.want) {
+				t.Errorf("UpdateSettings() diff = %v", cmp.Diff(tt.want, tt.s))
+			}
+		})
+	}
+}
+
+func TestSession_VolumeToSet(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		s       *Session
+		volume  int
+		want    int
+		wantErr error
+	}{
+		{
+			name:    "上限が設定されていないときはそのままの音量",
+			s:       &Session{MaxVolume: 0},
+			volume:  100,
+			want:    100,
+			wantErr: nil,
+		},
+		{
+			name:    "上限以下の音量のときはそのままの音量",
+			s:       &Session{MaxVolume: 80},
+			volume:  50,
+			want:    50,
+			wantErr: nil,
+		},
+		{
+			name:    "上限を超える音量のときは上限に丸められる",
+			s:       &Session{MaxVolume: 80},
+			volume:  90,
+			want:    80,
+			wantErr: nil,
+		},
+		{
+			name:    "負の音量のときはErrInvalidVolume",
+			s:       &Session{},
+			volume:  -1,
+			want:    0,
+			wantErr: ErrInvalidVolume,
+		},
+		{
+			name:    "100%を超える音量のときはErrInvalidVolume",
+			s:       &Session{},
+			volume:  101,
+			want:    0,
+			wantErr: ErrInvalidVolume,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.s.VolumeToSet(tt.volume)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("VolumeToSet() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("VolumeToSet() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSession_FallbackDeviceID(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		s       *Session
+		devices []*Device
+		want    string
+		wantOK  bool
+	}{
+		{
+			name: "前回使っていたデバイスがあればそのデバイス",
+			s:    &Session{DeviceID: "device2"},
+			devices: []*Device{
+				{ID: "device1"},
+				{ID: "device2"},
+			},
+			want:   "device2",
+			wantOK: true,
+		},
+		{
+			name: "前回使っていたデバイスがなくても使えるデバイスが1台だけならそのデバイス",
+			s:    &Session{DeviceID: "device1"},
+			devices: []*Device{
+				{ID: "device2"},
+				{ID: "device3", IsRestricted: true},
+			},
+			want:   "device2",
+			wantOK: true,
+		},
+		{
+			name: "前回使っていたデバイスがなく使えるデバイスが複数あるときは選べない",
+			s:    &Session{DeviceID: "device1"},
+			devices: []*Device{
+				{ID: "device2"},
+				{ID: "device3"},
+			},
+			want:   "",
+			wantOK: false,
+		},
+		{
+			name: "前回使っていたデバイスが制限されているときは選ばない",
+			s:    &Session{DeviceID: "device1"},
+			devices: []*Device{
+				{ID: "device1", IsRestricted: true},
+			},
+			want:   "",
+			wantOK: false,
+		},
+		{
+			name:    "デバイスが1台もないときは選べない",
+			s:       &Session{DeviceID: "device1"},
+			devices: nil,
+			want:    "",
+			wantOK:  false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.s.FallbackDeviceID(tt.devices)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("FallbackDeviceID() = (%s, %v), want (%s, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestSession_SleepOnTrackEnd(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 15, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		s          *Session
+		wantReason SleepReason
+		wantOK     bool
+		want       *Session
+	}{
+		{
+			name:       "スリープタイマーが設定されていないときは止めない",
+			s:          &Session{},
+			wantReason: "",
+			wantOK:     false,
+			want:       &Session{},
+		},
+		{
+			name:       "残りの曲数が2曲以上のときは1曲減らして止めない",
+			s:          &Session{SleepAfterTracks: 2},
+			wantReason: "",
+			wantOK:     false,
+			want:       &Session{SleepAfterTracks: 1},
+		},
+		{
+			name:       "残りの曲数が1曲のときは止めてスリープタイマーを解除する",
+			s:          &Session{SleepAfterTracks: 1, SleepAt: now.Add(time.Hour)},
+			wantReason: SleepReasonTrackCount,
+			wantOK:     true,
+			want:       &Session{},
+		},
+		{
+			name:       "指定された日時より前のときは止めない",
+			s:          &Session{SleepAt: now.Add(time.Minute)},
+			wantReason: "",
+			wantOK:     false,
+			want:       &Session{SleepAt: now.Add(time.Minute)},
+		},
+		{
+			name:       "指定された日時を過ぎたときは止めてスリープタイマーを解除する",
+			s:          &Session{SleepAfterTracks: 3, SleepAt: now},
+			wantReason: SleepReasonTime,
+			wantOK:     true,
+			want:       &Session{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reason, ok := tt.s.SleepOnTrackEnd(now)
+			if reason != tt.wantReason || ok != tt.wantOK {
+				t.Errorf("SleepOnTrackEnd() = (%s, %v), want (%s, %v)", reason, ok, tt.wantReason, tt.wantOK)
+			}
+			if !cmp.Equal(tt.s, tt.want) {
+				t.Errorf("SleepOnTrackEnd() diff = %v", cmp.Diff(tt.want, tt.s))
+			}
+		})
+	}
+}
+
+func TestNextSleepAt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC) // 日本時間で21:00
+
+	tests := []struct {
+		name    string
+		clock   string
+		want    time.Time
+		wantErr error
+	}{
+		{
+			name:    "今日のまだ来ていない時刻のときは今日の日時",
+			clock:   "23:30+09:00",
+			want:    time.Date(2020, 1, 1, 14, 30, 0, 0, time.UTC),
+			wantErr: nil,
+		},
+		{
+			name:    "今日の既に過ぎた時刻のときは翌日の日時",
+			clock:   "20:00+09:00",
+			want:    time.Date(2020, 1, 2, 11, 0, 0, 0, time.UTC),
+			wantErr: nil,
+		},
+		{
+			name:    "UTCで指定された時刻",
+			clock:   "13:00Z",
+			want:    time.Date(2020, 1, 1, 13, 0, 0, 0, time.UTC),
+			wantErr: nil,
+		},
+		{
+			name:    "時差が指定されていないときはErrInvalidSessionSettings",
+			clock:   "23:30",
+			want:    time.Time{},
+			wantErr: ErrInvalidSessionSettings,
+		},
+		{
+			name:    "不正な時刻のときはErrInvalidSessionSettings",
+			clock:   "25:00+09:00",
+			want:    time.Time{},
+			wantErr: ErrInvalidSessionSettings,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NextSleepAt(tt.clock, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("NextSleepAt() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("NextSleepAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewScheduledStartAt(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr error
+	}{
+		{
+			name:    "未来の日時はUTCに変換される",
+			value:   "2020-01-03T18:00:00+09:00",
+			want:    time.Date(2020, 1, 3, 9, 0, 0, 0, time.UTC),
+			wantErr: nil,
+		},
+		{
+			name:    "現在の日時はErrInvalidSessionSettings",
+			value:   "2020-01-01T12:00:00Z",
+			want:    time.Time{},
+			wantErr: ErrInvalidSessionSettings,
+		},
+		{
+			name:    "過去の日時はErrInvalidSessionSettings",
+			value:   "2019-12-31T18:00:00+09:00",
+			want:    time.Time{},
+			wantErr: ErrInvalidSessionSettings,
+		},
+		{
+			name:    "RFC3339形式でないときはErrInvalidSessionSettings",
+			value:   "2020-01-03 18:00",
+			want:    time.Time{},
+			wantErr: ErrInvalidSessionSettings,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewScheduledStartAt(tt.value, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("NewScheduledStartAt() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("NewScheduledStartAt() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSession_IsScheduledToStart(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		s    *Session
+		want bool
+	}{
+		{
+			name: "予約していないときはfalse",
+			s:    &Session{},
+			want: false,
+		},
+		{
+			name: "予約日時より前のときはfalse",
+			s:    &Session{ScheduledStartAt: now.Add(time.Second)},
+			want: false,
+		},
+		{
+			name: "予約日時ちょうどのときはtrue",
+			s:    &Session{ScheduledStartAt: now},
+			want: true,
+		},
+		{
+			name: "予約日時を過ぎているときはtrue",
+			s:    &Session{ScheduledStartAt: now.Add(-time.Minute)},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.s.IsScheduledToStart(now); got != tt.want {
+				t.Errorf("IsScheduledToStart() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestSession_IsPlayingCorrectTrack(t *testing.T) {
 	t.Parallel()
 
@@ -1,16 +1,34 @@
 package entity
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // Track は曲を表す構造体です。
 type Track struct {
-	URI      string
-	ID       string
-	Name     string
-	Duration time.Duration
-	Artists  []*Artist
-	URL      string // Spotifyのwebページ
-	Album    *Album
+	URI              string
+	ID               string
+	Name             string
+	Duration         time.Duration
+	Artists          []*Artist
+	URL              string // Spotifyのwebページ
+	Album            *Album
+	AvailableMarkets []string // 再生できる国のISO 3166-1 alpha-2コード
+}
+
+// IsPlayableIn は指定された国で曲を再生できるかどうか返します。
+// 国が分からない場合は再生できるものとみなします。
+func (t *Track) IsPlayableIn(market string) bool {
+	if market == "" {
+		return true
+	}
+	for _, m := range t.AvailableMarkets {
+		if m == market {
+			return true
+		}
+	}
+	return false
 }
 
 type Album struct {
@@ -43,3 +61,32 @@ func (cpi *CurrentPlayingInfo) Remain() time.Duration {
 	}
 	return cpi.Track.Duration - cpi.Progress
 }
+
+// IsValidSeekPosition は指定された再生位置が再生中の曲の範囲内かどうかを判定します。
+func (cpi *CurrentPlayingInfo) IsValidSeekPosition(position time.Duration) bool {
+	if cpi.Track == nil {
+		return false
+	}
+	return 0 <= position && position < cpi.Track.Duration
+}
+
+const (
+	trackURIPrefix    = "spotify:track:"
+	albumURIPrefix    = "spotify:album:"
+	playlistURIPrefix = "spotify:playlist:"
+)
+
+// IsTrackURI はURIが曲を表すかどうか返します。
+func IsTrackURI(uri string) bool {
+	return strings.HasPrefix(uri, trackURIPrefix) && len(uri) > len(trackURIPrefix)
+}
+
+// IsAlbumURI はURIがアルバムを表すかどうか返します。
+func IsAlbumURI(uri string) bool {
+	return strings.HasPrefix(uri, albumURIPrefix)
+}
+
+// IsPlaylistURI はURIがプレイリストを表すかどうか返します。
+func IsPlaylistURI(uri string) bool {
+	return strings.HasPrefix(uri, playlistURIPrefix)
+}
@@ -0,0 +1,92 @@
+package entity
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTrack_IsPlayableIn(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		track  *Track
+		market string
+		want   bool
+	}{
+		{
+			name:   "再生できる国に含まれているとtrue",
+			track:  &Track{AvailableMarkets: []string{"JP", "US"}},
+			market: "JP",
+			want:   true,
+		},
+		{
+			name:   "再生できる国に含まれていないとfalse",
+			track:  &Track{AvailableMarkets: []string{"US"}},
+			market: "JP",
+			want:   false,
+		},
+		{
+			name:   "国が分からないときはtrue",
+			track:  &Track{AvailableMarkets: []string{"US"}},
+			market: "",
+			want:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.track.IsPlayableIn(tt.market); got != tt.want {
+				t.Errorf("IsPlayableIn() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrentPlayingInfo_IsValidSeekPosition(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		cpi      *CurrentPlayingInfo
+		position time.Duration
+		want     bool
+	}{
+		{
+			name:     "曲の範囲内の再生位置のときはtrue",
+			cpi:      &CurrentPlayingInfo{Track: &Track{Duration: 3 * time.Minute}},
+			position: time.Minute,
+			want:     true,
+		},
+		{
+			name:     "曲の先頭のときはtrue",
+			cpi:      &CurrentPlayingInfo{Track: &Track{Duration: 3 * time.Minute}},
+			position: 0,
+			want:     true,
+		},
+		{
+			name:     "曲の長さ以上の再生位置のときはfalse",
+			cpi:      &CurrentPlayingInfo{Track: &Track{Duration: 3 * time.Minute}},
+			position: 3 * time.Minute,
+			want:     false,
+		},
+		{
+			name:     "負の再生位置のときはfalse",
+			cpi:      &CurrentPlayingInfo{Track: &Track{Duration: 3 * time.Minute}},
+			position: -time.Second,
+			want:     false,
+		},
+		{
+			name:     "曲が再生されていないときはfalse",
+			cpi:      &CurrentPlayingInfo{},
+			position: 0,
+			want:     false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cpi.IsValidSeekPosition(tt.position); got != tt.want {
+				t.Errorf("IsValidSeekPosition() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
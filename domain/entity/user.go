@@ -15,6 +15,7 @@ type (
 		SpotifyUserID string
 		DisplayName   string
 		Product       string
+		Country       string // ISO 3166-1 alpha-2コード
 	}
 )
 
@@ -10,6 +10,7 @@ import (
 	gomock "github.com/golang/mock/gomock"
 	oauth2 "golang.org/x/oauth2"
 	reflect "reflect"
+	time "time"
 )
 
 // MockSession is a mock of Session interface
@@ -107,6 +108,91 @@ func (mr *MockSessionMockRecorder) StoreQueueTrack(arg0, arg1 interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreQueueTrack", reflect.TypeOf((*MockSession)(nil).StoreQueueTrack), arg0, arg1)
 }
 
+// DeleteQueueTrack mocks base method
+func (m *MockSession) DeleteQueueTrack(arg0 context.Context, arg1 *entity.QueueTrack) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteQueueTrack", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteQueueTrack indicates an expected call of DeleteQueueTrack
+func (mr *MockSessionMockRecorder) DeleteQueueTrack(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQueueTrack", reflect.TypeOf((*MockSession)(nil).DeleteQueueTrack), arg0, arg1)
+}
+
+// UpdateQueueTrackIndexes mocks base method
+func (m *MockSession) UpdateQueueTrackIndexes(arg0 context.Context, arg1 []*entity.QueueTrack) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateQueueTrackIndexes", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateQueueTrackIndexes indicates an expected call of UpdateQueueTrackIndexes
+func (mr *MockSessionMockRecorder) UpdateQueueTrackIndexes(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQueueTrackIndexes", reflect.TypeOf((*MockSession)(nil).UpdateQueueTrackIndexes), arg0, arg1)
+}
+
+// StoreQueueTrackVote mocks base method
+func (m *MockSession) StoreQueueTrackVote(arg0 context.Context, arg1 *entity.QueueTrackVote) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StoreQueueTrackVote", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StoreQueueTrackVote indicates an expected call of StoreQueueTrackVote
+func (mr *MockSessionMockRecorder) StoreQueueTrackVote(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StoreQueueTrackVote", reflect.TypeOf((*MockSession)(nil).StoreQueueTrackVote), arg0, arg1)
+}
+
+// DeleteQueueTrackVote mocks base method
+func (m *MockSession) DeleteQueueTrackVote(ctx context.Context, queueTrackID int64, userID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteQueueTrackVote", ctx, queueTrackID, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteQueueTrackVote indicates an expected call of DeleteQueueTrackVote
+func (mr *MockSessionMockRecorder) DeleteQueueTrackVote(ctx, queueTrackID, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteQueueTrackVote", reflect.TypeOf((*MockSession)(nil).DeleteQueueTrackVote), ctx, queueTrackID, userID)
+}
+
+// StorePlayedTrack mocks base method
+func (m *MockSession) StorePlayedTrack(arg0 context.Context, arg1 *entity.PlayedTrack) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StorePlayedTrack", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StorePlayedTrack indicates an expected call of StorePlayedTrack
+func (mr *MockSessionMockRecorder) StorePlayedTrack(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StorePlayedTrack", reflect.TypeOf((*MockSession)(nil).StorePlayedTrack), arg0, arg1)
+}
+
+// FindPlayedTracksBySessionID mocks base method
+func (m *MockSession) FindPlayedTracksBySessionID(ctx context.Context, sessionID string) ([]*entity.PlayedTrack, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindPlayedTracksBySessionID", ctx, sessionID)
+	ret0, _ := ret[0].([]*entity.PlayedTrack)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindPlayedTracksBySessionID indicates an expected call of FindPlayedTracksBySessionID
+func (mr *MockSessionMockRecorder) FindPlayedTracksBySessionID(ctx, sessionID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindPlayedTracksBySessionID", reflect.TypeOf((*MockSession)(nil).FindPlayedTracksBySessionID), ctx, sessionID)
+}
+
 // FindCreatorTokenBySessionID mocks base method
 func (m *MockSession) FindCreatorTokenBySessionID(arg0 context.Context, arg1 string) (*oauth2.Token, string, error) {
 	m.ctrl.T.Helper()
@@ -123,6 +209,36 @@ func (mr *MockSessionMockRecorder) FindCreatorTokenBySessionID(arg0, arg1 interf
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindCreatorTokenBySessionID", reflect.TypeOf((*MockSession)(nil).FindCreatorTokenBySessionID), arg0, arg1)
 }
 
+// FindIDsByState mocks base method
+func (m *MockSession) FindIDsByState(ctx context.Context, stateType entity.StateType) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIDsByState", ctx, stateType)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIDsByState indicates an expected call of FindIDsByState
+func (mr *MockSessionMockRecorder) FindIDsByState(ctx, stateType interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIDsByState", reflect.TypeOf((*MockSession)(nil).FindIDsByState), ctx, stateType)
+}
+
+// FindIDsScheduledToStart mocks base method
+func (m *MockSession) FindIDsScheduledToStart(ctx context.Context, now time.Time) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindIDsScheduledToStart", ctx, now)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindIDsScheduledToStart indicates an expected call of FindIDsScheduledToStart
+func (mr *MockSessionMockRecorder) FindIDsScheduledToStart(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindIDsScheduledToStart", reflect.TypeOf((*MockSession)(nil).FindIDsScheduledToStart), ctx, now)
+}
+
 // ArchiveSessionsForBatch mocks base method
 func (m *MockSession) ArchiveSessionsForBatch() error {
 	m.ctrl.T.Helper()
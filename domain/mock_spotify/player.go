@@ -78,6 +78,48 @@ func (mr *MockPlayerMockRecorder) Pause(ctx, deviceID interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pause", reflect.TypeOf((*MockPlayer)(nil).Pause), ctx, deviceID)
 }
 
+// Seek mocks base method
+func (m *MockPlayer) Seek(ctx context.Context, position time.Duration, deviceID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Seek", ctx, position, deviceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Seek indicates an expected call of Seek
+func (mr *MockPlayerMockRecorder) Seek(ctx, position, deviceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Seek", reflect.TypeOf((*MockPlayer)(nil).Seek), ctx, position, deviceID)
+}
+
+// SetVolume mocks base method
+func (m *MockPlayer) SetVolume(ctx context.Context, volume int, deviceID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVolume", ctx, volume, deviceID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetVolume indicates an expected call of SetVolume
+func (mr *MockPlayerMockRecorder) SetVolume(ctx, volume, deviceID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVolume", reflect.TypeOf((*MockPlayer)(nil).SetVolume), ctx, volume, deviceID)
+}
+
+// TransferPlayback mocks base method
+func (m *MockPlayer) TransferPlayback(ctx context.Context, deviceID string, play bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferPlayback", ctx, deviceID, play)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferPlayback indicates an expected call of TransferPlayback
+func (mr *MockPlayerMockRecorder) TransferPlayback(ctx, deviceID, play interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferPlayback", reflect.TypeOf((*MockPlayer)(nil).TransferPlayback), ctx, deviceID, play)
+}
+
 // Enqueue mocks base method
 func (m *MockPlayer) Enqueue(ctx context.Context, trackURI, deviceID string) error {
 	m.ctrl.T.Helper()
@@ -63,3 +63,48 @@ func (mr *MockTrackClientMockRecorder) GetTracksFromURI(ctx, trackURIs interface
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTracksFromURI", reflect.TypeOf((*MockTrackClient)(nil).GetTracksFromURI), ctx, trackURIs)
 }
+
+// GetTrackURIsFromAlbum mocks base method
+func (m *MockTrackClient) GetTrackURIsFromAlbum(ctx context.Context, albumURI string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrackURIsFromAlbum", ctx, albumURI)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrackURIsFromAlbum indicates an expected call of GetTrackURIsFromAlbum
+func (mr *MockTrackClientMockRecorder) GetTrackURIsFromAlbum(ctx, albumURI interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrackURIsFromAlbum", reflect.TypeOf((*MockTrackClient)(nil).GetTrackURIsFromAlbum), ctx, albumURI)
+}
+
+// GetTrackURIsFromPlaylist mocks base method
+func (m *MockTrackClient) GetTrackURIsFromPlaylist(ctx context.Context, playlistURI string) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTrackURIsFromPlaylist", ctx, playlistURI)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTrackURIsFromPlaylist indicates an expected call of GetTrackURIsFromPlaylist
+func (mr *MockTrackClientMockRecorder) GetTrackURIsFromPlaylist(ctx, playlistURI interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTrackURIsFromPlaylist", reflect.TypeOf((*MockTrackClient)(nil).GetTrackURIsFromPlaylist), ctx, playlistURI)
+}
+
+// GetRecommendedTrackURIs mocks base method
+func (m *MockTrackClient) GetRecommendedTrackURIs(ctx context.Context, seedTrackURIs []string, limit int) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRecommendedTrackURIs", ctx, seedTrackURIs, limit)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRecommendedTrackURIs indicates an expected call of GetRecommendedTrackURIs
+func (mr *MockTrackClientMockRecorder) GetRecommendedTrackURIs(ctx, seedTrackURIs, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRecommendedTrackURIs", reflect.TypeOf((*MockTrackClient)(nil).GetRecommendedTrackURIs), ctx, seedTrackURIs, limit)
+}
@@ -4,6 +4,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/camphor-/relaym-server/domain/entity"
 	"golang.org/x/oauth2"
@@ -16,7 +17,15 @@ type Session interface {
 	StoreSession(context.Context, *entity.Session) error
 	Update(context.Context, *entity.Session) error
 	StoreQueueTrack(context.Context, *entity.QueueTrackToStore) error
+	DeleteQueueTrack(context.Context, *entity.QueueTrack) error
+	UpdateQueueTrackIndexes(context.Context, []*entity.QueueTrack) error
+	StoreQueueTrackVote(context.Context, *entity.QueueTrackVote) error
+	DeleteQueueTrackVote(ctx context.Context, queueTrackID int64, userID string) error
+	StorePlayedTrack(context.Context, *entity.PlayedTrack) error
+	FindPlayedTracksBySessionID(ctx context.Context, sessionID string) ([]*entity.PlayedTrack, error)
 	FindCreatorTokenBySessionID(context.Context, string) (*oauth2.Token, string, error)
+	FindIDsByState(ctx context.Context, stateType entity.StateType) ([]string, error)
+	FindIDsScheduledToStart(ctx context.Context, now time.Time) ([]string, error)
 	ArchiveSessionsForBatch() error
 	DoInTx(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error)
 }
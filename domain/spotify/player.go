@@ -14,6 +14,9 @@ type Player interface {
 	CurrentlyPlaying(ctx context.Context) (*entity.CurrentPlayingInfo, error)
 	PlayWithTracksAndPosition(ctx context.Context, deviceID string, trackURIs []string, position time.Duration) error
 	Pause(ctx context.Context, deviceID string) error
+	Seek(ctx context.Context, position time.Duration, deviceID string) error
+	SetVolume(ctx context.Context, volume int, deviceID string) error
+	TransferPlayback(ctx context.Context, deviceID string, play bool) error
 	Enqueue(ctx context.Context, trackURI string, deviceID string) error
 	SetRepeatMode(ctx context.Context, on bool, deviceID string) error
 	SetShuffleMode(ctx context.Context, on bool, deviceID string) error
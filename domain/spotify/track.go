@@ -12,4 +12,7 @@ import (
 type TrackClient interface {
 	Search(ctx context.Context, q string) ([]*entity.Track, error)
 	GetTracksFromURI(ctx context.Context, trackURIs []string) ([]*entity.Track, error)
+	GetTrackURIsFromAlbum(ctx context.Context, albumURI string) ([]string, error)
+	GetTrackURIsFromPlaylist(ctx context.Context, playlistURI string) ([]string, error)
+	GetRecommendedTrackURIs(ctx context.Context, seedTrackURIs []string, limit int) ([]string, error)
 }
@@ -20,6 +20,9 @@ import (
 	_ "github.com/go-sql-driver/mysql"
 )
 
+// playbackSchedulerWorkers は曲の終了や同期チェックの処理を同時に実行する数です。
+const playbackSchedulerWorkers = 16
+
 func main() {
 	logger := log.New()
 
@@ -44,17 +47,32 @@ func main() {
 	userRepo := database.NewUserRepository(dbMap)
 	sessionRepo := database.NewSessionRepository(dbMap)
 
-	syncCheckTimerManager := entity.NewSyncCheckTimerManager()
+	clock := entity.NewSystemClock()
+	playbackScheduler := entity.NewPlaybackScheduler(playbackSchedulerWorkers, clock)
 
 	userUC := usecase.NewUserUseCase(spotifyCli, userRepo)
 	authUC := usecase.NewAuthUseCase(spotifyCli, spotifyCli, authRepo, userRepo, sessionRepo)
-	sessionTimerUC := usecase.NewSessionTimerUseCase(sessionRepo, spotifyCli, hub, syncCheckTimerManager)
+	sessionTimerUC := usecase.NewSessionTimerUseCase(sessionRepo, spotifyCli, spotifyCli, hub, playbackScheduler, authUC, clock)
 	sessionUC := usecase.NewSessionUseCase(sessionRepo, userRepo, spotifyCli, spotifyCli, spotifyCli, hub, sessionTimerUC)
-	sessionStateUC := usecase.NewSessionStateUseCase(sessionRepo, spotifyCli, hub, sessionTimerUC)
+	sessionStateUC := usecase.NewSessionStateUseCase(sessionRepo, spotifyCli, spotifyCli, hub, sessionTimerUC)
+	sessionScheduleUC := usecase.NewSessionScheduleUseCase(sessionRepo, authUC, sessionStateUC, hub, clock)
 	trackUC := usecase.NewTrackUseCase(spotifyCli)
 	batchUC := usecase.NewBatchUseCase(sessionRepo, hub)
 
-	s := web.NewServer(authUC, userUC, sessionUC, sessionStateUC, trackUC, batchUC, hub)
+	scheduleCtx, cancelSchedule := context.WithCancel(context.Background())
+	defer cancelSchedule()
+	// 曲の終了や同期チェックを行う
+	go sessionTimerUC.RunScheduler(scheduleCtx)
+	// 再起動で失われた再生中のセッションの予定を立て直す
+	go func() {
+		if err := sessionTimerUC.RecoverTimers(scheduleCtx); err != nil {
+			logger.Errorj(map[string]interface{}{"message": "failed to recover timers", "error": err.Error()})
+		}
+	}()
+	// 予約された日時にセッションの再生を開始する
+	go sessionScheduleUC.Run(scheduleCtx)
+
+	s := web.NewServer(authUC, userUC, sessionUC, sessionStateUC, trackUC, batchUC, hub, clock)
 
 	// シグナルを受け取れるようにgoroutine内でサーバを起動する
 	go func() {
@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS `played_tracks` (
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'played_trackのID（不変）',
+  `session_id` VARCHAR(255) CHARACTER SET 'utf8mb4' COLLATE 'utf8mb4_bin' NOT NULL,
+  `uri` VARCHAR(255) NOT NULL COMMENT '再生された曲のSpotify URI（不変）',
+  `started_at` DATETIME NOT NULL COMMENT '曲の再生が始まった日時（不変）',
+  `ended_at` DATETIME NOT NULL COMMENT '曲の再生が終わった日時（不変）',
+  `end_reason` ENUM('FINISHED','SKIPPED','INTERRUPTED') NOT NULL COMMENT '曲の再生が終わった理由（不変）',
+  PRIMARY KEY (`id`),
+  INDEX `played_tracks_session_id_fk_idx` (`session_id` ASC) VISIBLE,
+  CONSTRAINT `played_tracks_session_id_fk`
+    FOREIGN KEY (`session_id`)
+    REFERENCES `sessions` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE)
+ENGINE = InnoDB;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS `queue_track_votes` (
+  `queue_track_id` BIGINT NOT NULL COMMENT '投票されたqueue_trackのID（不変）',
+  `user_id` VARCHAR(255) CHARACTER SET 'utf8mb4' COLLATE 'utf8mb4_bin' NOT NULL COMMENT '投票したユーザのID（不変）',
+  `value` TINYINT NOT NULL COMMENT '1: upvote, -1: downvote（可変）',
+  PRIMARY KEY (`queue_track_id`, `user_id`),
+  INDEX `queue_track_votes_user_id_fk_idx` (`user_id` ASC) VISIBLE,
+  CONSTRAINT `queue_track_votes_queue_track_id_fk`
+    FOREIGN KEY (`queue_track_id`)
+    REFERENCES `queue_tracks` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE,
+  CONSTRAINT `queue_track_votes_user_id_fk`
+    FOREIGN KEY (`user_id`)
+    REFERENCES `users` (`id`)
+    ON DELETE CASCADE
+    ON UPDATE CASCADE)
+ENGINE = InnoDB;
@@ -1,8 +1,14 @@
 CREATE TABLE IF NOT EXISTS `queue_tracks` (
-  `index` INT NOT NULL COMMENT 'session内でのindex（0-indexed）（不変）',
+  `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'queue_trackのID（不変）',
+  `index` INT NOT NULL COMMENT 'session内でのindex（0-indexed）（未再生の曲は削除・並び替えで可変）',
   `uri` VARCHAR(255) NOT NULL COMMENT 'Spotify APIから返ってくるuri（不変）',
   `session_id` VARCHAR(255) CHARACTER SET 'utf8mb4' COLLATE 'utf8mb4_bin' NOT NULL,
-  PRIMARY KEY (`session_id`, `index`),
+  `added_by_user_id` VARCHAR(255) CHARACTER SET 'utf8mb4' COLLATE 'utf8mb4_bin' NOT NULL DEFAULT '' COMMENT '曲を追加したログインユーザのID（ゲストの場合は空）',
+  `added_by_nickname` VARCHAR(255) NOT NULL DEFAULT '' COMMENT '曲を追加したユーザの表示名（ゲストの場合はニックネーム）',
+  `added_by_system` TINYINT(1) NOT NULL DEFAULT '0' COMMENT 'ラジオモードで自動で追加された曲かどうか（不変）',
+  `added_at` DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP COMMENT '曲が追加された日時（不変）',
+  PRIMARY KEY (`id`),
+  UNIQUE KEY `queue_tracks_session_id_index_uindex` (`session_id`, `index`),
   CONSTRAINT `tracks_session_id_fk`
     FOREIGN KEY (`session_id`)
     REFERENCES `sessions` (`id`)
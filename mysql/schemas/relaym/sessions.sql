@@ -8,6 +8,19 @@ CREATE TABLE IF NOT EXISTS `sessions` (
   `expired_at` datetime NOT NULL,
   `allow_to_control_by_others` TINYINT(1) NOT NULL DEFAULT '0',
   `progress_when_paused` INT NOT NULL DEFAULT '0',
+  `queue_mode` ENUM('FIFO','VOTE','FAIR') NOT NULL DEFAULT 'FIFO' COMMENT 'キューの曲を再生する順番の決め方（可変）',
+  `max_pending_tracks_per_user` INT NOT NULL DEFAULT '0' COMMENT '一人のユーザが追加できる未再生の曲数の上限（0は無制限）（可変）',
+  `enqueue_rate_limit` INT NOT NULL DEFAULT '0' COMMENT 'enqueue_rate_windowの間に一人のユーザが追加できる曲数の上限（0は無制限）（可変）',
+  `enqueue_rate_window` INT NOT NULL DEFAULT '0' COMMENT '追加できる曲数を制限する期間（ms）（可変）',
+  `reject_duplicate_tracks` TINYINT(1) NOT NULL DEFAULT '0' COMMENT '未再生の曲と同じ曲の追加を拒否するかどうか（可変）',
+  `head_started_at` DATETIME NULL DEFAULT NULL COMMENT 'queue_headの曲の再生が始まった日時（再生が始まっていない場合はNULL）（可変）',
+  `radio_mode` TINYINT(1) NOT NULL DEFAULT '0' COMMENT '未再生の曲が少なくなったときにおすすめの曲を自動で追加するかどうか（可変）',
+  `max_volume` INT NOT NULL DEFAULT '0' COMMENT 'APIから設定できる音量の上限（%）（0は無制限）（可変）',
+  `device_fallback` TINYINT(1) NOT NULL DEFAULT '0' COMMENT '再生するデバイスが見つからないときに別のデバイスで再生し直すかどうか（可変）',
+  `repeat_queue` TINYINT(1) NOT NULL DEFAULT '0' COMMENT 'キューの最後の曲の再生が終わったときに最初の曲に戻って再生を続けるかどうか（可変）',
+  `sleep_after_tracks` INT NOT NULL DEFAULT '0' COMMENT 'スリープタイマーで再生を止めるまでに再生し終える残りの曲数（0は無効）（可変）',
+  `sleep_at` DATETIME NULL DEFAULT NULL COMMENT 'スリープタイマーで再生を止める日時（無効の場合はNULL）（可変）',
+  `scheduled_start_at` DATETIME NULL DEFAULT NULL COMMENT '再生を開始する予約日時（予約していない場合はNULL）（可変）',
   PRIMARY KEY (`id`),
   INDEX `sessions_user_id_fk_idx` (`creator_id` ASC) VISIBLE,
   CONSTRAINT `sessions_user_id_fk`
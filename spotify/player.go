@@ -40,6 +40,7 @@ func (c *Client) toDevice(device spotify.PlayerDevice) *entity.Device {
 		ID:           string(device.ID),
 		IsRestricted: device.Restricted,
 		Name:         device.Name,
+		Volume:       device.Volume,
 	}
 }
 
@@ -173,7 +174,7 @@ func (c *Client) PlayWithTracksAndPosition(ctx context.Context, deviceID string,
 	opt := &spotify.PlayOptions{DeviceID: nil, URIs: c.toURIs(trackURIs), PositionMs: int(position.Milliseconds())}
 	if deviceID != "" {
 		spotifyID := spotify.ID(deviceID)
-		opt = &spotify.PlayOptions{DeviceID: &spotifyID, URIs: c.toURIs(trackURIs)}
+		opt = &spotify.PlayOptions{DeviceID: &spotifyID, URIs: c.toURIs(trackURIs), PositionMs: int(position.Milliseconds())}
 	}
 
 	err := cli.PlayOpt(opt)
@@ -206,6 +207,71 @@ func (c *Client) Pause(ctx context.Context, deviceID string) error {
 	return nil
 }
 
+// Seek は再生中の曲の再生位置を変更します。deviceIDが空の場合はデフォルトのデバイスで再生されます。
+// APIが非同期で処理がされるため、リクエストが返ってきても再生位置が変更されているとは限りません。
+// 設定が反映されたか確認するには CurrentlyPlaying() を叩く必要があります。
+// プレミアム会員必須
+func (c *Client) Seek(ctx context.Context, position time.Duration, deviceID string) error {
+	token, ok := service.GetTokenFromContext(ctx)
+	if !ok {
+		return errors.New("token not found")
+	}
+	cli := c.auth.NewClient(token)
+
+	opt := &spotify.PlayOptions{DeviceID: nil}
+	if deviceID != "" {
+		spotifyID := spotify.ID(deviceID)
+		opt = &spotify.PlayOptions{DeviceID: &spotifyID}
+	}
+	err := cli.SeekOpt(int(position.Milliseconds()), opt)
+	if convErr := c.convertPlayerError(err); convErr != nil {
+		return fmt.Errorf("spotify api: seek: %w", convErr)
+	}
+	return nil
+}
+
+// SetVolume は再生しているデバイスの音量 (%) を変更します。deviceIDが空の場合はデフォルトのデバイスで再生されます。
+// APIが非同期で処理がされるため、リクエストが返ってきても音量が変更されているとは限りません。
+// 設定が反映されたか確認するには CurrentlyPlaying() を叩く必要があります。
+// プレミアム会員必須
+func (c *Client) SetVolume(ctx context.Context, volume int, deviceID string) error {
+	token, ok := service.GetTokenFromContext(ctx)
+	if !ok {
+		return errors.New("token not found")
+	}
+	cli := c.auth.NewClient(token)
+
+	opt := &spotify.PlayOptions{DeviceID: nil}
+	if deviceID != "" {
+		spotifyID := spotify.ID(deviceID)
+		opt = &spotify.PlayOptions{DeviceID: &spotifyID}
+	}
+	err := cli.VolumeOpt(volume, opt)
+	if convErr := c.convertPlayerError(err); convErr != nil {
+		return fmt.Errorf("spotify api: set volume: %w", convErr)
+	}
+	return nil
+}
+
+// TransferPlayback は再生を指定したデバイスに移します。再生位置とキューはそのまま引き継がれます。
+// playがfalseのときは移す前の再生状態を維持します。
+// APIが非同期で処理がされるため、リクエストが返ってきても再生が移っているとは限りません。
+// 設定が反映されたか確認するには CurrentlyPlaying() を叩く必要があります。
+// プレミアム会員必須
+func (c *Client) TransferPlayback(ctx context.Context, deviceID string, play bool) error {
+	token, ok := service.GetTokenFromContext(ctx)
+	if !ok {
+		return errors.New("token not found")
+	}
+	cli := c.auth.NewClient(token)
+
+	err := cli.TransferPlayback(spotify.ID(deviceID), play)
+	if convErr := c.convertPlayerError(err); convErr != nil {
+		return fmt.Errorf("spotify api: transfer playback: %w", convErr)
+	}
+	return nil
+}
+
 // Enqueue は曲を「次に再生される曲」に追加するAPIです。deviceIDが空の場合はデフォルトのデバイスで再生されます。
 // APIが非同期で処理がされるため、リクエストが返ってきても曲の追加が完了しているとは限りません。
 // 設定が反映されたか確認するには CurrentlyPlaying() を叩く必要があります。
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"net/http"
 	"strings"
 	"time"
 
@@ -80,7 +81,7 @@ func (c *Client) GetTracksFromURI(ctx context.Context, trackURIs []string) ([]*e
 			var err error
 			resultTracks, err = cli.GetTracks(idsForAPI...)
 			if err != nil {
-				return nil, fmt.Errorf("get track uris=%s: %w", trackURIs, err)
+				return nil, fmt.Errorf("get track uris=%s: %w", trackURIs, c.convertTrackError(err))
 			}
 			c.cache.SetDefault(getTracksKey+key, resultTracks)
 		}
@@ -95,6 +96,108 @@ func (c *Client) GetTracksFromURI(ctx context.Context, trackURIs []string) ([]*e
 	return tracks, nil
 }
 
+// GetTrackURIsFromAlbum はSpotify APIを通して、与えられたAlbum URIのアルバムに含まれる曲のTrack URIを取得します。
+func (c *Client) GetTrackURIsFromAlbum(ctx context.Context, albumURI string) ([]string, error) {
+	// GetAlbumTracksOptは一度につき50曲までしか取得できない
+	const limit = 50
+
+	token, ok := service.GetTokenFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("token not found")
+	}
+	cli := c.auth.NewClient(token)
+
+	id := spotify.ID(strings.Replace(albumURI, "spotify:album:", "", 1))
+
+	var trackURIs []string
+	for offset := 0; ; offset += limit {
+		page, err := cli.GetAlbumTracksOpt(id, limit, offset)
+		if err != nil {
+			return nil, fmt.Errorf("get album tracks uri=%s offset=%d: %w", albumURI, offset, c.convertTrackError(err))
+		}
+		for _, t := range page.Tracks {
+			trackURIs = append(trackURIs, string(t.URI))
+		}
+		if page.Next == "" {
+			break
+		}
+	}
+
+	return trackURIs, nil
+}
+
+// GetTrackURIsFromPlaylist はSpotify APIを通して、与えられたPlaylist URIのプレイリストに含まれる曲のTrack URIを取得します。
+// ローカルファイルなどSpotify上の曲ではないものは除きます。
+func (c *Client) GetTrackURIsFromPlaylist(ctx context.Context, playlistURI string) ([]string, error) {
+	// GetPlaylistTracksOptは一度につき100曲までしか取得できない
+	const limit = 100
+
+	token, ok := service.GetTokenFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("token not found")
+	}
+	cli := c.auth.NewClient(token)
+
+	id := spotify.ID(strings.Replace(playlistURI, "spotify:playlist:", "", 1))
+
+	var trackURIs []string
+	for offset := 0; ; offset += limit {
+		l, o := limit, offset
+		page, err := cli.GetPlaylistTracksOpt(id, &spotify.Options{Limit: &l, Offset: &o}, "items(is_local,track(uri)),next")
+		if err != nil {
+			return nil, fmt.Errorf("get playlist tracks uri=%s offset=%d: %w", playlistURI, offset, c.convertTrackError(err))
+		}
+		for _, t := range page.Tracks {
+			if t.IsLocal || !strings.HasPrefix(string(t.Track.URI), "spotify:track:") {
+				continue
+			}
+			trackURIs = append(trackURIs, string(t.Track.URI))
+		}
+		if page.Next == "" {
+			break
+		}
+	}
+
+	return trackURIs, nil
+}
+
+// GetRecommendedTrackURIs はSpotify APIを通して、与えられたTrack URIの曲を元におすすめの曲のTrack URIを取得します。
+// トークンのユーザの国で再生できる曲のみを返します。
+func (c *Client) GetRecommendedTrackURIs(ctx context.Context, seedTrackURIs []string, limit int) ([]string, error) {
+	token, ok := service.GetTokenFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("token not found")
+	}
+	cli := c.auth.NewClient(token)
+
+	ids := make([]spotify.ID, len(seedTrackURIs))
+	for i, trackURI := range seedTrackURIs {
+		ids[i] = spotify.ID(strings.Replace(trackURI, "spotify:track:", "", 1))
+	}
+
+	market := spotify.MarketFromToken
+	result, err := cli.GetRecommendations(spotify.Seeds{Tracks: ids}, nil, &spotify.Options{Limit: &limit, Country: &market})
+	if err != nil {
+		return nil, fmt.Errorf("get recommendations seeds=%s: %w", seedTrackURIs, c.convertTrackError(err))
+	}
+
+	trackURIs := make([]string, len(result.Tracks))
+	for i, t := range result.Tracks {
+		trackURIs[i] = string(t.URI)
+	}
+	return trackURIs, nil
+}
+
+// convertTrackError は不正なIDや存在しないIDを指定した際のエラーをentity.ErrTrackNotFoundに変換します。
+func (c *Client) convertTrackError(err error) error {
+	if e, ok := err.(spotify.Error); ok {
+		if e.Status == http.StatusBadRequest || e.Status == http.StatusNotFound {
+			return fmt.Errorf("%s: %w", e.Message, entity.ErrTrackNotFound)
+		}
+	}
+	return err
+}
+
 func (c *Client) idsToCacheKey(ids []spotify.ID) string {
 	buff := bytes.Buffer{}
 	for _, id := range ids {
@@ -129,6 +232,7 @@ func (c *Client) toTrack(fullTrack *spotify.FullTrack) *entity.Track {
 			Name:   fullTrack.Album.Name,
 			Images: c.toImages(fullTrack.Album.Images),
 		},
+		AvailableMarkets: fullTrack.AvailableMarkets,
 	}
 }
 
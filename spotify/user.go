@@ -24,6 +24,7 @@ func (c *Client) GetMe(ctx context.Context) (*entity.SpotifyUser, error) {
 		SpotifyUserID: user.ID,
 		DisplayName:   user.DisplayName,
 		Product:       user.Product,
+		Country:       user.Country,
 	}, nil
 }
 
@@ -49,6 +50,7 @@ func (c *Client) toDevices(resultDevices []spotify.PlayerDevice) []*entity.Devic
 			ID:           rd.ID.String(),
 			IsRestricted: rd.Restricted,
 			Name:         rd.Name,
+			Volume:       rd.Volume,
 		}
 	}
 
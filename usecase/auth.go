@@ -148,3 +148,20 @@ func (u *AuthUseCase) GetTokenAndCreatorIDBySessionID(sessionID string) (*oauth2
 
 	return token, creatorID, nil
 }
+
+// SetCreatorTokenToContext はセッションの作成者のトークンを必要に応じて更新し、作成者のIDと共にctxにセットします。
+// HTTPリクエストの外でセッションの作成者の代わりにSpotifyを操作するときに使います。
+func (u *AuthUseCase) SetCreatorTokenToContext(ctx context.Context, sessionID string) (context.Context, error) {
+	token, creatorID, err := u.sessionRepo.FindCreatorTokenBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("FindCreatorTokenBySessionID: sessionID=%s: %w", sessionID, err)
+	}
+	token, err = u.RefreshAccessToken(creatorID, token)
+	if err != nil {
+		return nil, fmt.Errorf("refresh creator token sessionID=%s: %w", sessionID, err)
+	}
+
+	ctx = service.SetTokenToContext(ctx, token)
+	ctx = service.SetCreatorIDToContext(ctx, creatorID)
+	return ctx, nil
+}
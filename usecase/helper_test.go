@@ -0,0 +1,18 @@
+package usecase
+
+import "time"
+
+// fixedNow はテストで使うFakeClockの現在時刻です。
+var fixedNow = time.Date(2020, 1, 1, 12, 30, 0, 0, time.UTC)
+
+// waitUntil はcondがtrueを返すまで待ち、1秒以内にtrueにならなければfalseを返します。
+func waitUntil(cond func() bool) bool {
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if cond() {
+			return true
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return cond()
+}
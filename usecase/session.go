@@ -3,10 +3,13 @@ package usecase
 import (
 	"context"
 	"fmt"
+	"math/rand"
+	"time"
 
 	"github.com/camphor-/relaym-server/domain/entity"
 	"github.com/camphor-/relaym-server/domain/event"
 	"github.com/camphor-/relaym-server/domain/repository"
+	"github.com/camphor-/relaym-server/domain/service"
 	"github.com/camphor-/relaym-server/domain/spotify"
 )
 
@@ -35,45 +38,439 @@ func NewSessionUseCase(sessionRepo repository.Session, userRepo repository.User,
 }
 
 // EnqueueTrack はセッションのqueueにTrackを追加します。
-func (s *SessionUseCase) EnqueueTrack(ctx context.Context, sessionID string, trackURI string) error {
-	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+// アルバムやプレイリストのURIが指定された場合は、含まれる全ての曲を一つのトランザクションで追加します。
+// ログインしているユーザが追加した場合はユーザの表示名を、ゲストが追加した場合は指定されたニックネームを追加した人として記録します。
+// セッションの設定で追加できる曲数や重複が制限されている場合は、制限を超える追加をエラーにします。
+// アルバムやプレイリストの場合は1曲でも追加できなければ、どの曲も追加しません。
+// playNextがtrueのときは、キューの末尾ではなく次に再生されるように未再生の曲の先頭に追加します。
+func (s *SessionUseCase) EnqueueTrack(ctx context.Context, sessionID string, uri string, nickname string, playNext bool) error {
+	userID, _ := service.GetUserIDFromContext(ctx)
+	if userID != "" {
+		user, err := s.userRepo.FindByID(userID)
+		if err != nil {
+			return fmt.Errorf("FindByID userID=%s: %w", userID, err)
+		}
+		nickname = user.DisplayName
+	}
+
+	trackURIs, err := s.trackURIsFromURI(ctx, uri)
+	if err != nil {
+		return fmt.Errorf("get track uris uri=%s: %w", uri, err)
+	}
+	if len(trackURIs) == 0 {
+		return nil
+	}
+
+	res, err := s.sessionRepo.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+		session, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("FindByID sessionID=%s: %w", sessionID, err)
+		}
+		if playNext && !session.AllowToControlByOthers && !session.IsCreator(userID) {
+			return nil, fmt.Errorf("not allowd to play next: %w", entity.ErrSessionNotAllowToControlOthers)
+		}
+		before := session.TrackURIsInSpotifyQueue()
+
+		// 全ての曲を追加できるか確認してから保存する
+		now := s.timerUC.now()
+		qts := make([]*entity.QueueTrackToStore, 0, len(trackURIs))
+		for i, trackURI := range trackURIs {
+			if err := session.CanEnqueue(trackURI, userID, nickname, now); err != nil {
+				return nil, fmt.Errorf("can not enqueue URI=%s (track %d of %d, no tracks are enqueued): %w", trackURI, i+1, len(trackURIs), err)
+			}
+
+			qt := &entity.QueueTrackToStore{
+				URI:             trackURI,
+				SessionID:       sessionID,
+				AddedByUserID:   userID,
+				AddedByNickname: nickname,
+				AddedAt:         now,
+			}
+			session.AppendQueueTrack(qt)
+			qts = append(qts, qt)
+		}
+		for _, qt := range qts {
+			if err := s.sessionRepo.StoreQueueTrack(ctx, qt); err != nil {
+				return nil, fmt.Errorf("StoreQueueTrack URI=%s, sessionID=%s: %w", qt.URI, sessionID, err)
+			}
+		}
+
+		if playNext {
+			// 追加した曲のIDを使ってindexを更新するので、セッションを取得し直す
+			session, err = s.moveQueueTracksToNextTx(ctx, sessionID, len(trackURIs))
+			if err != nil {
+				return nil, fmt.Errorf("move queue tracks to next sessionID=%s: %w", sessionID, err)
+			}
+		}
+
+		if session.QueueMode.IsAutoArranged() {
+			if err := s.arrangeQueueTracksTx(ctx, sessionID); err != nil {
+				return nil, fmt.Errorf("arrange queue tracks sessionID=%s: %w", sessionID, err)
+			}
+		}
+
+		// Spotifyのキューに先読みして積まれる範囲の曲は並び替えの対象外なので、並び替える前のsessionから求められる
+		return newEnqueueResult(session, before), nil
+	})
 	if err != nil {
-		return fmt.Errorf("FindByID sessionID=%s: %w", sessionID, err)
+		return fmt.Errorf("enqueue track in transaction sessionID=%s: %w", sessionID, err)
 	}
 
-	err = s.sessionRepo.StoreQueueTrack(ctx, &entity.QueueTrackToStore{
-		URI:       trackURI,
+	result, ok := res.(*enqueueResult)
+	if !ok {
+		return fmt.Errorf("enqueue track in transaction sessionID=%s: unexpected response", sessionID)
+	}
+
+	if result.shouldResync {
+		if err := s.timerUC.syncSpotifyQueue(ctx, result.sess); err != nil {
+			return fmt.Errorf("sync spotify queue sessionID=%s: %w", sessionID, err)
+		}
+	}
+	for _, trackURI := range result.urisToEnqueueAPI {
+		if err := s.playerCli.Enqueue(ctx, trackURI, result.sess.DeviceID); err != nil {
+			return fmt.Errorf("Enqueue URI=%s, sessionID=%s: %w", trackURI, sessionID, err)
+		}
+	}
+	s.pusher.Push(&event.PushMessage{
 		SessionID: sessionID,
+		Msg:       entity.NewEventAddTrack(userID, nickname, len(trackURIs)),
 	})
+
+	return nil
+}
+
+// moveQueueTracksToNextTx はキューの末尾のcount曲を次に再生されるように移動して保存します。
+// 追加した曲のIDを取得するためにセッションを取得し直すので、トランザクションの中で呼び出す必要があります。
+func (s *SessionUseCase) moveQueueTracksToNextTx(ctx context.Context, sessionID string, count int) (*entity.Session, error) {
+	sess, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("find session id=%s: %w", sessionID, err)
+	}
+
+	start, err := sess.MoveQueueTracksToNext(count)
 	if err != nil {
-		return fmt.Errorf("StoreQueueTrack URI=%s, sessionID=%s: %w", trackURI, sessionID, err)
+		return nil, fmt.Errorf("move queue tracks to next: %w", err)
 	}
 
-	if session.ShouldCallEnqueueAPINow() {
-		err = s.playerCli.Enqueue(ctx, trackURI, session.DeviceID)
+	if err := s.sessionRepo.UpdateQueueTrackIndexes(ctx, sess.QueueTracks[start:]); err != nil {
+		return nil, fmt.Errorf("UpdateQueueTrackIndexes: %w", err)
+	}
+	return sess, nil
+}
+
+// enqueueResult はEnqueueTrackのトランザクションの結果を表します。
+// Spotifyのキューに先読みして積まれている曲の後ろに追加するだけで済む場合はurisToEnqueueAPIに追加する曲を、
+// 積まれている曲の順番が変わる場合はshouldResyncをtrueにします。
+type enqueueResult struct {
+	sess             *entity.Session
+	urisToEnqueueAPI []string
+	shouldResync     bool
+}
+
+// newEnqueueResult は曲を追加する前にSpotifyのキューに積まれていた曲と、追加した後のセッションからenqueueResultを生成します。
+func newEnqueueResult(sess *entity.Session, before []string) *enqueueResult {
+	after := sess.TrackURIsInSpotifyQueue()
+	if len(after) < len(before) || !equalURIs(before, after[:len(before)]) {
+		return &enqueueResult{sess: sess, shouldResync: true}
+	}
+	return &enqueueResult{sess: sess, urisToEnqueueAPI: after[len(before):]}
+}
+
+// trackURIsFromURI はアルバムやプレイリストのURIをそれに含まれる曲のTrack URIに展開し、セッションの作成者の国で再生できる曲のみを返します。
+// 曲のURIが直接指定された場合は、その曲が存在しないか再生できなければエラーを返します。
+func (s *SessionUseCase) trackURIsFromURI(ctx context.Context, uri string) ([]string, error) {
+	var trackURIs []string
+	switch {
+	case entity.IsTrackURI(uri):
+		trackURIs = []string{uri}
+	case entity.IsAlbumURI(uri):
+		uris, err := s.trackCli.GetTrackURIsFromAlbum(ctx, uri)
 		if err != nil {
-			return fmt.Errorf("Enqueue URI=%s, sessionID=%s: %w", trackURI, sessionID, err)
+			return nil, fmt.Errorf("GetTrackURIsFromAlbum uri=%s: %w", uri, err)
 		}
+		trackURIs = uris
+	case entity.IsPlaylistURI(uri):
+		uris, err := s.trackCli.GetTrackURIsFromPlaylist(ctx, uri)
+		if err != nil {
+			return nil, fmt.Errorf("GetTrackURIsFromPlaylist uri=%s: %w", uri, err)
+		}
+		trackURIs = uris
+	default:
+		return nil, fmt.Errorf("uri=%s: %w", uri, entity.ErrInvalidTrackURI)
+	}
+	if len(trackURIs) == 0 {
+		return nil, nil
 	}
+
+	// リクエストにはセッションの作成者のトークンが含まれている
+	creator, err := s.userCli.GetMe(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("GetMe: %w", err)
+	}
+	tracks, err := s.trackCli.GetTracksFromURI(ctx, trackURIs)
+	if err != nil {
+		return nil, fmt.Errorf("GetTracksFromURI: %w", err)
+	}
+
+	// アルバムやプレイリストには再生できない曲が含まれることがあるので、それらは除いて追加する
+	skipInvalid := !entity.IsTrackURI(uri)
+	playable := make([]string, 0, len(trackURIs))
+	for i, track := range tracks {
+		switch {
+		case track == nil:
+			if !skipInvalid {
+				return nil, fmt.Errorf("uri=%s: %w", trackURIs[i], entity.ErrTrackNotFound)
+			}
+		case !track.IsPlayableIn(creator.Country):
+			if !skipInvalid {
+				return nil, fmt.Errorf("uri=%s, market=%s: %w", trackURIs[i], creator.Country, entity.ErrTrackNotPlayable)
+			}
+		default:
+			playable = append(playable, trackURIs[i])
+		}
+	}
+	if len(playable) == 0 {
+		return nil, fmt.Errorf("uri=%s, market=%s: %w", uri, creator.Country, entity.ErrTrackNotPlayable)
+	}
+	return playable, nil
+}
+
+// RemoveQueueTrack はセッションのqueueのindex番目のTrackを削除します。
+func (s *SessionUseCase) RemoveQueueTrack(ctx context.Context, sessionID string, index int) error {
+	return s.editQueue(ctx, sessionID, func(ctx context.Context, sess *entity.Session) error {
+		removed, err := sess.RemoveQueueTrack(index)
+		if err != nil {
+			return fmt.Errorf("remove queue track: %w", err)
+		}
+		if err := s.sessionRepo.DeleteQueueTrack(ctx, removed); err != nil {
+			return fmt.Errorf("DeleteQueueTrack id=%d: %w", removed.ID, err)
+		}
+
+		// 公平モードでは曲を追加した人の順番が変わるので並び替える
+		start := sess.ArrangeQueueTracks()
+		if index < start {
+			start = index
+		}
+		if err := s.sessionRepo.UpdateQueueTrackIndexes(ctx, sess.QueueTracks[start:]); err != nil {
+			return fmt.Errorf("UpdateQueueTrackIndexes: %w", err)
+		}
+		return nil
+	})
+}
+
+// MoveQueueTrack はセッションのqueueのfrom番目のTrackをto番目に移動します。
+func (s *SessionUseCase) MoveQueueTrack(ctx context.Context, sessionID string, from, to int) error {
+	return s.editQueue(ctx, sessionID, func(ctx context.Context, sess *entity.Session) error {
+		if err := sess.MoveQueueTrack(from, to); err != nil {
+			return fmt.Errorf("move queue track: %w", err)
+		}
+
+		start, end := from, to
+		if to < from {
+			start, end = to, from
+		}
+		if err := s.sessionRepo.UpdateQueueTrackIndexes(ctx, sess.QueueTracks[start:end+1]); err != nil {
+			return fmt.Errorf("UpdateQueueTrackIndexes: %w", err)
+		}
+		return nil
+	})
+}
+
+// ShuffleQueueTracks はセッションのqueueのまだ再生されていないTrackをランダムに並び替えます。
+// seedが指定されたときはそのseedを使うので、同じqueueは同じ並びになります。
+func (s *SessionUseCase) ShuffleQueueTracks(ctx context.Context, sessionID string, seed *int64) error {
+	src := s.timerUC.now().UnixNano()
+	if seed != nil {
+		src = *seed
+	}
+	rnd := rand.New(rand.NewSource(src))
+
+	return s.editQueue(ctx, sessionID, func(ctx context.Context, sess *entity.Session) error {
+		start, err := sess.ShuffleQueueTracks(rnd)
+		if err != nil {
+			return fmt.Errorf("shuffle queue tracks: %w", err)
+		}
+		if start >= len(sess.QueueTracks) {
+			return nil
+		}
+
+		if err := s.sessionRepo.UpdateQueueTrackIndexes(ctx, sess.QueueTracks[start:]); err != nil {
+			return fmt.Errorf("UpdateQueueTrackIndexes: %w", err)
+		}
+		return nil
+	})
+}
+
+// VoteQueueTrack はセッションのqueueのindex番目のTrackにログインしているユーザとして投票し、スコアに従ってqueueを並び替えます。
+// valueが0のときは投票を取り消します。
+func (s *SessionUseCase) VoteQueueTrack(ctx context.Context, sessionID string, index int, value int) error {
+	userID, _ := service.GetUserIDFromContext(ctx)
+
+	_, err := s.sessionRepo.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+		sess, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("find session id=%s: %w", sessionID, err)
+		}
+
+		qt, err := sess.VotableQueueTrack(index)
+		if err != nil {
+			return nil, fmt.Errorf("find votable queue track: %w", err)
+		}
+
+		if value == 0 {
+			if err := s.sessionRepo.DeleteQueueTrackVote(ctx, qt.ID, userID); err != nil {
+				return nil, fmt.Errorf("DeleteQueueTrackVote queueTrackID=%d userID=%s: %w", qt.ID, userID, err)
+			}
+		} else {
+			vote, err := entity.NewQueueTrackVote(qt.ID, userID, value)
+			if err != nil {
+				return nil, fmt.Errorf("new queue track vote: %w", err)
+			}
+			if err := s.sessionRepo.StoreQueueTrackVote(ctx, vote); err != nil {
+				return nil, fmt.Errorf("StoreQueueTrackVote queueTrackID=%d userID=%s: %w", qt.ID, userID, err)
+			}
+		}
+
+		return nil, s.arrangeQueueTracksTx(ctx, sessionID)
+	})
+	if err != nil {
+		return fmt.Errorf("vote queue track in transaction sessionID=%s: %w", sessionID, err)
+	}
+
+	s.pusher.Push(&event.PushMessage{
+		SessionID: sessionID,
+		Msg:       entity.EventQueueChanged,
+	})
+	return nil
+}
+
+// UpdateSettings はセッションの設定を変更し、新しい設定に従ってqueueを並び替えます。
+// 設定を変更できるのはセッションの作成者のみです。
+func (s *SessionUseCase) UpdateSettings(ctx context.Context, sessionID string, settings *entity.SessionSettingsToUpdate) error {
+	_, err := s.sessionRepo.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+		sess, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("find session id=%s: %w", sessionID, err)
+		}
+
+		userID, _ := service.GetUserIDFromContext(ctx)
+		if !sess.IsCreator(userID) {
+			return nil, fmt.Errorf("update settings userID=%s: %w", userID, entity.ErrUserIsNotSessionCreator)
+		}
+
+		if err := sess.UpdateSettings(settings); err != nil {
+			return nil, fmt.Errorf("update settings: %w", err)
+		}
+		if err := s.sessionRepo.Update(ctx, sess); err != nil {
+			return nil, fmt.Errorf("update session id=%s: %w", sessionID, err)
+		}
+
+		return nil, s.arrangeQueueTracksTx(ctx, sessionID)
+	})
+	if err != nil {
+		return fmt.Errorf("update settings in transaction sessionID=%s: %w", sessionID, err)
+	}
+
 	s.pusher.Push(&event.PushMessage{
 		SessionID: sessionID,
-		Msg:       entity.EventAddTrack,
+		Msg:       entity.EventSettingsChanged,
 	})
+	return nil
+}
+
+// arrangeQueueTracksTx はキューのモードに従ってqueueを並び替えて保存します。
+// 投票の結果を反映させるためにセッションを取得し直すので、トランザクションの中で呼び出す必要があります。
+func (s *SessionUseCase) arrangeQueueTracksTx(ctx context.Context, sessionID string) error {
+	sess, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("find session id=%s: %w", sessionID, err)
+	}
+
+	start := sess.ArrangeQueueTracks()
+	if start >= len(sess.QueueTracks) {
+		return nil
+	}
+
+	if err := s.sessionRepo.UpdateQueueTrackIndexes(ctx, sess.QueueTracks[start:]); err != nil {
+		return fmt.Errorf("UpdateQueueTrackIndexes: %w", err)
+	}
+	return nil
+}
+
+// editQueue はトランザクションの中でセッションのqueueを編集し、
+// Spotifyのキューに積まれている曲が変わった場合はSpotifyのキューを積み直してからQUEUE_CHANGEDイベントを送ります。
+func (s *SessionUseCase) editQueue(ctx context.Context, sessionID string, edit func(ctx context.Context, sess *entity.Session) error) error {
+	type editQueueResponse struct {
+		sess         *entity.Session
+		shouldResync bool
+	}
+
+	res, err := s.sessionRepo.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+		sess, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("find session id=%s: %w", sessionID, err)
+		}
+
+		userID, _ := service.GetUserIDFromContext(ctx)
+		if !sess.AllowToControlByOthers && !sess.IsCreator(userID) {
+			return nil, fmt.Errorf("not allowd to edit queue: %w", entity.ErrSessionNotAllowToControlOthers)
+		}
+
+		before := sess.TrackURIsInSpotifyQueue()
+		if err := edit(ctx, sess); err != nil {
+			return nil, err
+		}
+		after := sess.TrackURIsInSpotifyQueue()
+
+		return &editQueueResponse{sess: sess, shouldResync: !equalURIs(before, after)}, nil
+	})
+	if err != nil {
+		return fmt.Errorf("edit queue in transaction sessionID=%s: %w", sessionID, err)
+	}
 
+	v, ok := res.(*editQueueResponse)
+	if !ok {
+		return fmt.Errorf("edit queue in transaction sessionID=%s: unexpected response", sessionID)
+	}
+
+	if v.shouldResync {
+		if err := s.timerUC.syncSpotifyQueue(ctx, v.sess); err != nil {
+			return fmt.Errorf("sync spotify queue sessionID=%s: %w", sessionID, err)
+		}
+	}
+
+	s.pusher.Push(&event.PushMessage{
+		SessionID: sessionID,
+		Msg:       entity.EventQueueChanged,
+	})
 	return nil
 }
 
+func equalURIs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 // CreateSession は与えられたセッション名のセッションを作成します。
-func (s *SessionUseCase) CreateSession(ctx context.Context, sessionName string, creatorID string, allowToControlByOthers bool) (*entity.SessionWithUser, error) {
+// scheduledStartAtがゼロ値でなければ、その日時に再生を開始するように予約します。
+func (s *SessionUseCase) CreateSession(ctx context.Context, sessionName string, creatorID string, allowToControlByOthers bool, scheduledStartAt time.Time) (*entity.SessionWithUser, error) {
 	creator, err := s.userRepo.FindByID(creatorID)
 	if err != nil {
 		return nil, fmt.Errorf("FindByID userID=%s: %w", creatorID, err)
 	}
 
-	newSession, err := entity.NewSession(sessionName, creatorID, allowToControlByOthers)
+	newSession, err := entity.NewSession(sessionName, creatorID, allowToControlByOthers, s.timerUC.now())
 	if err != nil {
 		return nil, fmt.Errorf("NewSession sessionName=%s: %w", sessionName, err)
 	}
+	newSession.ScheduledStartAt = scheduledStartAt
 
 	err = s.sessionRepo.StoreSession(ctx, newSession)
 	if err != nil {
@@ -93,7 +490,7 @@ func (s *SessionUseCase) CanConnectToPusher(ctx context.Context, sessionID strin
 	// サーバ再起動でタイマーがなくなると、イベントが正しくクライアントに送られなくなるのでこのタイミングで復旧させる。
 	if exists := s.timerUC.existsTimer(sessionID); !exists && sess.IsPlaying() {
 		fmt.Printf("session timer not found: create timer: sessionID=%s\n", sessionID)
-		go s.timerUC.startTrackEndTrigger(ctx, sessionID)
+		s.timerUC.startTrackEndTrigger(sessionID)
 	}
 
 	return nil
@@ -106,11 +503,43 @@ func (s *SessionUseCase) SetDevice(ctx context.Context, sessionID string, device
 		return fmt.Errorf("find session id=%s: %w", sessionID, err)
 	}
 
+	if sess.DeviceID == deviceID {
+		return nil
+	}
+
+	if err := s.transferPlayback(ctx, sess, deviceID); err != nil {
+		return fmt.Errorf("transfer playback: device_id=%s session_id=%s: %w", deviceID, sess.ID, err)
+	}
+
 	sess.DeviceID = deviceID
 	if err := s.sessionRepo.Update(ctx, sess); err != nil {
 		return fmt.Errorf("update device id: device_id=%s session_id=%s: %w", deviceID, sess.ID, err)
 	}
 
+	if sess.StateType == entity.Play {
+		s.timerUC.startTrackEndTrigger(sess.ID)
+	}
+
+	s.pusher.Push(&event.PushMessage{
+		SessionID: sess.ID,
+		Msg:       entity.EventDeviceChanged,
+	})
+	return nil
+}
+
+// transferPlayback はPLAYとPAUSEのときに、再生中の曲を同じ再生位置のまま新しいデバイスに移します。
+// PAUSEのときは一時停止したまま移すので、再開時はProgressWhenPausedの位置から新しいデバイスで再生されます。
+func (s *SessionUseCase) transferPlayback(ctx context.Context, sess *entity.Session, deviceID string) error {
+	switch sess.StateType {
+	case entity.Play:
+		if err := s.playerCli.TransferPlayback(ctx, deviceID, true); err != nil {
+			return fmt.Errorf("call transfer playback api: %w", err)
+		}
+	case entity.Pause:
+		if err := s.playerCli.TransferPlayback(ctx, deviceID, false); err != nil {
+			return fmt.Errorf("call transfer playback api: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -141,23 +570,14 @@ func (s *SessionUseCase) GetSession(ctx context.Context, sessionID string) (*ent
 		return nil, nil, nil, fmt.Errorf("CurrentlyPlaying: %w", err)
 	}
 
-	if !s.timerUC.existsTimer(sessionID) {
-		return entity.NewSessionWithUser(session, creator), tracks, cpi, nil
-	}
-
-	isExpired, err := s.timerUC.isTimerExpired(sessionID)
-
-	if isExpired {
+	// 曲の切り替わりの途中は同期が取れていないことがあるので、曲の終了を待っているときだけ確認する
+	if !s.timerUC.isWaitingTrackEnd(sessionID) {
 		return entity.NewSessionWithUser(session, creator), tracks, cpi, nil
 	}
 
-	if err != nil {
-		return nil, nil, nil, fmt.Errorf("isTimerExpired: %w", err)
-	}
-
 	if err := session.IsPlayingCorrectTrack(cpi); err != nil {
 		s.timerUC.deleteTimer(session.ID)
-		s.timerUC.handleInterrupt(session)
+		s.timerUC.handleInterrupt(ctx, session)
 
 		if updateErr := s.sessionRepo.Update(ctx, session); updateErr != nil {
 			return nil, nil, nil, fmt.Errorf("update session id=%s: %v: %w", session.ID, err, updateErr)
@@ -167,6 +587,30 @@ func (s *SessionUseCase) GetSession(ctx context.Context, sessionID string) (*ent
 	return entity.NewSessionWithUser(session, creator), tracks, cpi, nil
 }
 
+// GetPlayedTracks は指定されたidのsessionで再生された曲の履歴を再生された順に取得します。
+func (s *SessionUseCase) GetPlayedTracks(ctx context.Context, sessionID string) ([]*entity.PlayedTrack, []*entity.Track, error) {
+	if _, err := s.sessionRepo.FindByID(ctx, sessionID); err != nil {
+		return nil, nil, fmt.Errorf("FindByID sessionID=%s: %w", sessionID, err)
+	}
+
+	playedTracks, err := s.sessionRepo.FindPlayedTracksBySessionID(ctx, sessionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("find played tracks sessionID=%s: %w", sessionID, err)
+	}
+
+	trackURIs := make([]string, len(playedTracks))
+	for i, pt := range playedTracks {
+		trackURIs[i] = pt.URI
+	}
+
+	tracks, err := s.trackCli.GetTracksFromURI(ctx, trackURIs)
+	if err != nil {
+		return nil, nil, fmt.Errorf("get tracks: track_uris=%s: %w", trackURIs, err)
+	}
+
+	return playedTracks, tracks, nil
+}
+
 // GetActiveDevices はログインしているユーザがSpotifyを起動している端末を取得します。
 func (s *SessionUseCase) GetActiveDevices(ctx context.Context) ([]*entity.Device, error) {
 	return s.userCli.GetActiveDevices(ctx)
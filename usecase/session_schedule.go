@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/camphor-/relaym-server/domain/entity"
+	"github.com/camphor-/relaym-server/domain/event"
+	"github.com/camphor-/relaym-server/domain/repository"
+	"github.com/camphor-/relaym-server/domain/service"
+	"github.com/camphor-/relaym-server/log"
+)
+
+// scheduledStartCheckInterval は再生開始の予約日時になったセッションを確認する間隔です。
+const scheduledStartCheckInterval = 10 * time.Second
+
+// SessionScheduleUseCase は予約された日時にセッションの再生を開始するユースケースです。
+type SessionScheduleUseCase struct {
+	sessionRepo repository.Session
+	authUC      *AuthUseCase
+	stateUC     *SessionStateUseCase
+	pusher      event.Pusher
+	clock       entity.Clock
+}
+
+// NewSessionScheduleUseCase はSessionScheduleUseCaseのポインタを生成します。
+func NewSessionScheduleUseCase(sessionRepo repository.Session, authUC *AuthUseCase, stateUC *SessionStateUseCase, pusher event.Pusher, clock entity.Clock) *SessionScheduleUseCase {
+	return &SessionScheduleUseCase{sessionRepo: sessionRepo, authUC: authUC, stateUC: stateUC, pusher: pusher, clock: clock}
+}
+
+// Run は一定間隔で予約日時になったセッションの再生を開始します。ctxがキャンセルされるまで処理を続けます。
+// goroutineで実行されることを想定しています。
+func (s *SessionScheduleUseCase) Run(ctx context.Context) {
+	logger := log.New()
+	timer := s.clock.NewTimer(scheduledStartCheckInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C():
+			timer.Reset(scheduledStartCheckInterval)
+			if err := s.StartScheduledSessions(ctx, s.clock.Now().UTC()); err != nil {
+				logger.Errorj(map[string]interface{}{"message": "failed to start scheduled sessions", "error": err.Error()})
+			}
+		}
+	}
+}
+
+// StartScheduledSessions は再生開始の予約日時がnow以前になっているセッションの再生を、作成者の代わりに開始します。
+// 結果はイベントでクライアントに通知します。
+func (s *SessionScheduleUseCase) StartScheduledSessions(ctx context.Context, now time.Time) error {
+	ids, err := s.sessionRepo.FindIDsScheduledToStart(ctx, now)
+	if err != nil {
+		return fmt.Errorf("find sessions scheduled to start: %w", err)
+	}
+
+	for _, id := range ids {
+		s.startScheduledSession(ctx, id, now)
+	}
+	return nil
+}
+
+func (s *SessionScheduleUseCase) startScheduledSession(ctx context.Context, sessionID string, now time.Time) {
+	logger := log.New()
+
+	shouldStart, err := s.clearSchedule(ctx, sessionID, now)
+	if err != nil {
+		logger.Errorj(map[string]interface{}{"message": "failed to clear scheduled start", "sessionID": sessionID, "error": err.Error()})
+		return
+	}
+	if !shouldStart {
+		return
+	}
+
+	if err := s.startAsCreator(ctx, sessionID); err != nil {
+		logger.Infoj(map[string]interface{}{"message": "failed to start scheduled session", "sessionID": sessionID, "error": err.Error()})
+		s.pusher.Push(&event.PushMessage{
+			SessionID: sessionID,
+			Msg:       entity.NewEventScheduledStartFailed(scheduledStartFailure(err)),
+		})
+		return
+	}
+
+	logger.Infoj(map[string]interface{}{"message": "start scheduled session", "sessionID": sessionID})
+	s.pusher.Push(&event.PushMessage{
+		SessionID: sessionID,
+		Msg:       entity.EventScheduledStart,
+	})
+}
+
+// clearSchedule は再生開始の予約を解除し、再生を開始する必要があるかどうか返します。
+// 既に再生中のセッションは予約を解除するだけです。
+func (s *SessionScheduleUseCase) clearSchedule(ctx context.Context, sessionID string, now time.Time) (bool, error) {
+	shouldStart, err := s.sessionRepo.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+		sess, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return false, fmt.Errorf("find session id=%s: %w", sessionID, err)
+		}
+		if !sess.IsScheduledToStart(now) {
+			return false, nil
+		}
+
+		sess.ScheduledStartAt = time.Time{}
+		if err := s.sessionRepo.Update(ctx, sess); err != nil {
+			return false, fmt.Errorf("update session id=%s: %w", sessionID, err)
+		}
+		return !sess.IsPlaying(), nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("clear scheduled start in transaction: %w", err)
+	}
+	return shouldStart.(bool), nil
+}
+
+// startAsCreator はセッションの作成者のトークンを使ってセッションの再生を開始します。
+func (s *SessionScheduleUseCase) startAsCreator(ctx context.Context, sessionID string) error {
+	ctx, err := s.authUC.SetCreatorTokenToContext(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("set creator token to context: %w", err)
+	}
+	// 作成者として操作する
+	creatorID, _ := service.GetCreatorIDFromContext(ctx)
+	ctx = service.SetUserIDToContext(ctx, creatorID)
+
+	if err := s.stateUC.ChangeSessionState(ctx, sessionID, entity.Play); err != nil {
+		return fmt.Errorf("change session state to play: %w", err)
+	}
+	return nil
+}
+
+func scheduledStartFailure(err error) entity.ScheduledStartFailure {
+	switch {
+	case errors.Is(err, entity.ErrActiveDeviceNotFound):
+		return entity.ScheduledStartFailureActiveDeviceNotFound
+	case errors.Is(err, entity.ErrQueueTrackNotFound), errors.Is(err, entity.ErrNextQueueTrackNotFound):
+		return entity.ScheduledStartFailureQueueTrackNotFound
+	case errors.Is(err, entity.ErrChangeSessionStateNotPermit):
+		return entity.ScheduledStartFailureStateNotPermit
+	}
+	return entity.ScheduledStartFailureUnknown
+}
@@ -0,0 +1,145 @@
+package usecase
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/camphor-/relaym-server/domain/entity"
+	"github.com/camphor-/relaym-server/domain/event"
+	"github.com/camphor-/relaym-server/domain/mock_event"
+	"github.com/camphor-/relaym-server/domain/mock_repository"
+	"github.com/camphor-/relaym-server/domain/mock_spotify"
+	"github.com/golang/mock/gomock"
+	"golang.org/x/oauth2"
+)
+
+func TestSessionScheduleUseCase_startScheduledSession(t *testing.T) {
+	t.Parallel()
+
+	now := time.Date(2020, 1, 1, 9, 0, 0, 0, time.UTC)
+	token := &oauth2.Token{AccessToken: "access_token", Expiry: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+	}{
+		{
+			name:                "予約が解除されていたら何もしない",
+			sessionID:           "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:        "sessionID",
+					StateType: entity.Stop,
+				}, nil)
+			},
+		},
+		{
+			name:                "既に再生中のときは予約を解除するだけ",
+			sessionID:           "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:               "sessionID",
+					StateType:        entity.Play,
+					ScheduledStartAt: now,
+				}, nil)
+				m.EXPECT().Update(gomock.Any(), &entity.Session{
+					ID:        "sessionID",
+					StateType: entity.Play,
+				}).Return(nil)
+			},
+		},
+		{
+			name:      "デバイスが見つからないときはSCHEDULED_START_FAILEDイベントを送る",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().SetRepeatMode(gomock.Any(), false, "").Return(entity.ErrActiveDeviceNotFound)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.NewEventScheduledStartFailed(entity.ScheduledStartFailureActiveDeviceNotFound),
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:               "sessionID",
+					CreatorID:        "creator_id",
+					StateType:        entity.Stop,
+					ScheduledStartAt: now.Add(-time.Second),
+				}, nil)
+				m.EXPECT().Update(gomock.Any(), &entity.Session{
+					ID:        "sessionID",
+					CreatorID: "creator_id",
+					StateType: entity.Stop,
+				}).Return(nil)
+				m.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(token, "creator_id", nil)
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:        "sessionID",
+					CreatorID: "creator_id",
+					StateType: entity.Stop,
+				}, nil)
+			},
+		},
+		{
+			name:                "アーカイブされたセッションのときはSCHEDULED_START_FAILEDイベントを送る",
+			sessionID:           "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.NewEventScheduledStartFailed(entity.ScheduledStartFailureStateNotPermit),
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:               "sessionID",
+					CreatorID:        "creator_id",
+					StateType:        entity.Archived,
+					ScheduledStartAt: now,
+				}, nil)
+				m.EXPECT().Update(gomock.Any(), &entity.Session{
+					ID:        "sessionID",
+					CreatorID: "creator_id",
+					StateType: entity.Archived,
+				}).Return(nil)
+				m.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(token, "creator_id", nil)
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:        "sessionID",
+					CreatorID: "creator_id",
+					StateType: entity.Archived,
+				}, nil)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockPlayer := mock_spotify.NewMockPlayer(ctrl)
+			tt.prepareMockPlayerFn(mockPlayer)
+			mockPusher := mock_event.NewMockPusher(ctrl)
+			tt.prepareMockPusherFn(mockPusher)
+			mockSessionRepo := mock_repository.NewMockSession(ctrl)
+			mockSessionRepo.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+					return f(ctx)
+				})
+			tt.prepareMockSessionRepoFn(mockSessionRepo)
+			authUC := NewAuthUseCase(nil, nil, nil, nil, mockSessionRepo)
+			stateUC := NewSessionStateUseCase(mockSessionRepo, mockPlayer, nil, mockPusher, nil)
+			uc := NewSessionScheduleUseCase(mockSessionRepo, authUC, stateUC, mockPusher, entity.NewSystemClock())
+
+			uc.startScheduledSession(context.Background(), tt.sessionID, now)
+		})
+	}
+}
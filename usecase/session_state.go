@@ -11,19 +11,21 @@ import (
 	"github.com/camphor-/relaym-server/domain/repository"
 	"github.com/camphor-/relaym-server/domain/service"
 	"github.com/camphor-/relaym-server/domain/spotify"
+	"github.com/camphor-/relaym-server/log"
 )
 
 // SessionStateUseCase はセッションの再生に関するユースケースです。
 type SessionStateUseCase struct {
 	sessionRepo repository.Session
 	playerCli   spotify.Player
+	userCli     spotify.User
 	pusher      event.Pusher
 	timerUC     *SessionTimerUseCase
 }
 
 // NewSessionPlayerUseCase はSessionPlayerUseCaseのポインタを生成します。
-func NewSessionStateUseCase(sessionRepo repository.Session, playerCli spotify.Player, pusher event.Pusher, timerUC *SessionTimerUseCase) *SessionStateUseCase {
-	return &SessionStateUseCase{sessionRepo: sessionRepo, playerCli: playerCli, pusher: pusher, timerUC: timerUC}
+func NewSessionStateUseCase(sessionRepo repository.Session, playerCli spotify.Player, userCli spotify.User, pusher event.Pusher, timerUC *SessionTimerUseCase) *SessionStateUseCase {
+	return &SessionStateUseCase{sessionRepo: sessionRepo, playerCli: playerCli, userCli: userCli, pusher: pusher, timerUC: timerUC}
 }
 
 // NextTrack は指定されたidのsessionを次の曲に進めます
@@ -88,6 +90,12 @@ func (s *SessionStateUseCase) nextTrackInPauseTx(sessionID string) func(ctx cont
 			return nil, fmt.Errorf("GoNextTrack: %w", err)
 		}
 
+		if err := s.timerUC.storePlayedTrack(ctx, session, entity.TrackEndReasonSkipped, s.timerUC.now()); err != nil {
+			return nil, err
+		}
+
+		s.timerUC.fillRadioTracks(ctx, session)
+
 		if err := session.GoNextTrack(); err != nil && errors.Is(err, entity.ErrSessionAllTracksFinished) {
 			s.timerUC.handleAllTrackFinish(session)
 			if err := s.sessionRepo.Update(ctx, session); err != nil {
@@ -162,6 +170,297 @@ func (s *SessionStateUseCase) nextTrackInStopTx(sessionID string) func(ctx conte
 	}
 }
 
+// PrevTrack は指定されたidのsessionを前の曲に戻します。
+// 曲の再生が始まってから時間が経っている場合は、前の曲に戻らずに現在の曲を最初から再生し直します。
+func (s *SessionStateUseCase) PrevTrack(ctx context.Context, sessionID string) error {
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("find session id=%s: %w", sessionID, err)
+	}
+
+	userID, _ := service.GetUserIDFromContext(ctx)
+	if !session.AllowToControlByOthers && !session.IsCreator(userID) {
+		return fmt.Errorf("not allowd to control session: %w", entity.ErrSessionNotAllowToControlOthers)
+	}
+
+	v, err := s.sessionRepo.DoInTx(ctx, s.prevTrackTx(sessionID))
+	if err != nil {
+		return fmt.Errorf("prev track in transaction sessionID=%s: %w", sessionID, err)
+	}
+	result, ok := v.(*prevTrackResult)
+	if !ok {
+		return fmt.Errorf("prev track in transaction sessionID=%s: unexpected response", sessionID)
+	}
+
+	if err := s.timerUC.restartHeadTrack(ctx, result.sess); err != nil {
+		return fmt.Errorf("restart head track sessionID=%s: %w", sessionID, err)
+	}
+
+	msg := entity.NewEventSeek(0)
+	if result.wentBack {
+		msg = entity.NewEventPrevTrack(result.sess.QueueHead)
+	}
+	s.pusher.Push(&event.PushMessage{
+		SessionID: sessionID,
+		Msg:       msg,
+	})
+	return nil
+}
+
+type prevTrackResult struct {
+	sess     *entity.Session
+	wentBack bool
+}
+
+func (s *SessionStateUseCase) prevTrackTx(sessionID string) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		session, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("find session: %w", err)
+		}
+
+		progress, err := s.progressOfHeadTrack(ctx, session)
+		if err != nil {
+			return nil, err
+		}
+
+		if !session.ShouldGoPrevTrack(progress) {
+			session.SetProgressWhenPaused(0 * time.Second)
+			if err := s.sessionRepo.Update(ctx, session); err != nil {
+				return nil, fmt.Errorf("update session id=%s: %w", session.ID, err)
+			}
+			return &prevTrackResult{sess: session, wentBack: false}, nil
+		}
+
+		now := s.timerUC.now()
+		if err := s.timerUC.storePlayedTrack(ctx, session, entity.TrackEndReasonSkipped, now); err != nil {
+			return nil, err
+		}
+
+		if err := session.GoPrevTrack(); err != nil {
+			return nil, fmt.Errorf("go prev track: %w", err)
+		}
+		if session.StateType == entity.Play {
+			session.StartHeadTrack(now)
+		}
+
+		if err := s.sessionRepo.Update(ctx, session); err != nil {
+			return nil, fmt.Errorf("update session id=%s: %w", session.ID, err)
+		}
+		return &prevTrackResult{sess: session, wentBack: true}, nil
+	}
+}
+
+// progressOfHeadTrack はheadの曲の再生位置を返します。
+// PLAYのときはSpotifyで正しい曲が再生されているかも確認します。
+func (s *SessionStateUseCase) progressOfHeadTrack(ctx context.Context, sess *entity.Session) (time.Duration, error) {
+	switch sess.StateType {
+	case entity.Play:
+		cpi, err := s.playerCli.CurrentlyPlaying(ctx)
+		if err != nil {
+			return 0, fmt.Errorf("call currently playing api: %w", err)
+		}
+		if err := sess.IsPlayingCorrectTrack(cpi); err != nil {
+			return 0, fmt.Errorf("check playing track: %w", err)
+		}
+		return cpi.Progress, nil
+	case entity.Pause:
+		return sess.ProgressWhenPaused, nil
+	}
+	return 0, fmt.Errorf("state type %s: %w", sess.StateType, entity.ErrChangeSessionStateNotPermit)
+}
+
+// JumpToTrack は指定されたidのsessionのheadをキューのindex番目の曲に移動し、その曲から再生し直します。
+// STOPのときはheadを移動するだけで再生は始めません。
+func (s *SessionStateUseCase) JumpToTrack(ctx context.Context, sessionID string, index int) error {
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("find session id=%s: %w", sessionID, err)
+	}
+
+	userID, _ := service.GetUserIDFromContext(ctx)
+	if !session.AllowToControlByOthers && !session.IsCreator(userID) {
+		return fmt.Errorf("not allowd to control session: %w", entity.ErrSessionNotAllowToControlOthers)
+	}
+
+	v, err := s.sessionRepo.DoInTx(ctx, s.jumpToTrackTx(sessionID, index))
+	if err != nil {
+		return fmt.Errorf("jump to track in transaction sessionID=%s: %w", sessionID, err)
+	}
+	sess, ok := v.(*entity.Session)
+	if !ok {
+		return fmt.Errorf("jump to track in transaction sessionID=%s: unexpected response", sessionID)
+	}
+
+	if sess.StateType == entity.Play || sess.StateType == entity.Pause {
+		if err := s.timerUC.restartHeadTrack(ctx, sess); err != nil {
+			return fmt.Errorf("restart head track sessionID=%s: %w", sessionID, err)
+		}
+	}
+
+	s.pusher.Push(&event.PushMessage{
+		SessionID: sessionID,
+		Msg:       entity.NewEventNextTrack(sess.QueueHead),
+	})
+	return nil
+}
+
+func (s *SessionStateUseCase) jumpToTrackTx(sessionID string, index int) func(ctx context.Context) (interface{}, error) {
+	return func(ctx context.Context) (interface{}, error) {
+		session, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("find session: %w", err)
+		}
+		if session.StateType == entity.Archived {
+			return nil, fmt.Errorf("jump to track: %w", entity.ErrChangeSessionStateNotPermit)
+		}
+
+		now := s.timerUC.now()
+		if err := s.timerUC.storePlayedTrack(ctx, session, entity.TrackEndReasonSkipped, now); err != nil {
+			return nil, err
+		}
+
+		if err := session.JumpToTrack(index); err != nil {
+			return nil, fmt.Errorf("jump to track: %w", err)
+		}
+		if session.StateType == entity.Play {
+			session.StartHeadTrack(now)
+		}
+
+		if err := s.sessionRepo.Update(ctx, session); err != nil {
+			return nil, fmt.Errorf("update session id=%s: %w", session.ID, err)
+		}
+		return session, nil
+	}
+}
+
+// Seek は指定されたidのsessionで再生中の曲の再生位置を変更します。
+func (s *SessionStateUseCase) Seek(ctx context.Context, sessionID string, position time.Duration) error {
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("find session id=%s: %w", sessionID, err)
+	}
+
+	userID, _ := service.GetUserIDFromContext(ctx)
+	if !session.AllowToControlByOthers && !session.IsCreator(userID) {
+		return fmt.Errorf("not allowd to control session: %w", entity.ErrSessionNotAllowToControlOthers)
+	}
+
+	switch session.StateType {
+	case entity.Play:
+		if err := s.seekInPlay(ctx, session, position); err != nil {
+			return fmt.Errorf("seek in play session id=%s: %w", session.ID, err)
+		}
+	case entity.Pause:
+		if err := s.seekInPause(ctx, session, position); err != nil {
+			return fmt.Errorf("seek in pause session id=%s: %w", session.ID, err)
+		}
+	default:
+		return fmt.Errorf("seek in %s: %w", session.StateType, entity.ErrChangeSessionStateNotPermit)
+	}
+
+	s.pusher.Push(&event.PushMessage{
+		SessionID: session.ID,
+		Msg:       entity.NewEventSeek(position),
+	})
+	return nil
+}
+
+// seekInPlay はsessionのstateがPLAYの時のseekの処理を行います
+// 曲の残り時間が変わるので、曲の終了を検知するタイマーも合わせて再設定します。
+func (s *SessionStateUseCase) seekInPlay(ctx context.Context, sess *entity.Session, position time.Duration) error {
+	cpi, err := s.validateSeekPosition(ctx, sess, position)
+	if err != nil {
+		return err
+	}
+
+	if err := s.playerCli.Seek(ctx, position, sess.DeviceID); err != nil {
+		return fmt.Errorf("call seek api: %w", err)
+	}
+
+	if err := s.timerUC.resetTrackEndTrigger(sess.ID, cpi.Track.Duration-position); err != nil {
+		return fmt.Errorf("reset track end trigger: %w", err)
+	}
+	return nil
+}
+
+// seekInPause はsessionのstateがPAUSEの時のseekの処理を行います
+// 再開時にProgressWhenPausedの位置から再生されるので、Spotifyの再生位置は変更しません。
+func (s *SessionStateUseCase) seekInPause(ctx context.Context, sess *entity.Session, position time.Duration) error {
+	if _, err := s.validateSeekPosition(ctx, sess, position); err != nil {
+		return err
+	}
+
+	sess.SetProgressWhenPaused(position)
+
+	if err := s.sessionRepo.Update(ctx, sess); err != nil {
+		return fmt.Errorf("update session id=%s: %w", sess.ID, err)
+	}
+	return nil
+}
+
+// validateSeekPosition はSpotifyでセッションの曲が再生されていて、指定された再生位置がその曲の範囲内かどうかを確認します。
+func (s *SessionStateUseCase) validateSeekPosition(ctx context.Context, sess *entity.Session, position time.Duration) (*entity.CurrentPlayingInfo, error) {
+	cpi, err := s.playerCli.CurrentlyPlaying(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("call currently playing api: %w", err)
+	}
+	if err := sess.IsPlayingCorrectTrack(cpi); err != nil {
+		return nil, fmt.Errorf("check playing track: %w", err)
+	}
+	if !cpi.IsValidSeekPosition(position) {
+		return nil, fmt.Errorf("position=%s duration=%s: %w", position, cpi.Track.Duration, entity.ErrInvalidSeekPosition)
+	}
+	return cpi, nil
+}
+
+// GetVolume は指定されたidのsessionを再生しているデバイスの音量を取得します。
+func (s *SessionStateUseCase) GetVolume(ctx context.Context, sessionID string) (int, *entity.Session, error) {
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("find session id=%s: %w", sessionID, err)
+	}
+
+	cpi, err := s.playerCli.CurrentlyPlaying(ctx)
+	if err != nil {
+		return 0, nil, fmt.Errorf("call currently playing api: %w", err)
+	}
+	return cpi.Device.Volume, session, nil
+}
+
+// SetVolume は指定されたidのsessionを再生しているデバイスの音量を変更し、実際に設定した音量を返します。
+// セッションに音量の上限が設定されている場合は上限に丸めます。
+func (s *SessionStateUseCase) SetVolume(ctx context.Context, sessionID string, volume int) (int, *entity.Session, error) {
+	session, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		return 0, nil, fmt.Errorf("find session id=%s: %w", sessionID, err)
+	}
+
+	userID, _ := service.GetUserIDFromContext(ctx)
+	if !session.AllowToControlByOthers && !session.IsCreator(userID) {
+		return 0, nil, fmt.Errorf("not allowd to control session: %w", entity.ErrSessionNotAllowToControlOthers)
+	}
+
+	if session.StateType != entity.Play && session.StateType != entity.Pause {
+		return 0, nil, fmt.Errorf("set volume in %s: %w", session.StateType, entity.ErrChangeSessionStateNotPermit)
+	}
+
+	applied, err := session.VolumeToSet(volume)
+	if err != nil {
+		return 0, nil, fmt.Errorf("volume to set: %w", err)
+	}
+
+	if err := s.playerCli.SetVolume(ctx, applied, session.DeviceID); err != nil {
+		return 0, nil, fmt.Errorf("call set volume api: %w", err)
+	}
+
+	s.pusher.Push(&event.PushMessage{
+		SessionID: session.ID,
+		Msg:       entity.NewEventVolume(applied),
+	})
+	return applied, session, nil
+}
+
 // ChangeSessionState は与えられたセッションのstateを操作します。
 func (s *SessionStateUseCase) ChangeSessionState(ctx context.Context, sessionID string, st entity.StateType) error {
 	session, err := s.sessionRepo.FindByID(ctx, sessionID)
@@ -180,7 +479,7 @@ func (s *SessionStateUseCase) ChangeSessionState(ctx context.Context, sessionID
 
 	switch st {
 	case entity.Play:
-		if err := s.playORResume(ctx, session); err != nil {
+		if err := s.withDeviceFallback(ctx, session, func() error { return s.playORResume(ctx, session) }); err != nil {
 			return fmt.Errorf("playORResume sessionID=%s: %w", sessionID, err)
 		}
 	case entity.Pause:
@@ -199,6 +498,44 @@ func (s *SessionStateUseCase) ChangeSessionState(ctx context.Context, sessionID
 	return nil
 }
 
+// withDeviceFallback はfnが再生するデバイスが見つからないエラーを返したときに、
+// セッションでデバイスのフォールバックが有効であれば代わりのデバイスを選んでfnを再実行します。
+// fnはsess.DeviceIDのデバイスでSpotifyのAPIを呼び出す必要があります。
+// 再生中のデバイスを操作するスキップやシークなどは別のデバイスでやり直しても意味がないので、再生を始めるときだけに使います。
+func (s *SessionStateUseCase) withDeviceFallback(ctx context.Context, sess *entity.Session, fn func() error) error {
+	err := fn()
+	if err == nil || !sess.DeviceFallback || !errors.Is(err, entity.ErrActiveDeviceNotFound) {
+		return err
+	}
+
+	logger := log.New()
+	devices, devErr := s.userCli.GetActiveDevices(ctx)
+	if devErr != nil {
+		logger.Errorj(map[string]interface{}{"message": "failed to get active devices", "sessionID": sess.ID, "error": devErr.Error()})
+		return err
+	}
+	deviceID, ok := sess.FallbackDeviceID(devices)
+	if !ok {
+		logger.Infoj(map[string]interface{}{"message": "fallback device not found", "sessionID": sess.ID})
+		return err
+	}
+
+	logger.Infoj(map[string]interface{}{"message": "retry with fallback device", "sessionID": sess.ID, "deviceID": deviceID})
+	changed := sess.DeviceID != deviceID
+	sess.DeviceID = deviceID
+	if err := fn(); err != nil {
+		return fmt.Errorf("retry with fallback device device_id=%s: %w", deviceID, err)
+	}
+
+	if changed {
+		s.pusher.Push(&event.PushMessage{
+			SessionID: sess.ID,
+			Msg:       entity.EventDeviceChanged,
+		})
+	}
+	return nil
+}
+
 // playORResume はセッションのstateを STOP, PAUSE → PLAY に変更して曲の再生を始めます。
 func (s *SessionStateUseCase) playORResume(ctx context.Context, sess *entity.Session) error {
 	if err := s.playerCli.SetRepeatMode(ctx, false, sess.DeviceID); err != nil {
@@ -222,12 +559,13 @@ func (s *SessionStateUseCase) playORResume(ctx context.Context, sess *entity.Ses
 	if err := sess.MoveToPlay(); err != nil {
 		return fmt.Errorf("move to play id=%s: %w", sess.ID, err)
 	}
+	sess.StartHeadTrack(s.timerUC.now())
 
 	if err := s.sessionRepo.Update(ctx, sess); err != nil {
 		return fmt.Errorf("update session id=%s: %w", sess.ID, err)
 	}
 
-	go s.timerUC.startTrackEndTrigger(ctx, sess.ID)
+	s.timerUC.startTrackEndTrigger(sess.ID)
 
 	s.pusher.Push(&event.PushMessage{
 		SessionID: sess.ID,
@@ -274,16 +612,9 @@ func (s *SessionStateUseCase) pause(ctx context.Context, sess *entity.Session) e
 	if err != nil && !errors.Is(err, entity.ErrActiveDeviceNotFound) {
 		return fmt.Errorf("call currently playing api: %w", err)
 	}
-	sess.SetProgressWhenPaused(cpi.Progress)
-
-	if err := s.playerCli.Pause(ctx, sess.DeviceID); err != nil && !errors.Is(err, entity.ErrActiveDeviceNotFound) {
-		return fmt.Errorf("call pause api: %w", err)
-	}
-
-	s.timerUC.deleteTimer(sess.ID)
 
-	if err := sess.MoveToPause(); err != nil {
-		return fmt.Errorf("move to pause id=%s: %w", sess.ID, err)
+	if err := s.timerUC.moveToPause(ctx, sess, cpi.Progress); err != nil {
+		return fmt.Errorf("move to pause: %w", err)
 	}
 
 	if err := s.sessionRepo.Update(ctx, sess); err != nil {
@@ -355,7 +686,7 @@ func (s *SessionStateUseCase) stop(ctx context.Context, session *entity.Session)
 func (s *SessionStateUseCase) archiveToStop(ctx context.Context, session *entity.Session) error {
 	session.MoveToStop()
 
-	session.UpdateExpiredAt()
+	session.UpdateExpiredAt(s.timerUC.now())
 
 	if err := s.sessionRepo.Update(ctx, session); err != nil {
 		return fmt.Errorf("update session id=%s: %w", session.ID, err)
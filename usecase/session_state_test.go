@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -349,6 +350,566 @@ func TestSessionStateUseCase_nextTrackInStopTx(t *testing.T) {
 }
 
 // モックの準備
+func TestSessionStateUseCase_prevTrackTx(t *testing.T) {
+	t.Parallel()
+
+	startedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sessionWithHead := func(st entity.StateType, head int, progressWhenPaused time.Duration, headStartedAt time.Time) *entity.Session {
+		return &entity.Session{
+			ID:        "sessionID",
+			Name:      "name",
+			CreatorID: "creatorID",
+			DeviceID:  "deviceID",
+			StateType: st,
+			QueueHead: head,
+			QueueTracks: []*entity.QueueTrack{
+				{Index: 0, URI: "spotify:track:track_uri1", SessionID: "sessionID"},
+				{Index: 1, URI: "spotify:track:track_uri2", SessionID: "sessionID"},
+				{Index: 2, URI: "spotify:track:track_uri3", SessionID: "sessionID"},
+			},
+			AllowToControlByOthers: true,
+			ProgressWhenPaused:     progressWhenPaused,
+			HeadStartedAt:          headStartedAt,
+		}
+	}
+	playingInfo := func(progress time.Duration) *entity.CurrentPlayingInfo {
+		return &entity.CurrentPlayingInfo{
+			Playing:  true,
+			Progress: progress,
+			Track:    &entity.Track{URI: "spotify:track:track_uri2", Duration: 3 * time.Minute},
+		}
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		prepareMockPlayerCliFn   func(m *mock_spotify.MockPlayer)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		wantWentBack             bool
+		wantErr                  bool
+	}{
+		{
+			name:      "PLAYで再生が始まってすぐのときは前の曲に戻り、再生履歴が記録される",
+			sessionID: "sessionID",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playingInfo(time.Second), nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Play, 1, 0, startedAt), nil)
+				m.EXPECT().StorePlayedTrack(gomock.Any(), &entity.PlayedTrack{
+					SessionID: "sessionID",
+					URI:       "spotify:track:track_uri2",
+					StartedAt: startedAt,
+					EndedAt:   fixedNow,
+					EndReason: entity.TrackEndReasonSkipped,
+				}).Return(nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Play, 0, 0, fixedNow)).Return(nil)
+			},
+			wantWentBack: true,
+			wantErr:      false,
+		},
+		{
+			name:      "PLAYで再生が始まってから時間が経っているときは前の曲に戻らない",
+			sessionID: "sessionID",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playingInfo(time.Minute), nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Play, 1, 0, startedAt), nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Play, 1, 0, startedAt)).Return(nil)
+			},
+			wantWentBack: false,
+			wantErr:      false,
+		},
+		{
+			name:                   "PAUSEで一時停止した位置が曲の最初のほうのときは前の曲に戻る",
+			sessionID:              "sessionID",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 1, time.Second, time.Time{}), nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Pause, 0, 0, time.Time{})).Return(nil)
+			},
+			wantWentBack: true,
+			wantErr:      false,
+		},
+		{
+			name:                   "PAUSEで最初の曲のときは再生位置だけがリセットされる",
+			sessionID:              "sessionID",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 0, time.Second, time.Time{}), nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Pause, 0, 0, time.Time{})).Return(nil)
+			},
+			wantWentBack: false,
+			wantErr:      false,
+		},
+		{
+			name:      "PLAYでSpotifyで別の曲が再生されているときはエラー",
+			sessionID: "sessionID",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(&entity.CurrentPlayingInfo{
+					Playing: true,
+					Track:   &entity.Track{URI: "spotify:track:different"},
+				}, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Play, 1, 0, startedAt), nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:                   "STOPのときはエラー",
+			sessionID:              "sessionID",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Stop, 1, 0, time.Time{}), nil)
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			uc := newSessionStateUseCaseForTest(t, ctrl, tt.prepareMockPlayerCliFn, func(m *mock_spotify.MockTrackClient) {},
+				func(m *mock_event.MockPusher) {}, func(m *mock_repository.MockUser) {}, tt.prepareMockSessionRepoFn, "")
+
+			got, err := uc.prevTrackTx(tt.sessionID)(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("prevTrackTx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result := got.(*prevTrackResult); result.wentBack != tt.wantWentBack {
+				t.Errorf("prevTrackTx() wentBack = %v, want %v", result.wentBack, tt.wantWentBack)
+			}
+		})
+	}
+}
+
+func TestSessionStateUseCase_jumpToTrackTx(t *testing.T) {
+	t.Parallel()
+
+	startedAt := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	sessionWithHead := func(st entity.StateType, head int, progressWhenPaused time.Duration, headStartedAt time.Time) *entity.Session {
+		return &entity.Session{
+			ID:        "sessionID",
+			Name:      "name",
+			CreatorID: "creatorID",
+			DeviceID:  "deviceID",
+			StateType: st,
+			QueueHead: head,
+			QueueTracks: []*entity.QueueTrack{
+				{Index: 0, URI: "spotify:track:track_uri1", SessionID: "sessionID"},
+				{Index: 1, URI: "spotify:track:track_uri2", SessionID: "sessionID"},
+				{Index: 2, URI: "spotify:track:track_uri3", SessionID: "sessionID"},
+				{Index: 3, URI: "spotify:track:track_uri4", SessionID: "sessionID"},
+			},
+			AllowToControlByOthers: true,
+			ProgressWhenPaused:     progressWhenPaused,
+			HeadStartedAt:          headStartedAt,
+		}
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		index                    int
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		wantQueueHead            int
+		wantErr                  error
+	}{
+		{
+			name:      "PLAYのときは再生中の曲の再生履歴が記録されてheadが移動する",
+			sessionID: "sessionID",
+			index:     3,
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Play, 0, 0, startedAt), nil)
+				m.EXPECT().StorePlayedTrack(gomock.Any(), &entity.PlayedTrack{
+					SessionID: "sessionID",
+					URI:       "spotify:track:track_uri1",
+					StartedAt: startedAt,
+					EndedAt:   fixedNow,
+					EndReason: entity.TrackEndReasonSkipped,
+				}).Return(nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Play, 3, 0, fixedNow)).Return(nil)
+			},
+			wantQueueHead: 3,
+			wantErr:       nil,
+		},
+		{
+			name:      "PAUSEのときは前の曲にも移動でき、再開時の再生位置がリセットされる",
+			sessionID: "sessionID",
+			index:     1,
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 3, time.Minute, time.Time{}), nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Pause, 1, 0, time.Time{})).Return(nil)
+			},
+			wantQueueHead: 1,
+			wantErr:       nil,
+		},
+		{
+			name:      "STOPのときもheadが移動する",
+			sessionID: "sessionID",
+			index:     2,
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Stop, 0, 0, time.Time{}), nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Stop, 2, 0, time.Time{})).Return(nil)
+			},
+			wantQueueHead: 2,
+			wantErr:       nil,
+		},
+		{
+			name:      "存在しないindexのときはErrQueueTrackNotFound",
+			sessionID: "sessionID",
+			index:     4,
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 0, 0, time.Time{}), nil)
+			},
+			wantErr: entity.ErrQueueTrackNotFound,
+		},
+		{
+			name:      "ARCHIVEDのときはErrChangeSessionStateNotPermit",
+			sessionID: "sessionID",
+			index:     1,
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Archived, 0, 0, time.Time{}), nil)
+			},
+			wantErr: entity.ErrChangeSessionStateNotPermit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			uc := newSessionStateUseCaseForTest(t, ctrl, func(m *mock_spotify.MockPlayer) {}, func(m *mock_spotify.MockTrackClient) {},
+				func(m *mock_event.MockPusher) {}, func(m *mock_repository.MockUser) {}, tt.prepareMockSessionRepoFn, "")
+
+			got, err := uc.jumpToTrackTx(tt.sessionID, tt.index)(context.Background())
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("jumpToTrackTx() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if sess := got.(*entity.Session); sess.QueueHead != tt.wantQueueHead {
+				t.Errorf("jumpToTrackTx() QueueHead = %d, want %d", sess.QueueHead, tt.wantQueueHead)
+			}
+		})
+	}
+}
+
+func TestSessionStateUseCase_Seek(t *testing.T) {
+	t.Parallel()
+
+	sessionWithState := func(st entity.StateType, allowToControlByOthers bool) *entity.Session {
+		return &entity.Session{
+			ID:        "sessionID",
+			Name:      "name",
+			CreatorID: "creatorID",
+			DeviceID:  "deviceID",
+			StateType: st,
+			QueueHead: 0,
+			QueueTracks: []*entity.QueueTrack{
+				{Index: 0, URI: "spotify:track:track_uri1", SessionID: "sessionID"},
+				{Index: 1, URI: "spotify:track:track_uri2", SessionID: "sessionID"},
+			},
+			AllowToControlByOthers: allowToControlByOthers,
+			ProgressWhenPaused:     10 * time.Second,
+		}
+	}
+	playingInfo := &entity.CurrentPlayingInfo{
+		Playing:  true,
+		Progress: 10 * time.Second,
+		Track:    &entity.Track{URI: "spotify:track:track_uri1", Duration: 3 * time.Minute},
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		userID                   string
+		position                 time.Duration
+		addToTimerSessionID      string
+		prepareMockPlayerCliFn   func(m *mock_spotify.MockPlayer)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		wantWaitingTrackEnd      bool
+		wantErr                  error
+	}{
+		{
+			name:                "PLAYのときはSpotifyの再生位置を変更してSEEKイベントを送る",
+			sessionID:           "sessionID",
+			userID:              "creatorID",
+			position:            time.Minute,
+			addToTimerSessionID: "sessionID",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playingInfo, nil)
+				m.EXPECT().Seek(gomock.Any(), time.Minute, "deviceID").Return(nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Play, false), nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.NewEventSeek(time.Minute),
+				})
+			},
+			wantWaitingTrackEnd: true,
+			wantErr:             nil,
+		},
+		{
+			name:                "PLAYで曲の終了を検知する予定が無いときは新しい残り時間で検知を始める",
+			sessionID:           "sessionID",
+			userID:              "creatorID",
+			position:            time.Minute,
+			addToTimerSessionID: "",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playingInfo, nil)
+				m.EXPECT().Seek(gomock.Any(), time.Minute, "deviceID").Return(nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Play, false), nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.NewEventSeek(time.Minute),
+				})
+			},
+			wantWaitingTrackEnd: true,
+			wantErr:             nil,
+		},
+		{
+			name:                "PAUSEのときは再開時の再生位置を変更してSEEKイベントを送る",
+			sessionID:           "sessionID",
+			userID:              "userID",
+			position:            time.Minute,
+			addToTimerSessionID: "",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(&entity.CurrentPlayingInfo{
+					Playing:  false,
+					Progress: 10 * time.Second,
+					Track:    &entity.Track{URI: "spotify:track:track_uri1", Duration: 3 * time.Minute},
+				}, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Pause, true), nil)
+				want := sessionWithState(entity.Pause, true)
+				want.ProgressWhenPaused = time.Minute
+				m.EXPECT().Update(gomock.Any(), want).Return(nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.NewEventSeek(time.Minute),
+				})
+			},
+			wantErr: nil,
+		},
+		{
+			name:                "曲の長さ以上の再生位置のときはErrInvalidSeekPosition",
+			sessionID:           "sessionID",
+			userID:              "creatorID",
+			position:            5 * time.Minute,
+			addToTimerSessionID: "sessionID",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playingInfo, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Play, false), nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantWaitingTrackEnd: true,
+			wantErr:             entity.ErrInvalidSeekPosition,
+		},
+		{
+			name:                "Spotifyで別の曲が再生されているときはErrSessionPlayingDifferentTrack",
+			sessionID:           "sessionID",
+			userID:              "creatorID",
+			position:            time.Minute,
+			addToTimerSessionID: "sessionID",
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(&entity.CurrentPlayingInfo{
+					Playing: true,
+					Track:   &entity.Track{URI: "spotify:track:different", Duration: 3 * time.Minute},
+				}, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Play, false), nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantWaitingTrackEnd: true,
+			wantErr:             entity.ErrSessionPlayingDifferentTrack,
+		},
+		{
+			name:                   "STOPのときはErrChangeSessionStateNotPermit",
+			sessionID:              "sessionID",
+			userID:                 "creatorID",
+			position:               time.Minute,
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Stop, false), nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantErr:             entity.ErrChangeSessionStateNotPermit,
+		},
+		{
+			name:                   "作成者以外で他人による操作が許可されていないときはErrSessionNotAllowToControlOthers",
+			sessionID:              "sessionID",
+			userID:                 "userID",
+			position:               time.Minute,
+			prepareMockPlayerCliFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Play, false), nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantErr:             entity.ErrSessionNotAllowToControlOthers,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			uc := newSessionStateUseCaseForTest(t, ctrl, tt.prepareMockPlayerCliFn, func(m *mock_spotify.MockTrackClient) {},
+				tt.prepareMockPusherFn, func(m *mock_repository.MockUser) {}, tt.prepareMockSessionRepoFn, tt.addToTimerSessionID)
+
+			ctx := context.Background()
+			ctx = service.SetUserIDToContext(ctx, tt.userID)
+
+			if err := uc.Seek(ctx, tt.sessionID, tt.position); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Seek() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := uc.timerUC.isWaitingTrackEnd(tt.sessionID); got != tt.wantWaitingTrackEnd {
+				t.Errorf("Seek() waiting track end = %v, want %v", got, tt.wantWaitingTrackEnd)
+			}
+		})
+	}
+}
+
+func TestSessionStateUseCase_withDeviceFallback(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                string
+		sess                *entity.Session
+		fnErrs              []error
+		prepareMockUserFn   func(m *mock_spotify.MockUser)
+		prepareMockPusherFn func(m *mock_event.MockPusher)
+		wantDeviceIDs       []string
+		wantErr             error
+	}{
+		{
+			name:                "成功したときはそのまま",
+			sess:                &entity.Session{ID: "sessionID", DeviceID: "device1", DeviceFallback: true},
+			fnErrs:              []error{nil},
+			prepareMockUserFn:   func(m *mock_spotify.MockUser) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantDeviceIDs:       []string{"device1"},
+			wantErr:             nil,
+		},
+		{
+			name:                "フォールバックが無効のときはErrActiveDeviceNotFound",
+			sess:                &entity.Session{ID: "sessionID", DeviceID: "device1", DeviceFallback: false},
+			fnErrs:              []error{entity.ErrActiveDeviceNotFound},
+			prepareMockUserFn:   func(m *mock_spotify.MockUser) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantDeviceIDs:       []string{"device1"},
+			wantErr:             entity.ErrActiveDeviceNotFound,
+		},
+		{
+			name:   "前回使っていたデバイスを明示して再実行する",
+			sess:   &entity.Session{ID: "sessionID", DeviceID: "device1", DeviceFallback: true},
+			fnErrs: []error{entity.ErrActiveDeviceNotFound, nil},
+			prepareMockUserFn: func(m *mock_spotify.MockUser) {
+				m.EXPECT().GetActiveDevices(gomock.Any()).Return([]*entity.Device{{ID: "device1"}, {ID: "device2"}}, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantDeviceIDs:       []string{"device1", "device1"},
+			wantErr:             nil,
+		},
+		{
+			name:   "使えるデバイスが1台だけのときはそのデバイスで再実行してDEVICE_CHANGEDイベントを送る",
+			sess:   &entity.Session{ID: "sessionID", DeviceID: "", DeviceFallback: true},
+			fnErrs: []error{entity.ErrActiveDeviceNotFound, nil},
+			prepareMockUserFn: func(m *mock_spotify.MockUser) {
+				m.EXPECT().GetActiveDevices(gomock.Any()).Return([]*entity.Device{{ID: "device2"}}, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.EventDeviceChanged,
+				})
+			},
+			wantDeviceIDs: []string{"", "device2"},
+			wantErr:       nil,
+		},
+		{
+			name:   "使えるデバイスが選べないときはErrActiveDeviceNotFound",
+			sess:   &entity.Session{ID: "sessionID", DeviceID: "device1", DeviceFallback: true},
+			fnErrs: []error{entity.ErrActiveDeviceNotFound},
+			prepareMockUserFn: func(m *mock_spotify.MockUser) {
+				m.EXPECT().GetActiveDevices(gomock.Any()).Return([]*entity.Device{{ID: "device2"}, {ID: "device3"}}, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantDeviceIDs:       []string{"device1"},
+			wantErr:             entity.ErrActiveDeviceNotFound,
+		},
+		{
+			name:   "再実行しても失敗したときはそのエラー",
+			sess:   &entity.Session{ID: "sessionID", DeviceID: "device1", DeviceFallback: true},
+			fnErrs: []error{entity.ErrActiveDeviceNotFound, entity.ErrActiveDeviceNotFound},
+			prepareMockUserFn: func(m *mock_spotify.MockUser) {
+				m.EXPECT().GetActiveDevices(gomock.Any()).Return([]*entity.Device{{ID: "device2"}}, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantDeviceIDs:       []string{"device1", "device2"},
+			wantErr:             entity.ErrActiveDeviceNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockUser := mock_spotify.NewMockUser(ctrl)
+			tt.prepareMockUserFn(mockUser)
+			mockPusher := mock_event.NewMockPusher(ctrl)
+			tt.prepareMockPusherFn(mockPusher)
+			uc := NewSessionStateUseCase(nil, nil, mockUser, mockPusher, nil)
+
+			var gotDeviceIDs []string
+			fn := func() error {
+				gotDeviceIDs = append(gotDeviceIDs, tt.sess.DeviceID)
+				return tt.fnErrs[len(gotDeviceIDs)-1]
+			}
+
+			if err := uc.withDeviceFallback(context.Background(), tt.sess, fn); !errors.Is(err, tt.wantErr) {
+				t.Errorf("withDeviceFallback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(gotDeviceIDs) != len(tt.wantDeviceIDs) {
+				t.Fatalf("withDeviceFallback() called fn with %v, want %v", gotDeviceIDs, tt.wantDeviceIDs)
+			}
+			for i := range gotDeviceIDs {
+				if gotDeviceIDs[i] != tt.wantDeviceIDs[i] {
+					t.Errorf("withDeviceFallback() called fn with %v, want %v", gotDeviceIDs, tt.wantDeviceIDs)
+				}
+			}
+		})
+	}
+}
+
 func newSessionStateUseCaseForTest(
 	t *testing.T,
 	ctrl *gomock.Controller,
@@ -370,12 +931,12 @@ func newSessionStateUseCaseForTest(
 	prepareMockUserRepoFn(mockUserRepo)
 	mockSessionRepo := mock_repository.NewMockSession(ctrl)
 	prepareMockSessionRepoFn(mockSessionRepo)
-	syncCheckTimerManager := entity.NewSyncCheckTimerManager()
+	clock := entity.NewFakeClock(fixedNow)
+	playbackScheduler := entity.NewPlaybackScheduler(1, clock)
 	if sessionID != "" {
-		timer := syncCheckTimerManager.CreateExpiredTimer(sessionID)
-		timer.SetDuration(5 * time.Minute)
+		playbackScheduler.Add(sessionID, entity.PlaybackJobTrackEnd, fixedNow.Add(5*time.Minute))
 	}
-	timerUC := NewSessionTimerUseCase(mockSessionRepo, mockPlayer, mockPusher, syncCheckTimerManager)
-	return NewSessionStateUseCase(mockSessionRepo, mockPlayer, mockPusher, timerUC)
+	timerUC := NewSessionTimerUseCase(mockSessionRepo, mockPlayer, mockTrackCli, mockPusher, playbackScheduler, nil, clock)
+	return NewSessionStateUseCase(mockSessionRepo, mockPlayer, nil, mockPusher, timerUC)
 
 }
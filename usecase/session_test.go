@@ -2,11 +2,15 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/camphor-/relaym-server/domain/entity"
+	"github.com/camphor-/relaym-server/domain/event"
+	"github.com/camphor-/relaym-server/domain/mock_event"
 	"github.com/camphor-/relaym-server/domain/mock_repository"
+	"github.com/camphor-/relaym-server/domain/mock_spotify"
 	"github.com/golang/mock/gomock"
 )
 
@@ -68,8 +72,8 @@ func TestSessionUseCase_CanConnectToPusher(t *testing.T) {
 			defer ctrl.Finish()
 			mockSessionRepo := mock_repository.NewMockSession(ctrl)
 			tt.prepareMockSessionRepoFn(mockSessionRepo)
-			syncCheckTimerManager := entity.NewSyncCheckTimerManager()
-			stUC := NewSessionTimerUseCase(nil, &FakePlayer{}, nil, syncCheckTimerManager)
+			clock := entity.NewFakeClock(fixedNow)
+			stUC := NewSessionTimerUseCase(nil, &FakePlayer{}, nil, nil, entity.NewPlaybackScheduler(1, clock), nil, clock)
 			s := NewSessionUseCase(mockSessionRepo, nil, &FakePlayer{}, nil, nil, nil, stUC)
 
 			if err := s.CanConnectToPusher(context.Background(), tt.sessionID); (err != nil) != tt.wantErr {
@@ -123,6 +127,18 @@ func (m *FakePlayer) Pause(ctx context.Context, deviceID string) error {
 	return nil
 }
 
+func (m *FakePlayer) Seek(ctx context.Context, position time.Duration, deviceID string) error {
+	return nil
+}
+
+func (m *FakePlayer) SetVolume(ctx context.Context, volume int, deviceID string) error {
+	return nil
+}
+
+func (m *FakePlayer) TransferPlayback(ctx context.Context, deviceID string, play bool) error {
+	return nil
+}
+
 func (m *FakePlayer) Enqueue(ctx context.Context, trackURI, deviceID string) error {
 	return nil
 }
@@ -142,3 +158,102 @@ func (m *FakePlayer) DeleteAllTracksInQueue(ctx context.Context, deviceID, track
 func (m *FakePlayer) GoNextTrack(ctx context.Context, deviceID string) error {
 	return nil
 }
+
+func TestSessionUseCase_EnqueueTrack(t *testing.T) {
+	t.Parallel()
+
+	albumURI := "spotify:album:1"
+	trackURIs := []string{"spotify:track:1", "spotify:track:2", "spotify:track:3"}
+
+	tests := []struct {
+		name                     string
+		session                  *entity.Session
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		wantErr                  error
+	}{
+		{
+			name: "アルバムを指定すると含まれる全ての曲が追加される",
+			session: &entity.Session{
+				ID:          "sessionID",
+				StateType:   entity.Stop,
+				QueueTracks: []*entity.QueueTrack{},
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				for _, uri := range trackURIs {
+					m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
+						URI:             uri,
+						SessionID:       "sessionID",
+						AddedByNickname: "guest",
+						AddedAt:         fixedNow,
+					}).Return(nil)
+				}
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.NewEventAddTrack("", "guest", 3),
+				})
+			},
+			wantErr: nil,
+		},
+		{
+			name: "アルバムの途中の曲で追加できる曲数の上限を超えるときはどの曲も追加されない",
+			session: &entity.Session{
+				ID:                      "sessionID",
+				StateType:               entity.Stop,
+				QueueTracks:             []*entity.QueueTrack{},
+				MaxPendingTracksPerUser: 2,
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			wantErr:                  entity.ErrTooManyPendingQueueTracks,
+		},
+		{
+			name: "アルバムの中に未再生の曲と重複する曲があるときはどの曲も追加されない",
+			session: &entity.Session{
+				ID:        "sessionID",
+				StateType: entity.Stop,
+				QueueTracks: []*entity.QueueTrack{
+					{Index: 0, URI: "spotify:track:3", SessionID: "sessionID"},
+				},
+				RejectDuplicateTracks: true,
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			wantErr:                  entity.ErrDuplicateQueueTrack,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockTrackCli := mock_spotify.NewMockTrackClient(ctrl)
+			mockTrackCli.EXPECT().GetTrackURIsFromAlbum(gomock.Any(), albumURI).Return(trackURIs, nil)
+			mockTrackCli.EXPECT().GetTracksFromURI(gomock.Any(), trackURIs).Return([]*entity.Track{
+				{URI: trackURIs[0]}, {URI: trackURIs[1]}, {URI: trackURIs[2]},
+			}, nil)
+			mockUserCli := mock_spotify.NewMockUser(ctrl)
+			mockUserCli.EXPECT().GetMe(gomock.Any()).Return(&entity.SpotifyUser{}, nil)
+			mockSessionRepo := mock_repository.NewMockSession(ctrl)
+			mockSessionRepo.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+					return f(ctx)
+				})
+			mockSessionRepo.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(tt.session, nil)
+			tt.prepareMockSessionRepoFn(mockSessionRepo)
+			mockPusher := mock_event.NewMockPusher(ctrl)
+			tt.prepareMockPusherFn(mockPusher)
+
+			clock := entity.NewFakeClock(fixedNow)
+			stUC := NewSessionTimerUseCase(mockSessionRepo, &FakePlayer{}, mockTrackCli, mockPusher, entity.NewPlaybackScheduler(1, clock), nil, clock)
+			s := NewSessionUseCase(mockSessionRepo, nil, &FakePlayer{}, mockTrackCli, mockUserCli, mockPusher, stUC)
+
+			err := s.EnqueueTrack(context.Background(), "sessionID", albumURI, "guest", false)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("EnqueueTrack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -13,87 +13,335 @@ import (
 	"github.com/camphor-/relaym-server/log"
 )
 
+var waitTimeAfterPlay = 5 * time.Second
 var waitTimeAfterHandleTrackEnd = 7 * time.Second
 var waitTimeAfterHandleSkipTrack = 300 * time.Millisecond
 
+// trackEndTriggerMargin は曲の終了を検知するタイマーを曲の残り時間より早めに発火させる時間です。
+// ぴったしのタイマーをセットすると、Spotifyでは次の曲の再生が始まってるのにRelaym側では次の曲に進んでおらず、
+// INTERRUPTになってしまう
+const trackEndTriggerMargin = 2 * time.Second
+
+// minSyncCheckInterval と maxSyncCheckInterval は曲の再生中にSpotifyと同期が取れているか確認する間隔の最小値と最大値です。
+// ずれが見つからない間は間隔を倍に延ばしていき、ずれが見つかったら最小値に戻します。
+var minSyncCheckInterval = 5 * time.Second
+var maxSyncCheckInterval = 40 * time.Second
+
+// driftThreshold は曲の終了を検知する予定を立て直す、予定と実際の再生位置から求めた曲の終了日時のずれの大きさです。
+const driftThreshold = time.Second
+
+// retryIntervalOnTokenError はセッションの作成者のトークンを取得できなかったときに処理をやり直すまでの時間です。
+const retryIntervalOnTokenError = 5 * time.Second
+
+// maxTokenRetryCount はセッションの作成者のトークンを取得できなかったときに処理をやり直す最大の回数です。
+// これを超えても取得できなければ再生を続けられないので、セッションをINTERRUPTにします。
+const maxTokenRetryCount = 3
+
+// radioRecommendationLimit はラジオモードで一度に取得するおすすめの曲数です。キューに含まれている曲を除いても足りるように多めに取得します。
+const radioRecommendationLimit = 20
+
 type SessionTimerUseCase struct {
-	tm          *entity.SyncCheckTimerManager
+	scheduler   *entity.PlaybackScheduler
 	sessionRepo repository.Session
 	playerCli   spotify.Player
+	trackCli    spotify.TrackClient
 	pusher      event.Pusher
+	authUC      *AuthUseCase
+	clock       entity.Clock
+}
+
+func NewSessionTimerUseCase(sessionRepo repository.Session, playerCli spotify.Player, trackCli spotify.TrackClient, pusher event.Pusher, scheduler *entity.PlaybackScheduler, authUC *AuthUseCase, clock entity.Clock) *SessionTimerUseCase {
+	return &SessionTimerUseCase{scheduler: scheduler, sessionRepo: sessionRepo, playerCli: playerCli, trackCli: trackCli, pusher: pusher, authUC: authUC, clock: clock}
+}
+
+// now はスケジューラと同じClockで現在時刻をUTCで返します。
+func (s *SessionTimerUseCase) now() time.Time {
+	return s.clock.Now().UTC()
+}
+
+// RunScheduler は曲の終了や同期チェックの予定を実行するスケジューラを動かします。ctxがキャンセルされるまで処理を続けます。
+// goroutineで実行されることを想定しています。
+func (s *SessionTimerUseCase) RunScheduler(ctx context.Context) {
+	s.scheduler.Run(ctx, s.handlePlaybackJob)
+}
+
+// RecoverTimers はサーバの起動時に、再生中のセッションの曲の終了を検知する予定を立て直します。
+// Spotifyで正しい曲が再生されていないセッションはINTERRUPTにします。
+func (s *SessionTimerUseCase) RecoverTimers(ctx context.Context) error {
+	logger := log.New()
+
+	ids, err := s.sessionRepo.FindIDsByState(ctx, entity.Play)
+	if err != nil {
+		return fmt.Errorf("find playing sessions: %w", err)
+	}
+
+	for _, id := range ids {
+		if err := s.recoverTimer(ctx, id); err != nil {
+			logger.Errorj(map[string]interface{}{"message": "failed to recover timer", "sessionID": id, "error": err.Error()})
+			continue
+		}
+	}
+	return nil
 }
 
-func NewSessionTimerUseCase(sessionRepo repository.Session, playerCli spotify.Player, pusher event.Pusher, tm *entity.SyncCheckTimerManager) *SessionTimerUseCase {
-	return &SessionTimerUseCase{tm: tm, sessionRepo: sessionRepo, playerCli: playerCli, pusher: pusher}
+// recoverTimer は作成者のトークンでSpotifyの再生状況を確認し、曲の終了を検知する予定を立てるかINTERRUPTにします。
+func (s *SessionTimerUseCase) recoverTimer(ctx context.Context, sessionID string) error {
+	logger := log.New()
+
+	creatorCtx, err := s.authUC.SetCreatorTokenToContext(ctx, sessionID)
+	if err != nil {
+		return fmt.Errorf("set creator token to context: %w", err)
+	}
+
+	cpi, cpiErr := s.playerCli.CurrentlyPlaying(creatorCtx)
+
+	_, err = s.sessionRepo.DoInTx(creatorCtx, func(ctx context.Context) (interface{}, error) {
+		sess, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("find session id=%s: %w", sessionID, err)
+		}
+		// 起動処理の間にクライアントから操作された
+		if sess.StateType != entity.Play || s.existsTimer(sessionID) {
+			return nil, nil
+		}
+
+		if cpiErr == nil {
+			cpiErr = sess.IsPlayingCorrectTrack(cpi)
+		}
+		if cpiErr != nil {
+			logger.Infoj(map[string]interface{}{"message": "recover timer detects interrupt", "sessionID": sessionID, "error": cpiErr.Error()})
+			s.handleInterrupt(ctx, sess)
+			if err := s.sessionRepo.Update(ctx, sess); err != nil {
+				return nil, fmt.Errorf("update session id=%s: %w", sessionID, err)
+			}
+			return nil, nil
+		}
+
+		logger.Infoj(map[string]interface{}{"message": "recover timer", "sessionID": sessionID, "remain": cpi.Remain().String()})
+		s.scheduler.AddJob(*s.nextTrackEndJob(sessionID, s.now().Add(cpi.Remain()-trackEndTriggerMargin), minSyncCheckInterval))
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("recover timer in transaction: %w", err)
+	}
+	return nil
 }
 
-// startTrackEndTrigger は曲の終了やストップを検知してそれぞれの処理を実行します。 goroutineで実行されることを想定しています。
-func (s *SessionTimerUseCase) startTrackEndTrigger(ctx context.Context, sessionID string) {
+// startTrackEndTrigger は曲の再生を待ってから同期チェックを行い、曲の終了を検知する予定を立てます。
+// 既に予定がある場合は置き換えます。
+func (s *SessionTimerUseCase) startTrackEndTrigger(sessionID string) {
 	logger := log.New()
 	logger.Debugj(map[string]interface{}{"message": "start track end trigger", "sessionID": sessionID})
 
 	// 曲の再生を待つ
-	waitTimer := time.NewTimer(5 * time.Second)
-	currentOperation := operationPlay
-
-	triggerAfterTrackEnd := s.tm.CreateExpiredTimer(sessionID)
-	for {
-		select {
-		case <-waitTimer.C:
-			if err := s.handleWaitTimerExpired(ctx, sessionID, triggerAfterTrackEnd, currentOperation); err != nil {
-				return
-			}
-		case <-triggerAfterTrackEnd.StopCh():
-			logger.Infoj(map[string]interface{}{"message": "stop timer", "sessionID": sessionID})
-			waitTimer.Stop()
-			s.deleteTimer(sessionID)
-			return
+	s.scheduler.Add(sessionID, entity.PlaybackJobCheckAfterPlay, s.now().Add(waitTimeAfterPlay))
+}
 
-		case <-triggerAfterTrackEnd.NextCh():
-			logger.Debugj(map[string]interface{}{"message": "call to move next track", "sessionID": sessionID})
-			waitTimer.Stop()
-			nextTrack, err := s.handleNext(ctx, sessionID)
-			if err != nil {
-				if errors.Is(err, entity.ErrSessionPlayingDifferentTrack) {
-					logger.Infoj(map[string]interface{}{"message": "handleTrackEnd detects interrupt", "sessionID": sessionID, "error": err.Error()})
-					return
-				}
-				logger.Errorj(map[string]interface{}{"message": "handleTrackEnd with error", "sessionID": sessionID, "error": err.Error()})
-				return
-			}
-			if !nextTrack {
-				logger.Infoj(map[string]interface{}{"message": "no next track", "sessionID": sessionID})
-				return
-			}
+// handlePlaybackJob はスケジューラから呼び出され、曲の終了やスキップ、同期チェックの処理を実行して次の予定を返します。
+// 長時間のセッションではリクエスト時のトークンの期限が切れるので、処理の度にセッションの作成者のトークンを取得し直します。
+func (s *SessionTimerUseCase) handlePlaybackJob(ctx context.Context, job entity.PlaybackJob) *entity.PlaybackJob {
+	logger := log.New()
+	sessionID := job.SessionID
 
-			waitTimer = time.NewTimer(waitTimeAfterHandleSkipTrack)
-			currentOperation = operationNextTrack
-
-		case <-triggerAfterTrackEnd.ExpireCh():
-			triggerAfterTrackEnd.MakeIsTimerExpiredTrue()
-			logger.Debugj(map[string]interface{}{"message": "trigger expired", "sessionID": sessionID})
-			nextTrack, err := s.handleTrackEnd(ctx, sessionID)
-			if err != nil {
-				if errors.Is(err, entity.ErrSessionPlayingDifferentTrack) {
-					logger.Infoj(map[string]interface{}{"message": "handleTrackEnd detects interrupt", "sessionID": sessionID, "error": err.Error()})
-					return
-				}
-				logger.Errorj(map[string]interface{}{"message": "handleTrackEnd with error", "sessionID": sessionID, "error": err.Error()})
-				return
-			}
-			if !nextTrack {
-				logger.Infoj(map[string]interface{}{"message": "no next track", "sessionID": sessionID})
-				return
-			}
-			waitTimer = time.NewTimer(waitTimeAfterHandleTrackEnd)
-			currentOperation = operationNextTrack
+	creatorCtx, err := s.authUC.SetCreatorTokenToContext(ctx, sessionID)
+	if err != nil {
+		if errors.Is(err, entity.ErrSessionNotFound) {
+			logger.Infoj(map[string]interface{}{"message": "session not found on playback job", "sessionID": sessionID})
+			return nil
+		}
+		logger.Errorj(map[string]interface{}{"message": "failed to set creator token", "sessionID": sessionID, "retryCount": job.TokenRetryCount, "error": err.Error()})
+		if job.TokenRetryCount < maxTokenRetryCount {
+			// 一時的なエラーの可能性があるので、少し待ってから同じ処理をやり直す
+			retry := job
+			retry.At = s.now().Add(retryIntervalOnTokenError)
+			retry.TokenRetryCount++
+			return &retry
+		}
+		if err := s.interruptOnTokenError(ctx, sessionID); err != nil {
+			logger.Errorj(map[string]interface{}{"message": "failed to interrupt on token error", "sessionID": sessionID, "error": err.Error()})
+		}
+		return nil
+	}
+	ctx = creatorCtx
+
+	switch job.Type {
+	case entity.PlaybackJobCheckAfterPlay, entity.PlaybackJobCheckAfterSkip, entity.PlaybackJobCheckAfterTrackEnd:
+		remainDuration, err := s.handleWaitTimerExpired(ctx, sessionID, job.Type)
+		if err != nil {
+			return nil
+		}
+		return s.nextTrackEndJob(sessionID, s.now().Add(remainDuration), minSyncCheckInterval)
+
+	case entity.PlaybackJobSyncCheck:
+		return s.handleSyncCheck(ctx, job)
+
+	case entity.PlaybackJobNextTrack:
+		logger.Debugj(map[string]interface{}{"message": "call to move next track", "sessionID": sessionID})
+		nextTrack, err := s.handleNext(ctx, sessionID)
+		if !s.shouldContinue(sessionID, nextTrack, err) {
+			return nil
+		}
+		return &entity.PlaybackJob{SessionID: sessionID, Type: entity.PlaybackJobCheckAfterSkip, At: s.now().Add(waitTimeAfterHandleSkipTrack)}
+
+	case entity.PlaybackJobTrackEnd:
+		logger.Debugj(map[string]interface{}{"message": "trigger expired", "sessionID": sessionID})
+		nextTrack, err := s.handleTrackEnd(ctx, sessionID)
+		if !s.shouldContinue(sessionID, nextTrack, err) {
+			return nil
+		}
+		return &entity.PlaybackJob{SessionID: sessionID, Type: entity.PlaybackJobCheckAfterTrackEnd, At: s.now().Add(waitTimeAfterHandleTrackEnd)}
+	}
+
+	logger.Errorj(map[string]interface{}{"message": "unknown playback job", "sessionID": sessionID, "type": job.Type})
+	return nil
+}
+
+// nextTrackEndJob は曲の終了を検知する予定を返します。
+// 曲の終了までに時間があるときは、先に曲の再生中の同期チェックを予定します。
+func (s *SessionTimerUseCase) nextTrackEndJob(sessionID string, trackEndAt time.Time, interval time.Duration) *entity.PlaybackJob {
+	checkAt := s.now().Add(interval)
+	// 曲の終了の直前に確認しても予定を立て直す余裕が無い
+	if checkAt.Add(minSyncCheckInterval).After(trackEndAt) {
+		return &entity.PlaybackJob{SessionID: sessionID, Type: entity.PlaybackJobTrackEnd, At: trackEndAt}
+	}
+	return &entity.PlaybackJob{
+		SessionID:    sessionID,
+		Type:         entity.PlaybackJobSyncCheck,
+		At:           checkAt,
+		TrackEndAt:   trackEndAt,
+		SyncInterval: interval,
+	}
+}
+
+// handleSyncCheck は曲の再生中にSpotifyと同期が取れているか確認します。
+// 再生位置がずれていれば曲の終了を検知する予定を立て直し、違う曲の再生や一時停止を見つけたらすぐにINTERRUPTにします。
+func (s *SessionTimerUseCase) handleSyncCheck(ctx context.Context, job entity.PlaybackJob) *entity.PlaybackJob {
+	logger := log.New()
+	sessionID := job.SessionID
+
+	// 一時的なエラーで再生を止めないように、確認できなかったときはそのまま曲の終了を待つ
+	cpi, err := s.playerCli.CurrentlyPlaying(ctx)
+	if err != nil {
+		logger.Infoj(map[string]interface{}{"message": "sync check: failed to get currently playing info", "sessionID": sessionID, "error": err.Error()})
+		return s.nextTrackEndJob(sessionID, job.TrackEndAt, job.SyncInterval)
+	}
+	sess, err := s.sessionRepo.FindByID(ctx, sessionID)
+	if err != nil {
+		logger.Errorj(map[string]interface{}{"message": "sync check: failed to get session", "sessionID": sessionID, "error": err.Error()})
+		return s.nextTrackEndJob(sessionID, job.TrackEndAt, job.SyncInterval)
+	}
+
+	if err := sess.IsPlayingCorrectTrack(cpi); err != nil {
+		interrupted, err := s.interruptIfOutOfSync(ctx, sessionID)
+		if err != nil {
+			logger.Errorj(map[string]interface{}{"message": "sync check: failed to interrupt", "sessionID": sessionID, "error": err.Error()})
+			return s.nextTrackEndJob(sessionID, job.TrackEndAt, job.SyncInterval)
+		}
+		if interrupted {
+			return nil
+		}
+		// ロックを取った時点では同期が取れていたので、次の確認で改めて判断する
+		return s.nextTrackEndJob(sessionID, job.TrackEndAt, minSyncCheckInterval)
+	}
+
+	trackEndAt := s.now().Add(cpi.Remain() - trackEndTriggerMargin)
+	drift := trackEndAt.Sub(job.TrackEndAt)
+	if drift < driftThreshold && drift > -driftThreshold {
+		interval := job.SyncInterval * 2
+		if interval > maxSyncCheckInterval {
+			interval = maxSyncCheckInterval
+		}
+		return s.nextTrackEndJob(sessionID, job.TrackEndAt, interval)
+	}
+
+	logger.Infoj(map[string]interface{}{"message": "sync check detects drift", "sessionID": sessionID, "drift": drift.String()})
+	return s.nextTrackEndJob(sessionID, trackEndAt, minSyncCheckInterval)
+}
+
+// interruptIfOutOfSync はセッションをロックしてからSpotifyの再生状況を取得し直し、同期が取れていなければINTERRUPTにします。
+// 確認している間に一時停止や設定の変更、Spotifyのキューの積み直しなどの操作がされていたら、その操作を優先してINTERRUPTにはしません。
+func (s *SessionTimerUseCase) interruptIfOutOfSync(ctx context.Context, sessionID string) (bool, error) {
+	logger := log.New()
+
+	interrupted, err := s.sessionRepo.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+		sess, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return false, fmt.Errorf("find session id=%s: %w", sessionID, err)
+		}
+		if sess.StateType != entity.Play {
+			return false, nil
+		}
+		cpi, err := s.playerCli.CurrentlyPlaying(ctx)
+		if err != nil {
+			return false, fmt.Errorf("call currently playing api: %w", err)
+		}
+		// 積み直しは予定を置き換えてから始めるので、再生状況を取得した後に予定が変わっていなければ積み直しの途中ではない
+		if !s.scheduler.IsRunning(sessionID, entity.PlaybackJobSyncCheck) {
+			return false, nil
+		}
+		err = sess.IsPlayingCorrectTrack(cpi)
+		if err == nil {
+			return false, nil
+		}
+
+		logger.Infoj(map[string]interface{}{"message": "sync check detects interrupt", "sessionID": sessionID, "error": err.Error()})
+		s.handleInterrupt(ctx, sess)
+		if err := s.sessionRepo.Update(ctx, sess); err != nil {
+			return false, fmt.Errorf("update session id=%s: %w", sessionID, err)
+		}
+		return true, nil
+	})
+	if err != nil {
+		return false, fmt.Errorf("interrupt in transaction: %w", err)
+	}
+	return interrupted.(bool), nil
+}
+
+// interruptOnTokenError はセッションの作成者のトークンを取得できず再生を続けられないときに、再生中のセッションをINTERRUPTにします。
+func (s *SessionTimerUseCase) interruptOnTokenError(ctx context.Context, sessionID string) error {
+	_, err := s.sessionRepo.DoInTx(ctx, func(ctx context.Context) (interface{}, error) {
+		sess, err := s.sessionRepo.FindByIDForUpdate(ctx, sessionID)
+		if err != nil {
+			return nil, fmt.Errorf("find session id=%s: %w", sessionID, err)
 		}
+		if sess.StateType != entity.Play {
+			return nil, nil
+		}
+
+		s.handleInterrupt(ctx, sess)
+		if err := s.sessionRepo.Update(ctx, sess); err != nil {
+			return nil, fmt.Errorf("update session id=%s: %w", sessionID, err)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("interrupt in transaction: %w", err)
+	}
+	return nil
+}
+
+// shouldContinue は曲の終了やスキップの処理の結果から、次の曲の同期チェックを続けるかどうか返します。
+func (s *SessionTimerUseCase) shouldContinue(sessionID string, nextTrack bool, err error) bool {
+	logger := log.New()
+	if err != nil {
+		if errors.Is(err, entity.ErrSessionPlayingDifferentTrack) {
+			logger.Infoj(map[string]interface{}{"message": "handleTrackEnd detects interrupt", "sessionID": sessionID, "error": err.Error()})
+			return false
+		}
+		logger.Errorj(map[string]interface{}{"message": "handleTrackEnd with error", "sessionID": sessionID, "error": err.Error()})
+		return false
+	}
+	if !nextTrack {
+		logger.Infoj(map[string]interface{}{"message": "no next track", "sessionID": sessionID})
+		return false
 	}
+	return true
 }
 
-func (s *SessionTimerUseCase) handleWaitTimerExpired(ctx context.Context, sessionID string, triggerAfterTrackEnd *entity.SyncCheckTimer, currentOperation currentOperation) error {
+// handleWaitTimerExpired はSpotifyと同期が取れているか確認し、曲の終了を検知するまでの時間を返します。
+func (s *SessionTimerUseCase) handleWaitTimerExpired(ctx context.Context, sessionID string, jobType entity.PlaybackJobType) (time.Duration, error) {
 	logger := log.New()
-	logger.Debugj(map[string]interface{}{"message": "currentOperation", "currentOperation": currentOperation})
+	logger.Debugj(map[string]interface{}{"message": "handle wait timer expired", "type": jobType})
 
 	playingInfo, err := s.playerCli.CurrentlyPlaying(ctx)
 	if err != nil {
@@ -102,7 +350,7 @@ func (s *SessionTimerUseCase) handleWaitTimerExpired(ctx context.Context, sessio
 			"sessionID": sessionID,
 			"error":     err.Error(),
 		})
-		return fmt.Errorf("failed to get currently playing info")
+		return 0, fmt.Errorf("failed to get currently playing info")
 	}
 
 	sess, err := s.sessionRepo.FindByID(ctx, sessionID)
@@ -112,41 +360,37 @@ func (s *SessionTimerUseCase) handleWaitTimerExpired(ctx context.Context, sessio
 			"sessionID": sessionID,
 			"error":     err.Error(),
 		})
-		return fmt.Errorf("failed to get session from repo")
+		return 0, fmt.Errorf("failed to get session from repo")
 	}
 
 	if err := sess.IsPlayingCorrectTrack(playingInfo); err != nil {
-		s.handleInterrupt(sess)
+		s.handleInterrupt(ctx, sess)
 		if err := s.sessionRepo.Update(ctx, sess); err != nil {
 			logger.Errorj(map[string]interface{}{
 				"message":   "handleWaitTimerExpired: failed to update session after IsPlayingCorrectTrack and handleInterrupt",
 				"sessionID": sessionID,
 				"error":     err.Error(),
 			})
-			return fmt.Errorf("failed to update session")
+			return 0, fmt.Errorf("failed to update session")
 		}
-		return fmt.Errorf("session interrupt")
+		return 0, fmt.Errorf("session interrupt")
 	}
 
-	switch currentOperation {
-	case operationNextTrack:
+	switch jobType {
+	case entity.PlaybackJobCheckAfterSkip, entity.PlaybackJobCheckAfterTrackEnd:
 		s.pusher.Push(&event.PushMessage{
 			SessionID: sess.ID,
 			Msg:       entity.NewEventNextTrack(sess.QueueHead),
 		})
 	}
 
-	// ぴったしのタイマーをセットすると、Spotifyでは次の曲の再生が始まってるのにRelaym側では次の曲に進んでおらず、
-	// INTERRUPTになってしまう
-	remainDuration := playingInfo.Remain() - 2*time.Second
+	remainDuration := playingInfo.Remain() - trackEndTriggerMargin
 
 	logger.Infoj(map[string]interface{}{
 		"message": "start timer", "sessionID": sessionID, "remainDuration": remainDuration.String(),
 	})
 
-	triggerAfterTrackEnd.SetDuration(remainDuration)
-
-	return nil
+	return remainDuration, nil
 }
 
 // handleTrackEnd はある一曲の再生が終わったときの処理を行います。
@@ -208,6 +452,16 @@ func (s *SessionTimerUseCase) handleTrackEndTx(sessionID string) func(ctx contex
 			return s.handleArchiveInTransaction(sessionID)
 		}
 
+		now := s.now()
+		// 次の曲が始まる前にスリープタイマーを確認する
+		reason, sleep := sess.SleepOnTrackEnd(now)
+
+		if err := s.storePlayedTrack(ctx, sess, entity.TrackEndReasonFinished, now); err != nil {
+			return &handleTrackEndResponse{nextTrack: false}, err
+		}
+
+		s.fillRadioTracks(ctx, sess)
+
 		if err := sess.GoNextTrack(); err != nil && errors.Is(err, entity.ErrSessionAllTracksFinished) {
 			s.handleAllTrackFinish(sess)
 			return &handleTrackEndResponse{
@@ -216,6 +470,17 @@ func (s *SessionTimerUseCase) handleTrackEndTx(sessionID string) func(ctx contex
 			}, nil
 		}
 
+		if sleep {
+			// Spotifyに任せると次の曲の再生が始まってしまうので、自分で次の曲に進めてすぐに一時停止する
+			if err := s.playerCli.GoNextTrack(ctx, sess.DeviceID); err != nil {
+				s.handleInterrupt(ctx, sess)
+				return &handleTrackEndResponse{nextTrack: false, err: nil}, nil
+			}
+			return s.sleepInTransaction(ctx, sess, reason)
+		}
+
+		sess.StartHeadTrack(now)
+
 		res, err := s.enqueueTrackInTransaction(ctx, sess)
 		if res != nil {
 			return res, err
@@ -227,6 +492,28 @@ func (s *SessionTimerUseCase) handleTrackEndTx(sessionID string) func(ctx contex
 	}
 }
 
+// sleepInTransaction はスリープタイマーで再生を止めます。
+// Spotifyで次の曲に進めた直後に呼び出し、次の曲の最初で一時停止してからSpotifyのキューに先読みする曲を積みます。
+func (s *SessionTimerUseCase) sleepInTransaction(ctx context.Context, sess *entity.Session, reason entity.SleepReason) (*handleTrackEndResponse, error) {
+	logger := log.New()
+	logger.Infoj(map[string]interface{}{"message": "sleep timer", "sessionID": sess.ID, "reason": reason})
+
+	if err := s.moveToPause(ctx, sess, 0); err != nil {
+		return &handleTrackEndResponse{nextTrack: false}, fmt.Errorf("move to pause: %w", err)
+	}
+
+	res, err := s.enqueueTrackInTransaction(ctx, sess)
+	if res != nil {
+		return res, err
+	}
+
+	s.pusher.Push(&event.PushMessage{
+		SessionID: sess.ID,
+		Msg:       entity.NewEventSleep(reason),
+	})
+	return &handleTrackEndResponse{nextTrack: false, err: nil}, nil
+}
+
 // handleNextTx はINTERRUPTになってerrorを帰す場合もトランザクションをコミットして欲しいので、
 // アプリケーションエラーはhandleTrackEndResponseのフィールドで返すようにしてerrorの返り値はnilにしている
 func (s *SessionTimerUseCase) handleNextTx(sessionID string) func(ctx context.Context) (interface{}, error) {
@@ -247,6 +534,10 @@ func (s *SessionTimerUseCase) handleNextTx(sessionID string) func(ctx context.Co
 			}
 		}()
 
+		now := s.now()
+		// スキップも曲の終わりとして、次の曲が始まる前にスリープタイマーを確認する
+		reason, sleep := sess.SleepOnTrackEnd(now)
+
 		if err := s.playerCli.GoNextTrack(ctx, sess.DeviceID); err != nil {
 			return &handleTrackEndResponse{nextTrack: false}, fmt.Errorf("GoNextTrack: %w", err)
 		}
@@ -255,6 +546,12 @@ func (s *SessionTimerUseCase) handleNextTx(sessionID string) func(ctx context.Co
 			return s.handleArchiveInTransaction(sessionID)
 		}
 
+		if err := s.storePlayedTrack(ctx, sess, entity.TrackEndReasonSkipped, now); err != nil {
+			return &handleTrackEndResponse{nextTrack: false}, err
+		}
+
+		s.fillRadioTracks(ctx, sess)
+
 		if err := sess.GoNextTrack(); err != nil && errors.Is(err, entity.ErrSessionAllTracksFinished) {
 			s.handleAllTrackFinish(sess)
 			return &handleTrackEndResponse{
@@ -263,6 +560,12 @@ func (s *SessionTimerUseCase) handleNextTx(sessionID string) func(ctx context.Co
 			}, nil
 		}
 
+		if sleep {
+			return s.sleepInTransaction(ctx, sess, reason)
+		}
+
+		sess.StartHeadTrack(now)
+
 		res, err := s.enqueueTrackInTransaction(ctx, sess)
 		if res != nil {
 			return res, err
@@ -292,7 +595,7 @@ func (s *SessionTimerUseCase) enqueueTrackInTransaction(ctx context.Context, ses
 	track := sess.TrackURIShouldBeAddedWhenHandleTrackEnd()
 	if track != "" {
 		if err := s.playerCli.Enqueue(ctx, track, sess.DeviceID); err != nil {
-			s.handleInterrupt(sess)
+			s.handleInterrupt(ctx, sess)
 			if err := s.sessionRepo.Update(ctx, sess); err != nil {
 				logger.Errorj(map[string]interface{}{
 					"message":   "handleWaitTimerExpired: failed to update session after Enqueue and handleInterrupt",
@@ -307,6 +610,86 @@ func (s *SessionTimerUseCase) enqueueTrackInTransaction(ctx context.Context, ses
 	return nil, nil
 }
 
+// syncSpotifyQueue はSpotifyのキューに先読みして積んである曲がセッションのキューとずれたときに、Spotify側のキューを積み直します。
+// Spotifyにはキューから曲を削除するAPIが無いので、キューを空にしてから現在の曲を同じ再生位置で再生し直します。
+func (s *SessionTimerUseCase) syncSpotifyQueue(ctx context.Context, sess *entity.Session) error {
+	switch sess.StateType {
+	case entity.Play:
+		cpi, err := s.playerCli.CurrentlyPlaying(ctx)
+		if err != nil {
+			return fmt.Errorf("call currently playing api: %w", err)
+		}
+		if err := s.replayFromHead(ctx, sess, cpi.Progress); err != nil {
+			return fmt.Errorf("replay from head: %w", err)
+		}
+		s.startTrackEndTrigger(sess.ID)
+	case entity.Pause:
+		if err := s.replayFromHead(ctx, sess, sess.ProgressWhenPaused); err != nil {
+			return fmt.Errorf("replay from head: %w", err)
+		}
+		if err := s.playerCli.Pause(ctx, sess.DeviceID); err != nil {
+			return fmt.Errorf("call pause api: %w", err)
+		}
+	}
+	return nil
+}
+
+// replayFromHead はSpotifyのキューを空にしてから、headの曲を指定した位置から再生し、先読みする曲をSpotifyのキューに積み直します。
+// PLAYのときは積み直している間の同期チェックでINTERRUPTにしないように、先に曲の再生を待つ予定に置き換えます。
+func (s *SessionTimerUseCase) replayFromHead(ctx context.Context, sess *entity.Session, position time.Duration) error {
+	if sess.StateType == entity.Play {
+		s.startTrackEndTrigger(sess.ID)
+	}
+
+	headURI := sess.HeadTrack().URI
+	if err := s.playerCli.DeleteAllTracksInQueue(ctx, sess.DeviceID, headURI); err != nil {
+		return fmt.Errorf("call DeleteAllTracksInQueue: %w", err)
+	}
+	if err := s.playerCli.PlayWithTracksAndPosition(ctx, sess.DeviceID, []string{headURI}, position); err != nil {
+		return fmt.Errorf("call play api with tracks %s: %w", headURI, err)
+	}
+	for _, uri := range sess.TrackURIsInSpotifyQueue() {
+		if err := s.playerCli.Enqueue(ctx, uri, sess.DeviceID); err != nil {
+			return fmt.Errorf("call add queue api trackURI=%s: %w", uri, err)
+		}
+	}
+	return nil
+}
+
+// restartHeadTrack はheadの曲を最初から再生し直し、Spotifyのキューに先読みする曲と曲の終了を検知するタイマーを作り直します。
+// PAUSEのときは再生し直した後に一時停止します。
+func (s *SessionTimerUseCase) restartHeadTrack(ctx context.Context, sess *entity.Session) error {
+	if err := s.replayFromHead(ctx, sess, 0); err != nil {
+		return fmt.Errorf("replay from head: %w", err)
+	}
+	switch sess.StateType {
+	case entity.Play:
+		s.startTrackEndTrigger(sess.ID)
+	case entity.Pause:
+		if err := s.playerCli.Pause(ctx, sess.DeviceID); err != nil {
+			return fmt.Errorf("call pause api: %w", err)
+		}
+	}
+	return nil
+}
+
+// moveToPause はSpotifyの再生を一時停止してセッションをPAUSEにし、曲の終了を検知するタイマーを止めます。
+// progressは再開するときの再生位置です。セッションの保存は呼び出し側で行う必要があります。
+func (s *SessionTimerUseCase) moveToPause(ctx context.Context, sess *entity.Session, progress time.Duration) error {
+	sess.SetProgressWhenPaused(progress)
+
+	if err := s.playerCli.Pause(ctx, sess.DeviceID); err != nil && !errors.Is(err, entity.ErrActiveDeviceNotFound) {
+		return fmt.Errorf("call pause api: %w", err)
+	}
+
+	s.deleteTimer(sess.ID)
+
+	if err := sess.MoveToPause(); err != nil {
+		return fmt.Errorf("move to pause id=%s: %w", sess.ID, err)
+	}
+	return nil
+}
+
 // handleAllTrackFinish はキューの全ての曲の再生が終わったときの処理を行います。
 func (s *SessionTimerUseCase) handleAllTrackFinish(sess *entity.Session) {
 	logger := log.New()
@@ -318,10 +701,14 @@ func (s *SessionTimerUseCase) handleAllTrackFinish(sess *entity.Session) {
 }
 
 // handleInterrupt はSpotifyとの同期が取れていないときの処理を行います。
-func (s *SessionTimerUseCase) handleInterrupt(sess *entity.Session) {
+func (s *SessionTimerUseCase) handleInterrupt(ctx context.Context, sess *entity.Session) {
 	logger := log.New()
 	logger.Debugj(map[string]interface{}{"message": "interrupt detected", "sessionID": sess.ID})
 
+	if err := s.storePlayedTrack(ctx, sess, entity.TrackEndReasonInterrupted, s.now()); err != nil {
+		logger.Errorj(map[string]interface{}{"message": "failed to store played track", "sessionID": sess.ID, "error": err.Error()})
+	}
+
 	sess.MoveToStop()
 
 	s.pusher.Push(&event.PushMessage{
@@ -330,31 +717,105 @@ func (s *SessionTimerUseCase) handleInterrupt(sess *entity.Session) {
 	})
 }
 
+// fillRadioTracks はラジオモードのときに未再生の曲が少なくなっていれば、最近再生された曲を元にしたおすすめの曲をキューに追加します。
+// Spotifyのキューに先読みして積むべき曲が増えた場合はSpotifyのキューにも追加します。
+// ラジオモードの曲の追加に失敗しても再生は続けられるので、エラーはログに出力するだけにします。
+func (s *SessionTimerUseCase) fillRadioTracks(ctx context.Context, sess *entity.Session) {
+	logger := log.New()
+
+	count := sess.RadioTrackCountToFill()
+	seeds := sess.RadioSeedTrackURIs()
+	if count == 0 || len(seeds) == 0 {
+		return
+	}
+
+	recommended, err := s.trackCli.GetRecommendedTrackURIs(ctx, seeds, radioRecommendationLimit)
+	if err != nil {
+		logger.Errorj(map[string]interface{}{"message": "failed to get recommended tracks", "sessionID": sess.ID, "error": err.Error()})
+		return
+	}
+	trackURIs := sess.TrackURIsNotInQueue(recommended)
+	if len(trackURIs) > count {
+		trackURIs = trackURIs[:count]
+	}
+	if len(trackURIs) == 0 {
+		return
+	}
+
+	before := sess.TrackURIsInSpotifyQueue()
+	now := s.now()
+	for _, trackURI := range trackURIs {
+		qt := &entity.QueueTrackToStore{
+			URI:           trackURI,
+			SessionID:     sess.ID,
+			AddedBySystem: true,
+			AddedAt:       now,
+		}
+		if err := s.sessionRepo.StoreQueueTrack(ctx, qt); err != nil {
+			logger.Errorj(map[string]interface{}{"message": "failed to store radio track", "sessionID": sess.ID, "uri": trackURI, "error": err.Error()})
+			return
+		}
+		sess.AppendQueueTrack(qt)
+	}
+
+	for _, trackURI := range sess.TrackURIsInSpotifyQueue()[len(before):] {
+		if err := s.playerCli.Enqueue(ctx, trackURI, sess.DeviceID); err != nil {
+			logger.Errorj(map[string]interface{}{"message": "failed to enqueue radio track", "sessionID": sess.ID, "uri": trackURI, "error": err.Error()})
+			return
+		}
+	}
+
+	s.pusher.Push(&event.PushMessage{
+		SessionID: sess.ID,
+		Msg:       entity.EventQueueChanged,
+	})
+}
+
+// storePlayedTrack はheadの曲の再生を終了させ、再生履歴として保存します。
+func (s *SessionTimerUseCase) storePlayedTrack(ctx context.Context, sess *entity.Session, reason entity.TrackEndReason, now time.Time) error {
+	played := sess.EndHeadTrack(reason, now)
+	if played == nil {
+		return nil
+	}
+	if err := s.sessionRepo.StorePlayedTrack(ctx, played); err != nil {
+		return fmt.Errorf("store played track session id=%s: %w", sess.ID, err)
+	}
+	return nil
+}
+
+// resetTrackEndTrigger はシークなどで曲の残り時間が変わったときに、曲の終了を検知する予定を立て直します。
+// 曲の終了を待っていないときは、次の曲の同期チェックの後に改めて予定が立てられるので何もしません。
+func (s *SessionTimerUseCase) resetTrackEndTrigger(sessionID string, remain time.Duration) error {
+	job := s.nextTrackEndJob(sessionID, s.now().Add(remain-trackEndTriggerMargin), minSyncCheckInterval)
+	// サーバーの再起動直後などで予定が無いときは、新しい残り時間で曲の終了の検知を始める
+	if !s.existsTimer(sessionID) {
+		s.scheduler.AddJob(*job)
+		return nil
+	}
+	if err := s.scheduler.Reschedule(*job, entity.PlaybackJobSyncCheck, entity.PlaybackJobTrackEnd); err != nil {
+		return fmt.Errorf("reschedule track end: %w", err)
+	}
+	return nil
+}
+
 func (s *SessionTimerUseCase) existsTimer(sessionID string) bool {
-	_, exists := s.tm.GetTimer(sessionID)
-	return exists
+	return s.scheduler.Exists(sessionID)
 }
 
 func (s *SessionTimerUseCase) deleteTimer(sessionID string) {
-	s.tm.DeleteTimer(sessionID)
+	s.scheduler.Cancel(sessionID)
 }
 
-func (s *SessionTimerUseCase) isTimerExpired(sessionID string) (bool, error) {
-	return s.tm.IsTimerExpired(sessionID)
+// isWaitingTrackEnd は曲の終了を待っている (Spotifyと同期が取れているはずの) 状態かどうか返します。
+func (s *SessionTimerUseCase) isWaitingTrackEnd(sessionID string) bool {
+	return s.scheduler.IsWaiting(sessionID, entity.PlaybackJobSyncCheck, entity.PlaybackJobTrackEnd)
 }
 
 func (s *SessionTimerUseCase) sendToNextCh(sessionID string) error {
-	return s.tm.SendToNextCh(sessionID)
+	return s.scheduler.RequestNext(sessionID)
 }
 
 type handleTrackEndResponse struct {
 	nextTrack bool
 	err       error
 }
-
-type currentOperation string
-
-const (
-	operationPlay      currentOperation = "play"
-	operationNextTrack currentOperation = "NextTrack"
-)
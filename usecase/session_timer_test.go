@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -10,7 +11,9 @@ import (
 	"github.com/camphor-/relaym-server/domain/mock_event"
 	"github.com/camphor-/relaym-server/domain/mock_repository"
 	"github.com/camphor-/relaym-server/domain/mock_spotify"
+	"github.com/camphor-/relaym-server/domain/service"
 	"github.com/golang/mock/gomock"
+	"golang.org/x/oauth2"
 )
 
 func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
@@ -20,6 +23,7 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 		name                     string
 		sessionID                string
 		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockTrackFn       func(m *mock_spotify.MockTrackClient)
 		prepareMockPusherFn      func(m *mock_event.MockPusher)
 		prepareMockUserRepoFn    func(m *mock_repository.MockUser)
 		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
@@ -30,6 +34,7 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 			name:                "最後の曲が再生し終わったときにSTOPイベントが送られる",
 			sessionID:           "sessionID",
 			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn:  func(m *mock_spotify.MockTrackClient) {},
 			prepareMockPusherFn: func(m *mock_event.MockPusher) {
 				m.EXPECT().Push(&event.PushMessage{
 					SessionID: "sessionID",
@@ -67,11 +72,23 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 			wantErr:       false,
 		},
 		{
-			name:      "次の曲が存在するときはNEXTTRACKイベントが送られて、次の再生状態に遷移する",
+			name:      "ラジオモードで未再生の曲が少ないときはおすすめの曲が追加されて、次の再生状態に遷移する",
 			sessionID: "sessionID",
 			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				gomock.InOrder(
+					m.EXPECT().Enqueue(gomock.Any(), "spotify:track:radio1", "deviceID").Return(nil),
+					m.EXPECT().Enqueue(gomock.Any(), "spotify:track:radio2", "deviceID").Return(nil),
+				)
+			},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetRecommendedTrackURIs(gomock.Any(), []string{"spotify:track:played"}, radioRecommendationLimit).
+					Return([]string{"spotify:track:next", "spotify:track:radio1", "spotify:track:radio2", "spotify:track:radio3"}, nil)
 			},
 			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.EventQueueChanged,
+				})
 			},
 			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
 			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
@@ -82,6 +99,92 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 					DeviceID:  "deviceID",
 					StateType: entity.Play,
 					QueueHead: 0,
+					RadioMode: true,
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:played", SessionID: "sessionID"},
+						{Index: 1, URI: "spotify:track:next", SessionID: "sessionID"},
+					},
+				}, nil)
+				gomock.InOrder(
+					m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
+						URI:           "spotify:track:radio1",
+						SessionID:     "sessionID",
+						AddedBySystem: true,
+						AddedAt:       fixedNow,
+					}).Return(nil),
+					m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
+						URI:           "spotify:track:radio2",
+						SessionID:     "sessionID",
+						AddedBySystem: true,
+						AddedAt:       fixedNow,
+					}).Return(nil),
+				)
+				m.EXPECT().Update(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantNextTrack: true,
+			wantErr:       false,
+		},
+		{
+			name:      "ラジオモードでもキューを繰り返すときはおすすめの曲を追加せずに、キューの最初に戻って先読みする曲をEnqueueする",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:next", "deviceID").Return(nil)
+			},
+			prepareMockTrackFn:    func(m *mock_spotify.MockTrackClient) {},
+			prepareMockPusherFn:   func(m *mock_event.MockPusher) {},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:          "sessionID",
+					Name:        "name",
+					CreatorID:   "creatorID",
+					DeviceID:    "deviceID",
+					StateType:   entity.Play,
+					QueueHead:   0,
+					RadioMode:   true,
+					RepeatQueue: true,
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:played", SessionID: "sessionID"},
+						{Index: 1, URI: "spotify:track:next", SessionID: "sessionID"},
+					},
+				}, nil)
+				m.EXPECT().Update(gomock.Any(), &entity.Session{
+					ID:            "sessionID",
+					Name:          "name",
+					CreatorID:     "creatorID",
+					DeviceID:      "deviceID",
+					StateType:     entity.Play,
+					QueueHead:     1,
+					HeadStartedAt: fixedNow,
+					RadioMode:     true,
+					RepeatQueue:   true,
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:played", SessionID: "sessionID"},
+						{Index: 1, URI: "spotify:track:next", SessionID: "sessionID"},
+					},
+				}).Return(nil)
+			},
+			wantNextTrack: true,
+			wantErr:       false,
+		},
+		{
+			name:      "次の曲が存在するときはNEXTTRACKイベントが送られて、次の再生状態に遷移する",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+			},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:            "sessionID",
+					Name:          "name",
+					CreatorID:     "creatorID",
+					DeviceID:      "deviceID",
+					StateType:     entity.Play,
+					QueueHead:     0,
+					HeadStartedAt: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
 					QueueTracks: []*entity.QueueTrack{
 						{
 							Index:     0,
@@ -95,13 +198,21 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 						},
 					},
 				}, nil)
+				m.EXPECT().StorePlayedTrack(gomock.Any(), &entity.PlayedTrack{
+					SessionID: "sessionID",
+					URI:       "spotify:track:asfafefea",
+					StartedAt: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+					EndedAt:   fixedNow,
+					EndReason: entity.TrackEndReasonFinished,
+				}).Return(nil)
 				m.EXPECT().Update(gomock.Any(), &entity.Session{
-					ID:        "sessionID",
-					Name:      "name",
-					CreatorID: "creatorID",
-					DeviceID:  "deviceID",
-					StateType: entity.Play,
-					QueueHead: 1,
+					ID:            "sessionID",
+					Name:          "name",
+					CreatorID:     "creatorID",
+					DeviceID:      "deviceID",
+					StateType:     entity.Play,
+					QueueHead:     1,
+					HeadStartedAt: fixedNow,
 					QueueTracks: []*entity.QueueTrack{
 						{
 							Index:     0,
@@ -119,10 +230,72 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 			wantNextTrack: true,
 			wantErr:       false,
 		},
+		{
+			name:      "スリープタイマーの曲数を再生し終えたときは次の曲の最初で一時停止してSLEEPイベントが送られる",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				gomock.InOrder(
+					m.EXPECT().GoNextTrack(gomock.Any(), "deviceID").Return(nil),
+					m.EXPECT().Pause(gomock.Any(), "deviceID").Return(nil),
+					m.EXPECT().Enqueue(gomock.Any(), "spotify:track:4", "deviceID").Return(nil),
+				)
+			},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.NewEventSleep(entity.SleepReasonTrackCount),
+				})
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:            "sessionID",
+					Name:          "name",
+					CreatorID:     "creatorID",
+					DeviceID:      "deviceID",
+					StateType:     entity.Play,
+					QueueHead:     0,
+					HeadStartedAt: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:1", SessionID: "sessionID"},
+						{Index: 1, URI: "spotify:track:2", SessionID: "sessionID"},
+						{Index: 2, URI: "spotify:track:3", SessionID: "sessionID"},
+						{Index: 3, URI: "spotify:track:4", SessionID: "sessionID"},
+					},
+					SleepAfterTracks: 1,
+				}, nil)
+				m.EXPECT().StorePlayedTrack(gomock.Any(), &entity.PlayedTrack{
+					SessionID: "sessionID",
+					URI:       "spotify:track:1",
+					StartedAt: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+					EndedAt:   fixedNow,
+					EndReason: entity.TrackEndReasonFinished,
+				}).Return(nil)
+				m.EXPECT().Update(gomock.Any(), &entity.Session{
+					ID:        "sessionID",
+					Name:      "name",
+					CreatorID: "creatorID",
+					DeviceID:  "deviceID",
+					StateType: entity.Pause,
+					QueueHead: 1,
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:1", SessionID: "sessionID"},
+						{Index: 1, URI: "spotify:track:2", SessionID: "sessionID"},
+						{Index: 2, URI: "spotify:track:3", SessionID: "sessionID"},
+						{Index: 3, URI: "spotify:track:4", SessionID: "sessionID"},
+					},
+					SleepAfterTracks: 0,
+				}).Return(nil)
+			},
+			wantNextTrack: false,
+			wantErr:       false,
+		},
 		{
 			name:                  "次の曲が存在するが、実際には違う曲が流れていた場合はINTERRUPTイベントが送られる",
 			sessionID:             "sessionID",
 			prepareMockPlayerFn:   func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn:    func(m *mock_spotify.MockTrackClient) {},
 			prepareMockPusherFn:   func(m *mock_event.MockPusher) {},
 			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
 			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
@@ -147,12 +320,13 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 					},
 				}, nil)
 				m.EXPECT().Update(gomock.Any(), &entity.Session{
-					ID:        "sessionID",
-					Name:      "name",
-					CreatorID: "creatorID",
-					DeviceID:  "deviceID",
-					StateType: entity.Play,
-					QueueHead: 1,
+					ID:            "sessionID",
+					Name:          "name",
+					CreatorID:     "creatorID",
+					DeviceID:      "deviceID",
+					StateType:     entity.Play,
+					QueueHead:     1,
+					HeadStartedAt: fixedNow,
 					QueueTracks: []*entity.QueueTrack{
 						{
 							Index:     0,
@@ -175,6 +349,7 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 			sessionID: "sessionID",
 			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
 			},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {},
 			prepareMockPusherFn: func(m *mock_event.MockPusher) {
 			},
 			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
@@ -200,12 +375,13 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 					},
 				}, nil)
 				m.EXPECT().Update(gomock.Any(), &entity.Session{
-					ID:        "sessionID",
-					Name:      "name",
-					CreatorID: "creatorID",
-					DeviceID:  "deviceID",
-					StateType: entity.Play,
-					QueueHead: 1,
+					ID:            "sessionID",
+					Name:          "name",
+					CreatorID:     "creatorID",
+					DeviceID:      "deviceID",
+					StateType:     entity.Play,
+					QueueHead:     1,
+					HeadStartedAt: fixedNow,
 					QueueTracks: []*entity.QueueTrack{
 						{
 							Index:     0,
@@ -228,6 +404,7 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 			sessionID: "sessionID",
 			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
 			},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {},
 			prepareMockPusherFn: func(m *mock_event.MockPusher) {
 				m.EXPECT().Push(&event.PushMessage{
 					SessionID: "sessionID",
@@ -287,6 +464,8 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 			defer ctrl.Finish()
 			mockPlayer := mock_spotify.NewMockPlayer(ctrl)
 			tt.prepareMockPlayerFn(mockPlayer)
+			mockTrackCli := mock_spotify.NewMockTrackClient(ctrl)
+			tt.prepareMockTrackFn(mockTrackCli)
 			mockPusher := mock_event.NewMockPusher(ctrl)
 			tt.prepareMockPusherFn(mockPusher)
 			mockUserRepo := mock_repository.NewMockUser(ctrl)
@@ -300,9 +479,8 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 				waitTimeAfterHandleTrackEnd = tmpWaitTimeBeforeHandleTrackEnd
 			}()
 
-			syncCheckTimerManager := entity.NewSyncCheckTimerManager()
-
-			s := NewSessionTimerUseCase(mockSessionRepo, mockPlayer, mockPusher, syncCheckTimerManager)
+			clock := entity.NewFakeClock(fixedNow)
+			s := NewSessionTimerUseCase(mockSessionRepo, mockPlayer, mockTrackCli, mockPusher, entity.NewPlaybackScheduler(1, clock), nil, clock)
 			gotTriggerAfterTrackEndResponseInterface, err := s.handleTrackEndTx(tt.sessionID)(context.Background())
 
 			gotHandleTrackEndResponse, ok := gotTriggerAfterTrackEndResponseInterface.(*handleTrackEndResponse)
@@ -320,11 +498,167 @@ func TestSessionTimerUseCase_handleTrackEndTx(t *testing.T) {
 	}
 }
 
+func TestSessionTimerUseCase_handleNextTx(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		wantNextTrack            bool
+		wantErr                  bool
+	}{
+		{
+			name:      "スキップすると次の曲に進み、先読みする曲がEnqueueされる",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().GoNextTrack(gomock.Any(), "deviceID").Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:4", "deviceID").Return(nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:            "sessionID",
+					Name:          "name",
+					CreatorID:     "creatorID",
+					DeviceID:      "deviceID",
+					StateType:     entity.Play,
+					QueueHead:     0,
+					HeadStartedAt: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:1", SessionID: "sessionID"},
+						{Index: 1, URI: "spotify:track:2", SessionID: "sessionID"},
+						{Index: 2, URI: "spotify:track:3", SessionID: "sessionID"},
+						{Index: 3, URI: "spotify:track:4", SessionID: "sessionID"},
+					},
+				}, nil)
+				m.EXPECT().StorePlayedTrack(gomock.Any(), &entity.PlayedTrack{
+					SessionID: "sessionID",
+					URI:       "spotify:track:1",
+					StartedAt: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+					EndedAt:   fixedNow,
+					EndReason: entity.TrackEndReasonSkipped,
+				}).Return(nil)
+				m.EXPECT().Update(gomock.Any(), &entity.Session{
+					ID:            "sessionID",
+					Name:          "name",
+					CreatorID:     "creatorID",
+					DeviceID:      "deviceID",
+					StateType:     entity.Play,
+					QueueHead:     1,
+					HeadStartedAt: fixedNow,
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:1", SessionID: "sessionID"},
+						{Index: 1, URI: "spotify:track:2", SessionID: "sessionID"},
+						{Index: 2, URI: "spotify:track:3", SessionID: "sessionID"},
+						{Index: 3, URI: "spotify:track:4", SessionID: "sessionID"},
+					},
+				}).Return(nil)
+			},
+			wantNextTrack: true,
+			wantErr:       false,
+		},
+		{
+			name:      "スリープタイマーの曲数をスキップで使い切ったときは次の曲の最初で一時停止してSLEEPイベントが送られる",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				gomock.InOrder(
+					m.EXPECT().GoNextTrack(gomock.Any(), "deviceID").Return(nil),
+					m.EXPECT().Pause(gomock.Any(), "deviceID").Return(nil),
+					m.EXPECT().Enqueue(gomock.Any(), "spotify:track:4", "deviceID").Return(nil),
+				)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.NewEventSleep(entity.SleepReasonTrackCount),
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:            "sessionID",
+					Name:          "name",
+					CreatorID:     "creatorID",
+					DeviceID:      "deviceID",
+					StateType:     entity.Play,
+					QueueHead:     0,
+					HeadStartedAt: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:1", SessionID: "sessionID"},
+						{Index: 1, URI: "spotify:track:2", SessionID: "sessionID"},
+						{Index: 2, URI: "spotify:track:3", SessionID: "sessionID"},
+						{Index: 3, URI: "spotify:track:4", SessionID: "sessionID"},
+					},
+					SleepAfterTracks: 1,
+				}, nil)
+				m.EXPECT().StorePlayedTrack(gomock.Any(), &entity.PlayedTrack{
+					SessionID: "sessionID",
+					URI:       "spotify:track:1",
+					StartedAt: time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC),
+					EndedAt:   fixedNow,
+					EndReason: entity.TrackEndReasonSkipped,
+				}).Return(nil)
+				m.EXPECT().Update(gomock.Any(), &entity.Session{
+					ID:        "sessionID",
+					Name:      "name",
+					CreatorID: "creatorID",
+					DeviceID:  "deviceID",
+					StateType: entity.Pause,
+					QueueHead: 1,
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:1", SessionID: "sessionID"},
+						{Index: 1, URI: "spotify:track:2", SessionID: "sessionID"},
+						{Index: 2, URI: "spotify:track:3", SessionID: "sessionID"},
+						{Index: 3, URI: "spotify:track:4", SessionID: "sessionID"},
+					},
+					SleepAfterTracks: 0,
+				}).Return(nil)
+			},
+			wantNextTrack: false,
+			wantErr:       false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockPlayer := mock_spotify.NewMockPlayer(ctrl)
+			tt.prepareMockPlayerFn(mockPlayer)
+			mockTrackCli := mock_spotify.NewMockTrackClient(ctrl)
+			mockPusher := mock_event.NewMockPusher(ctrl)
+			tt.prepareMockPusherFn(mockPusher)
+			mockSessionRepo := mock_repository.NewMockSession(ctrl)
+			tt.prepareMockSessionRepoFn(mockSessionRepo)
+
+			clock := entity.NewFakeClock(fixedNow)
+			s := NewSessionTimerUseCase(mockSessionRepo, mockPlayer, mockTrackCli, mockPusher, entity.NewPlaybackScheduler(1, clock), nil, clock)
+			got, err := s.handleNextTx(tt.sessionID)(context.Background())
+			if err != nil {
+				t.Fatalf("handleNextTx() error = %v", err)
+			}
+
+			gotHandleTrackEndResponse, ok := got.(*handleTrackEndResponse)
+			if !ok {
+				t.Fatal("got should be *handleTrackEndResponse")
+			}
+			if (gotHandleTrackEndResponse.err != nil) != tt.wantErr {
+				t.Errorf("handleNextTx() error = %v, wantErr %v", gotHandleTrackEndResponse.err, tt.wantErr)
+				return
+			}
+			if gotHandleTrackEndResponse.nextTrack != tt.wantNextTrack {
+				t.Errorf("handleNextTx() gotNextTrack = %v, want %v", gotHandleTrackEndResponse.nextTrack, tt.wantNextTrack)
+			}
+		})
+	}
+}
+
 func TestSessionTimerUseCase_handleWaitTimerExpired(t *testing.T) {
 	tests := []struct {
 		name                     string
 		sessionID                string
-		currentOperation         currentOperation
+		jobType                  entity.PlaybackJobType
 		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
 		prepareMockPusherFn      func(m *mock_event.MockPusher)
 		prepareMockUserRepoFn    func(m *mock_repository.MockUser)
@@ -332,9 +666,9 @@ func TestSessionTimerUseCase_handleWaitTimerExpired(t *testing.T) {
 		wantErr                  bool
 	}{
 		{
-			name:             "Spotifyとの同期が取れていることが確認されると、currentOperationがPlayの時はイベントは送信されない",
-			sessionID:        "sessionID",
-			currentOperation: "Play",
+			name:      "Spotifyとの同期が取れていることが確認されると、再生開始後の確認の時はイベントは送信されない",
+			sessionID: "sessionID",
+			jobType:   entity.PlaybackJobCheckAfterPlay,
 			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
 				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(&entity.CurrentPlayingInfo{
 					Playing:  true,
@@ -381,9 +715,9 @@ func TestSessionTimerUseCase_handleWaitTimerExpired(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:             "Spotifyとの同期が取れていることが確認されると、currentOperationがNextTrackの時はイベントが送信される",
-			sessionID:        "sessionID",
-			currentOperation: "NextTrack",
+			name:      "Spotifyとの同期が取れていることが確認されると、スキップ後の確認の時はイベントが送信される",
+			sessionID: "sessionID",
+			jobType:   entity.PlaybackJobCheckAfterSkip,
 			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
 				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(&entity.CurrentPlayingInfo{
 					Playing:  true,
@@ -435,9 +769,9 @@ func TestSessionTimerUseCase_handleWaitTimerExpired(t *testing.T) {
 			wantErr: false,
 		},
 		{
-			name:             "Spotifyとの同期が取れていないとhandleInterruptが呼び出されErrorが返る",
-			sessionID:        "sessionID",
-			currentOperation: "NextTrack",
+			name:      "Spotifyとの同期が取れていないとhandleInterruptが呼び出されErrorが返る",
+			sessionID: "sessionID",
+			jobType:   entity.PlaybackJobCheckAfterSkip,
 			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
 				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(&entity.CurrentPlayingInfo{
 					Playing:  true,
@@ -510,6 +844,7 @@ func TestSessionTimerUseCase_handleWaitTimerExpired(t *testing.T) {
 			defer ctrl.Finish()
 			mockPlayer := mock_spotify.NewMockPlayer(ctrl)
 			tt.prepareMockPlayerFn(mockPlayer)
+			mockTrackCli := mock_spotify.NewMockTrackClient(ctrl)
 			mockPusher := mock_event.NewMockPusher(ctrl)
 			tt.prepareMockPusherFn(mockPusher)
 			mockUserRepo := mock_repository.NewMockUser(ctrl)
@@ -523,14 +858,769 @@ func TestSessionTimerUseCase_handleWaitTimerExpired(t *testing.T) {
 				waitTimeAfterHandleTrackEnd = tmpWaitTimeBeforeHandleTrackEnd
 			}()
 
-			syncCheckTimerManager := entity.NewSyncCheckTimerManager()
+			clock := entity.NewFakeClock(fixedNow)
+			s := NewSessionTimerUseCase(mockSessionRepo, mockPlayer, mockTrackCli, mockPusher, entity.NewPlaybackScheduler(1, clock), nil, clock)
 
-			s := NewSessionTimerUseCase(mockSessionRepo, mockPlayer, mockPusher, syncCheckTimerManager)
+			if _, err := s.handleWaitTimerExpired(context.Background(), tt.sessionID, tt.jobType); (err != nil) != tt.wantErr {
+				t.Errorf("handleWaitTimerExpired() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
 
-			triggerAfterTrackEnd := s.tm.CreateExpiredTimer(tt.sessionID)
+func TestSessionTimerUseCase_recoverTimer(t *testing.T) {
+	t.Parallel()
 
-			if err := s.handleWaitTimerExpired(context.Background(), tt.sessionID, triggerAfterTrackEnd, tt.currentOperation); (err != nil) != tt.wantErr {
-				t.Errorf("handleWaitTimerExpired() error = %v, wantErr %v", err, tt.wantErr)
+	token := &oauth2.Token{AccessToken: "access_token", Expiry: time.Now().Add(time.Hour)}
+	newSession := func(stateType entity.StateType) *entity.Session {
+		return &entity.Session{
+			ID:        "sessionID",
+			Name:      "name",
+			CreatorID: "creatorID",
+			DeviceID:  "deviceID",
+			StateType: stateType,
+			QueueHead: 1,
+			QueueTracks: []*entity.QueueTrack{
+				{Index: 0, URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF"},
+				{Index: 1, URI: "spotify:track:06QTSGUEgcmKwiEJ0IMPig"},
+			},
+		}
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		wantWaitingTrackEnd      bool
+		wantErr                  bool
+	}{
+		{
+			name:      "Spotifyで正しい曲が再生されていると曲の終了を検知する予定が立てられる",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(&entity.CurrentPlayingInfo{
+					Playing:  true,
+					Progress: 10 * time.Second,
+					Track: &entity.Track{
+						URI:      "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
+						Duration: 213 * time.Second,
+					},
+				}, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(token, "creatorID", nil)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(newSession(entity.Play), nil)
+			},
+			wantWaitingTrackEnd: true,
+			wantErr:             false,
+		},
+		{
+			name:      "Spotifyで別の曲が再生されているとINTERRUPTになる",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(&entity.CurrentPlayingInfo{
+					Playing:  true,
+					Progress: 10 * time.Second,
+					Track: &entity.Track{
+						URI:      "spotify:track:5uQ0vKy2973Y9IUCd1wMEF",
+						Duration: 213 * time.Second,
+					},
+				}, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.EventInterrupt,
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(token, "creatorID", nil)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(newSession(entity.Play), nil)
+				m.EXPECT().Update(gomock.Any(), newSession(entity.Stop)).Return(nil)
+			},
+			wantWaitingTrackEnd: false,
+			wantErr:             false,
+		},
+		{
+			name:      "再生状況が取得できないとINTERRUPTになる",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(nil, entity.ErrActiveDeviceNotFound)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.EventInterrupt,
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(token, "creatorID", nil)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(newSession(entity.Play), nil)
+				m.EXPECT().Update(gomock.Any(), newSession(entity.Stop)).Return(nil)
+			},
+			wantWaitingTrackEnd: false,
+			wantErr:             false,
+		},
+		{
+			name:      "確認している間にPLAYでなくなったセッションは何もしない",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(nil, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(token, "creatorID", nil)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(newSession(entity.Pause), nil)
+			},
+			wantWaitingTrackEnd: false,
+			wantErr:             false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockPlayer := mock_spotify.NewMockPlayer(ctrl)
+			tt.prepareMockPlayerFn(mockPlayer)
+			mockPusher := mock_event.NewMockPusher(ctrl)
+			tt.prepareMockPusherFn(mockPusher)
+			mockSessionRepo := mock_repository.NewMockSession(ctrl)
+			mockSessionRepo.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+					return f(ctx)
+				})
+			tt.prepareMockSessionRepoFn(mockSessionRepo)
+			authUC := NewAuthUseCase(nil, nil, nil, nil, mockSessionRepo)
+
+			s := NewSessionTimerUseCase(mockSessionRepo, mockPlayer, nil, mockPusher, entity.NewPlaybackScheduler(1, entity.NewSystemClock()), authUC, entity.NewSystemClock())
+			if err := s.recoverTimer(context.Background(), tt.sessionID); (err != nil) != tt.wantErr {
+				t.Errorf("recoverTimer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := s.isWaitingTrackEnd(tt.sessionID); got != tt.wantWaitingTrackEnd {
+				t.Errorf("recoverTimer() waiting track end = %v, want %v", got, tt.wantWaitingTrackEnd)
+			}
+		})
+	}
+}
+
+func TestSessionTimerUseCase_handlePlaybackJob_CreatorToken(t *testing.T) {
+	t.Parallel()
+
+	expiredToken := &oauth2.Token{AccessToken: "expired_access_token", RefreshToken: "refresh_token", Expiry: time.Now().Add(-time.Hour)}
+	refreshedToken := &oauth2.Token{AccessToken: "refreshed_access_token", RefreshToken: "refresh_token", Expiry: time.Now().Add(time.Hour)}
+
+	tests := []struct {
+		name                     string
+		tokenRetryCount          int
+		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockAuthCliFn     func(m *mock_spotify.MockAuth)
+		prepareMockAuthRepoFn    func(m *mock_repository.MockAuth)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		wantType                 entity.PlaybackJobType
+		wantTokenRetryCount      int
+		wantNil                  bool
+	}{
+		{
+			name: "期限が切れたトークンは更新してからSpotifyを操作する",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).DoAndReturn(func(ctx context.Context) (*entity.CurrentPlayingInfo, error) {
+					if token, _ := service.GetTokenFromContext(ctx); token != refreshedToken {
+						t.Errorf("CurrentlyPlaying() called with token %v, want %v", token, refreshedToken)
+					}
+					return &entity.CurrentPlayingInfo{
+						Playing:  true,
+						Progress: 10 * time.Second,
+						Track:    &entity.Track{URI: "spotify:track:06QTSGUEgcmKwiEJ0IMPig", Duration: 213 * time.Second},
+					}, nil
+				})
+			},
+			prepareMockAuthCliFn: func(m *mock_spotify.MockAuth) {
+				m.EXPECT().Refresh(expiredToken).Return(refreshedToken, nil)
+			},
+			prepareMockAuthRepoFn: func(m *mock_repository.MockAuth) {
+				m.EXPECT().StoreORUpdateToken("creatorID", refreshedToken).Return(nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(expiredToken, "creatorID", nil)
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:        "sessionID",
+					CreatorID: "creatorID",
+					StateType: entity.Play,
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:06QTSGUEgcmKwiEJ0IMPig"},
+					},
+				}, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantType:            entity.PlaybackJobSyncCheck,
+			wantNil:             false,
+		},
+		{
+			name:                  "トークンの取得に失敗したときは同じ処理をやり直す",
+			tokenRetryCount:       1,
+			prepareMockPlayerFn:   func(m *mock_spotify.MockPlayer) {},
+			prepareMockAuthCliFn:  func(m *mock_spotify.MockAuth) {},
+			prepareMockAuthRepoFn: func(m *mock_repository.MockAuth) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(nil, "", errors.New("unknown error"))
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantType:            entity.PlaybackJobCheckAfterPlay,
+			wantTokenRetryCount: 2,
+			wantNil:             false,
+		},
+		{
+			name:                "トークンの取得をやり直しても失敗し続けるときはINTERRUPTにして予定を削除する",
+			tokenRetryCount:     maxTokenRetryCount,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockAuthCliFn: func(m *mock_spotify.MockAuth) {
+				m.EXPECT().Refresh(expiredToken).Return(nil, errors.New("invalid_grant"))
+			},
+			prepareMockAuthRepoFn: func(m *mock_repository.MockAuth) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(expiredToken, "creatorID", nil)
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+					// 実際のDoInTxはctxに値を詰めるので、nilを渡すとpanicになる
+					if ctx == nil {
+						t.Fatal("DoInTx() called with nil context")
+					}
+					return f(ctx)
+				})
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(&entity.Session{
+					ID:        "sessionID",
+					CreatorID: "creatorID",
+					StateType: entity.Play,
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:06QTSGUEgcmKwiEJ0IMPig"},
+					},
+				}, nil)
+				m.EXPECT().Update(gomock.Any(), &entity.Session{
+					ID:        "sessionID",
+					CreatorID: "creatorID",
+					StateType: entity.Stop,
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:06QTSGUEgcmKwiEJ0IMPig"},
+					},
+				}).Return(nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.EventInterrupt,
+				})
+			},
+			wantNil: true,
+		},
+		{
+			name:                  "セッションが存在しないときは予定を削除する",
+			prepareMockPlayerFn:   func(m *mock_spotify.MockPlayer) {},
+			prepareMockAuthCliFn:  func(m *mock_spotify.MockAuth) {},
+			prepareMockAuthRepoFn: func(m *mock_repository.MockAuth) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(nil, "", entity.ErrSessionNotFound)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			wantNil:             true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockPlayer := mock_spotify.NewMockPlayer(ctrl)
+			tt.prepareMockPlayerFn(mockPlayer)
+			mockAuthCli := mock_spotify.NewMockAuth(ctrl)
+			tt.prepareMockAuthCliFn(mockAuthCli)
+			mockAuthRepo := mock_repository.NewMockAuth(ctrl)
+			tt.prepareMockAuthRepoFn(mockAuthRepo)
+			mockSessionRepo := mock_repository.NewMockSession(ctrl)
+			tt.prepareMockSessionRepoFn(mockSessionRepo)
+			mockPusher := mock_event.NewMockPusher(ctrl)
+			tt.prepareMockPusherFn(mockPusher)
+			authUC := NewAuthUseCase(mockAuthCli, nil, mockAuthRepo, nil, mockSessionRepo)
+
+			s := NewSessionTimerUseCase(mockSessionRepo, mockPlayer, nil, mockPusher, entity.NewPlaybackScheduler(1, entity.NewSystemClock()), authUC, entity.NewSystemClock())
+			job := entity.PlaybackJob{SessionID: "sessionID", Type: entity.PlaybackJobCheckAfterPlay, TokenRetryCount: tt.tokenRetryCount}
+			got := s.handlePlaybackJob(context.Background(), job)
+			if (got == nil) != tt.wantNil {
+				t.Fatalf("handlePlaybackJob() = %v, wantNil %v", got, tt.wantNil)
+			}
+			if got != nil && got.Type != tt.wantType {
+				t.Errorf("handlePlaybackJob() type = %s, want %s", got.Type, tt.wantType)
+			}
+			if got != nil && got.TokenRetryCount != tt.wantTokenRetryCount {
+				t.Errorf("handlePlaybackJob() token retry count = %d, want %d", got.TokenRetryCount, tt.wantTokenRetryCount)
+			}
+		})
+	}
+}
+
+func TestSessionTimerUseCase_PlaybackSequence(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	// Expiryが無いトークンは期限切れにならない
+	token := &oauth2.Token{AccessToken: "access_token"}
+	trackURIs := []string{"spotify:track:0", "spotify:track:1", "spotify:track:2", "spotify:track:3"}
+	newSession := func(head int, headStartedAt time.Time) *entity.Session {
+		queueTracks := make([]*entity.QueueTrack, len(trackURIs))
+		for i, uri := range trackURIs {
+			queueTracks[i] = &entity.QueueTrack{Index: i, URI: uri, SessionID: "sessionID"}
+		}
+		return &entity.Session{
+			ID:            "sessionID",
+			CreatorID:     "creatorID",
+			DeviceID:      "deviceID",
+			StateType:     entity.Play,
+			QueueHead:     head,
+			QueueTracks:   queueTracks,
+			HeadStartedAt: headStartedAt,
+		}
+	}
+	// 短い曲では再生中の同期チェックを挟まずに曲の終了を待つ
+	shortTrack := 14 * time.Second
+	longTrack := time.Minute
+	playing := func(head int, progress, duration time.Duration) *entity.CurrentPlayingInfo {
+		return &entity.CurrentPlayingInfo{
+			Playing:  true,
+			Progress: progress,
+			Track:    &entity.Track{URI: trackURIs[head], Duration: duration},
+		}
+	}
+
+	type step struct {
+		action      func(s *SessionTimerUseCase)
+		advance     time.Duration
+		wantRun     int
+		wantWaiting entity.PlaybackJobType // 空文字のときは予定が無いことを期待する
+	}
+	tests := []struct {
+		name                     string
+		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		steps                    []step
+	}{
+		{
+			name: "再生開始後の確認から曲の終了を検知して次の曲の確認まで進む",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				gomock.InOrder(
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 5*time.Second, shortTrack), nil),
+					m.EXPECT().Enqueue(gomock.Any(), "spotify:track:3", "deviceID").Return(nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(1, 5*time.Second, shortTrack), nil),
+				)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.NewEventNextTrack(1),
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				gomock.InOrder(
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().StorePlayedTrack(gomock.Any(), &entity.PlayedTrack{
+						SessionID: "sessionID",
+						URI:       "spotify:track:0",
+						StartedAt: start,
+						EndedAt:   start.Add(12 * time.Second),
+						EndReason: entity.TrackEndReasonFinished,
+					}).Return(nil),
+					m.EXPECT().Update(gomock.Any(), newSession(1, start.Add(12*time.Second))).Return(nil),
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(1, start.Add(12*time.Second)), nil),
+				)
+			},
+			steps: []step{
+				{advance: 4 * time.Second, wantRun: 0, wantWaiting: entity.PlaybackJobCheckAfterPlay},
+				{advance: time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobTrackEnd},
+				// 残り9秒からマージンの2秒を引いた7秒後に曲の終了を検知する
+				{advance: 6 * time.Second, wantRun: 0, wantWaiting: entity.PlaybackJobTrackEnd},
+				{advance: time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobCheckAfterTrackEnd},
+				{advance: 6 * time.Second, wantRun: 0, wantWaiting: entity.PlaybackJobCheckAfterTrackEnd},
+				{advance: time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobTrackEnd},
+			},
+		},
+		{
+			name: "スキップが指示されると曲の終了を待たずに次の曲に進む",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				gomock.InOrder(
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 5*time.Second, shortTrack), nil),
+					m.EXPECT().GoNextTrack(gomock.Any(), "deviceID").Return(nil),
+					m.EXPECT().Enqueue(gomock.Any(), "spotify:track:3", "deviceID").Return(nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(1, 5*time.Second, shortTrack), nil),
+				)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.NewEventNextTrack(1),
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				gomock.InOrder(
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().StorePlayedTrack(gomock.Any(), &entity.PlayedTrack{
+						SessionID: "sessionID",
+						URI:       "spotify:track:0",
+						StartedAt: start,
+						EndedAt:   start.Add(8 * time.Second),
+						EndReason: entity.TrackEndReasonSkipped,
+					}).Return(nil),
+					m.EXPECT().Update(gomock.Any(), newSession(1, start.Add(8*time.Second))).Return(nil),
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(1, start.Add(8*time.Second)), nil),
+				)
+			},
+			steps: []step{
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobTrackEnd},
+				{advance: 3 * time.Second, wantRun: 0, wantWaiting: entity.PlaybackJobTrackEnd},
+				{
+					action: func(s *SessionTimerUseCase) {
+						if err := s.sendToNextCh("sessionID"); err != nil {
+							t.Fatal(err)
+						}
+					},
+					advance:     0,
+					wantRun:     1,
+					wantWaiting: entity.PlaybackJobCheckAfterSkip,
+				},
+				{advance: 300 * time.Millisecond, wantRun: 1, wantWaiting: entity.PlaybackJobTrackEnd},
+			},
+		},
+		{
+			name: "曲の終了後の確認でSpotifyが別の曲を再生しているとINTERRUPTになり予定が削除される",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				gomock.InOrder(
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 5*time.Second, shortTrack), nil),
+					m.EXPECT().Enqueue(gomock.Any(), "spotify:track:3", "deviceID").Return(nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 5*time.Second, shortTrack), nil),
+				)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.EventInterrupt,
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				interrupted := newSession(1, time.Time{})
+				interrupted.StateType = entity.Stop
+				gomock.InOrder(
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().StorePlayedTrack(gomock.Any(), gomock.Any()).Return(nil),
+					m.EXPECT().Update(gomock.Any(), newSession(1, start.Add(12*time.Second))).Return(nil),
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(1, start.Add(12*time.Second)), nil),
+					m.EXPECT().StorePlayedTrack(gomock.Any(), &entity.PlayedTrack{
+						SessionID: "sessionID",
+						URI:       "spotify:track:1",
+						StartedAt: start.Add(12 * time.Second),
+						EndedAt:   start.Add(19 * time.Second),
+						EndReason: entity.TrackEndReasonInterrupted,
+					}).Return(nil),
+					m.EXPECT().Update(gomock.Any(), interrupted).Return(nil),
+				)
+			},
+			steps: []step{
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobTrackEnd},
+				{advance: 7 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobCheckAfterTrackEnd},
+				{advance: 7 * time.Second, wantRun: 1, wantWaiting: ""},
+			},
+		},
+		{
+			name: "曲の再生中の同期チェックで再生位置のずれが見つかると曲の終了を検知する予定が立て直される",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				gomock.InOrder(
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 5*time.Second, longTrack), nil),
+					// バッファリングで再生が5秒遅れた
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 5*time.Second, longTrack), nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 10*time.Second, longTrack), nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 20*time.Second, longTrack), nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 40*time.Second, longTrack), nil),
+				)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil).Times(5)
+			},
+			steps: []step{
+				// 曲の終了は58秒後の予定で、5秒後に同期チェックをする
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+				// ずれが見つかったので曲の終了を63秒後に立て直し、5秒後に再び同期チェックをする
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+				{advance: 4 * time.Second, wantRun: 0, wantWaiting: entity.PlaybackJobSyncCheck},
+				// ずれが無いので同期チェックの間隔を10秒、20秒と延ばしていく
+				{advance: time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+				{advance: 10 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+				{advance: 19 * time.Second, wantRun: 0, wantWaiting: entity.PlaybackJobSyncCheck},
+				// 次の同期チェックは曲の終了より後になるので、曲の終了を待つ
+				{advance: time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobTrackEnd},
+				// 元の予定の58秒後を過ぎても曲の終了は検知しない
+				{advance: 17 * time.Second, wantRun: 0, wantWaiting: entity.PlaybackJobTrackEnd},
+			},
+		},
+		{
+			name: "曲の再生中にSpotifyで一時停止されると曲の終了を待たずにINTERRUPTになる",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				paused := playing(0, 8*time.Second, longTrack)
+				paused.Playing = false
+				gomock.InOrder(
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 5*time.Second, longTrack), nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(paused, nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(paused, nil),
+				)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.EventInterrupt,
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				interrupted := newSession(0, time.Time{})
+				interrupted.StateType = entity.Stop
+				gomock.InOrder(
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().StorePlayedTrack(gomock.Any(), &entity.PlayedTrack{
+						SessionID: "sessionID",
+						URI:       "spotify:track:0",
+						StartedAt: start,
+						EndedAt:   start.Add(10 * time.Second),
+						EndReason: entity.TrackEndReasonInterrupted,
+					}).Return(nil),
+					m.EXPECT().Update(gomock.Any(), interrupted).Return(nil),
+				)
+			},
+			steps: []step{
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: ""},
+			},
+		},
+		{
+			name: "同期が取れていないように見えてもロックした後のセッションが一時停止されていればINTERRUPTにしない",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				paused := playing(0, 8*time.Second, longTrack)
+				paused.Playing = false
+				gomock.InOrder(
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 5*time.Second, longTrack), nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(paused, nil),
+				)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				pausedSession := newSession(0, time.Time{})
+				pausedSession.StateType = entity.Pause
+				gomock.InOrder(
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(pausedSession, nil),
+				)
+			},
+			steps: []step{
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+			},
+		},
+		{
+			name: "ロックした後に取得し直した再生状況で同期が取れていればINTERRUPTにしない",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				// Spotifyのキューを積み直している途中で一時的に止まっていた
+				stopped := playing(0, 0, longTrack)
+				stopped.Playing = false
+				gomock.InOrder(
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 5*time.Second, longTrack), nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(stopped, nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 10*time.Second, longTrack), nil),
+				)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				gomock.InOrder(
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+					m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(newSession(0, start), nil),
+				)
+			},
+			steps: []step{
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+			},
+		},
+		{
+			name: "曲の再生中の同期チェックで再生状況が取得できなくても曲の終了を待ち続ける",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				gomock.InOrder(
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(playing(0, 5*time.Second, longTrack), nil),
+					m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(nil, errors.New("unknown error")),
+				)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(newSession(0, start), nil)
+			},
+			steps: []step{
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+				{advance: 5 * time.Second, wantRun: 1, wantWaiting: entity.PlaybackJobSyncCheck},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			mockPlayer := mock_spotify.NewMockPlayer(ctrl)
+			tt.prepareMockPlayerFn(mockPlayer)
+			mockPusher := mock_event.NewMockPusher(ctrl)
+			tt.prepareMockPusherFn(mockPusher)
+			mockSessionRepo := mock_repository.NewMockSession(ctrl)
+			mockSessionRepo.EXPECT().FindCreatorTokenBySessionID(gomock.Any(), "sessionID").Return(token, "creatorID", nil).AnyTimes()
+			mockSessionRepo.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+					return f(ctx)
+				}).AnyTimes()
+			tt.prepareMockSessionRepoFn(mockSessionRepo)
+			authUC := NewAuthUseCase(nil, nil, nil, nil, mockSessionRepo)
+
+			clock := entity.NewFakeClock(start)
+			s := NewSessionTimerUseCase(mockSessionRepo, mockPlayer, nil, mockPusher, entity.NewPlaybackScheduler(1, clock), authUC, clock)
+			s.startTrackEndTrigger("sessionID")
+
+			// 処理を実行するたびに通知して、テストの手順と同期を取る
+			ran := make(chan struct{}, 10)
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan struct{})
+			go func() {
+				defer close(stopped)
+				s.scheduler.Run(ctx, func(ctx context.Context, job entity.PlaybackJob) *entity.PlaybackJob {
+					next := s.handlePlaybackJob(ctx, job)
+					ran <- struct{}{}
+					return next
+				})
+			}()
+			defer func() {
+				cancel()
+				<-stopped
+			}()
+
+			for i, st := range tt.steps {
+				if st.action != nil {
+					st.action(s)
+				}
+				clock.Advance(st.advance)
+				for n := 0; n < st.wantRun; n++ {
+					select {
+					case <-ran:
+					case <-time.After(time.Second):
+						t.Fatalf("step %d: ran %d playback jobs, want %d", i, n, st.wantRun)
+					}
+				}
+				settled := waitUntil(func() bool {
+					if st.wantWaiting == "" {
+						return !s.existsTimer("sessionID")
+					}
+					return s.scheduler.IsWaiting("sessionID", st.wantWaiting)
+				})
+				if !settled {
+					t.Fatalf("step %d: should be waiting %q", i, st.wantWaiting)
+				}
+				select {
+				case <-ran:
+					t.Fatalf("step %d: ran more playback jobs than %d", i, st.wantRun)
+				default:
+				}
+			}
+		})
+	}
+}
+
+func TestSessionTimerUseCase_syncSpotifyQueue(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name                    string
+		state                   entity.StateType
+		scheduled               bool
+		prepareMockPlayerFn     func(m *mock_spotify.MockPlayer)
+		wantWaitingWhileRebuild bool
+		wantWaitingAfterRebuild bool
+	}{
+		{
+			name:      "PLAYのときは積み直す前に同期チェックの予定を曲の再生を待つ予定に置き換える",
+			state:     entity.Play,
+			scheduled: true,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(&entity.CurrentPlayingInfo{
+					Playing:  true,
+					Progress: 10 * time.Second,
+					Track:    &entity.Track{URI: "spotify:track:0", Duration: time.Minute},
+				}, nil)
+				m.EXPECT().PlayWithTracksAndPosition(gomock.Any(), "deviceID", []string{"spotify:track:0"}, 10*time.Second).Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:1", "deviceID").Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:2", "deviceID").Return(nil)
+			},
+			wantWaitingWhileRebuild: true,
+			wantWaitingAfterRebuild: true,
+		},
+		{
+			name:      "PAUSEのときは予定を立てない",
+			state:     entity.Pause,
+			scheduled: false,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().PlayWithTracksAndPosition(gomock.Any(), "deviceID", []string{"spotify:track:0"}, time.Duration(0)).Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:1", "deviceID").Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:2", "deviceID").Return(nil)
+				m.EXPECT().Pause(gomock.Any(), "deviceID").Return(nil)
+			},
+			wantWaitingWhileRebuild: false,
+			wantWaitingAfterRebuild: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			clock := entity.NewFakeClock(fixedNow)
+			mockPlayer := mock_spotify.NewMockPlayer(ctrl)
+			s := NewSessionTimerUseCase(nil, mockPlayer, nil, nil, entity.NewPlaybackScheduler(1, clock), nil, clock)
+			if tt.scheduled {
+				s.scheduler.Add("sessionID", entity.PlaybackJobSyncCheck, fixedNow.Add(time.Second))
+			}
+
+			var gotWaitingWhileRebuild bool
+			mockPlayer.EXPECT().DeleteAllTracksInQueue(gomock.Any(), "deviceID", "spotify:track:0").DoAndReturn(
+				func(ctx context.Context, deviceID, trackURI string) error {
+					gotWaitingWhileRebuild = s.scheduler.IsWaiting("sessionID", entity.PlaybackJobCheckAfterPlay)
+					return nil
+				})
+			tt.prepareMockPlayerFn(mockPlayer)
+
+			sess := &entity.Session{
+				ID:        "sessionID",
+				DeviceID:  "deviceID",
+				StateType: tt.state,
+				QueueHead: 0,
+				QueueTracks: []*entity.QueueTrack{
+					{Index: 0, URI: "spotify:track:0"},
+					{Index: 1, URI: "spotify:track:1"},
+					{Index: 2, URI: "spotify:track:2"},
+				},
+			}
+			if err := s.syncSpotifyQueue(context.Background(), sess); err != nil {
+				t.Fatalf("syncSpotifyQueue() error = %v", err)
+			}
+			if gotWaitingWhileRebuild != tt.wantWaitingWhileRebuild {
+				t.Errorf("syncSpotifyQueue() waiting while rebuild = %v, want %v", gotWaitingWhileRebuild, tt.wantWaitingWhileRebuild)
+			}
+			if got := s.scheduler.IsWaiting("sessionID", entity.PlaybackJobCheckAfterPlay); got != tt.wantWaitingAfterRebuild {
+				t.Errorf("syncSpotifyQueue() waiting after rebuild = %v, want %v", got, tt.wantWaitingAfterRebuild)
 			}
 		})
 	}
@@ -1,11 +1,18 @@
 package handler
 
 import (
+	"context"
+	"time"
+
 	"github.com/camphor-/relaym-server/domain/service"
+
 	"github.com/labstack/echo/v4"
 	"golang.org/x/oauth2"
 )
 
+// fixedNow はテストで使うFakeClockの現在時刻です。
+var fixedNow = time.Date(2020, 1, 1, 12, 30, 0, 0, time.UTC)
+
 func setToContext(c echo.Context, userID string, token *oauth2.Token) echo.Context {
 	ctx := c.Request().Context()
 	ctx = service.SetUserIDToContext(ctx, userID)
@@ -13,3 +20,8 @@ func setToContext(c echo.Context, userID string, token *oauth2.Token) echo.Conte
 	c.SetRequest(c.Request().WithContext(ctx))
 	return c
 }
+
+// doInTx はSessionリポジトリのDoInTxのモックで渡された関数をそのまま実行するために使います。
+func doInTx(ctx context.Context, f func(ctx context.Context) (interface{}, error)) (interface{}, error) {
+	return f(ctx)
+}
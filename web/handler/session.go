@@ -3,6 +3,9 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
 
 	"github.com/camphor-/relaym-server/log"
 
@@ -13,15 +16,22 @@ import (
 	"github.com/labstack/echo/v4"
 )
 
+// maxNicknameLength はゲストが曲を追加する際に指定できるニックネームの最大文字数です。
+const maxNicknameLength = 255
+
+// enqueuePositionNext は曲を次に再生されるように追加するときに指定するpositionです。
+const enqueuePositionNext = "next"
+
 // SessionHandler は /sessions 以下のエンドポイントを管理する構造体です。
 type SessionHandler struct {
 	uc      *usecase.SessionUseCase
 	stateUC *usecase.SessionStateUseCase
+	clock   entity.Clock
 }
 
 // NewSessionHandler はSessionHandlerのポインタを生成する関数です。
-func NewSessionHandler(uc *usecase.SessionUseCase, stateUC *usecase.SessionStateUseCase) *SessionHandler {
-	return &SessionHandler{uc: uc, stateUC: stateUC}
+func NewSessionHandler(uc *usecase.SessionUseCase, stateUC *usecase.SessionStateUseCase, clock entity.Clock) *SessionHandler {
+	return &SessionHandler{uc: uc, stateUC: stateUC, clock: clock}
 }
 
 // PostSession は POST /sessions に対応するハンドラーです。
@@ -30,6 +40,7 @@ func (h *SessionHandler) PostSession(c echo.Context) error {
 	type reqJSON struct {
 		Name                   string `json:"name"`
 		AllowToControlByOthers bool   `json:"allow_to_control_by_others"`
+		ScheduledStartAt       string `json:"scheduled_start_at"`
 	}
 	req := new(reqJSON)
 	if err := c.Bind(req); err != nil {
@@ -42,9 +53,19 @@ func (h *SessionHandler) PostSession(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "empty name")
 	}
 
+	var scheduledStartAt time.Time
+	if req.ScheduledStartAt != "" {
+		at, err := entity.NewScheduledStartAt(req.ScheduledStartAt, h.clock.Now().UTC())
+		if err != nil {
+			logger.Debugj(map[string]interface{}{"message": "failed to parse scheduled start at", "error": err.Error()})
+			return echo.NewHTTPError(http.StatusBadRequest, "invalid scheduled_start_at")
+		}
+		scheduledStartAt = at
+	}
+
 	ctx := c.Request().Context()
 	userID, _ := service.GetUserIDFromContext(ctx)
-	session, err := h.uc.CreateSession(ctx, sessionName, userID, req.AllowToControlByOthers)
+	session, err := h.uc.CreateSession(ctx, sessionName, userID, req.AllowToControlByOthers, scheduledStartAt)
 	if err != nil {
 		logger.Error(err)
 		return echo.NewHTTPError(http.StatusInternalServerError)
@@ -71,11 +92,33 @@ func (h *SessionHandler) GetSession(c echo.Context) error {
 	return c.JSON(http.StatusOK, h.toSessionRes(session, playingInfo, tracks))
 }
 
+// GetHistory は GET /sessions/:id/history に対応するハンドラーです。
+func (h *SessionHandler) GetHistory(c echo.Context) error {
+	logger := log.New()
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	playedTracks, tracks, err := h.uc.GetPlayedTracks(ctx, id)
+	if err != nil {
+		if errors.Is(err, entity.ErrSessionNotFound) {
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusNotFound)
+		}
+		logger.Error(err)
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	}
+	return c.JSON(http.StatusOK, &historyRes{
+		Tracks: toPlayedTrackJSON(playedTracks, tracks),
+	})
+}
+
 // Enqueue は POST /sessions/:id/queue に対応するハンドラーです。
 func (h *SessionHandler) Enqueue(c echo.Context) error {
 	logger := log.New()
 	type reqJSON struct {
-		URI string `json:"uri"`
+		URI      string `json:"uri"`
+		Nickname string `json:"nickname"`
+		Position string `json:"position"`
 	}
 	req := new(reqJSON)
 	if err := c.Bind(req); err != nil {
@@ -87,11 +130,42 @@ func (h *SessionHandler) Enqueue(c echo.Context) error {
 		return echo.NewHTTPError(http.StatusBadRequest, "invalid track id")
 	}
 
+	if utf8.RuneCountInString(req.Nickname) > maxNicknameLength {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid nickname")
+	}
+
+	if req.Position != "" && req.Position != enqueuePositionNext {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid position")
+	}
+
 	ctx := c.Request().Context()
 	sessionID := c.Param("id")
 
-	if err := h.uc.EnqueueTrack(ctx, sessionID, req.URI); err != nil {
-		if errors.Is(err, entity.ErrSessionNotFound) {
+	if err := h.uc.EnqueueTrack(ctx, sessionID, req.URI, req.Nickname, req.Position == enqueuePositionNext); err != nil {
+		switch {
+		case errors.Is(err, entity.ErrSessionNotAllowToControlOthers):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrSessionNotAllowToControlOthers.Error())
+		case errors.Is(err, entity.ErrQueueTrackNotMovable):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrQueueTrackNotMovable.Error())
+		case errors.Is(err, entity.ErrActiveDeviceNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusForbidden, entity.ErrActiveDeviceNotFound.Error())
+		case errors.Is(err, entity.ErrInvalidTrackURI):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidTrackURI.Error())
+		case errors.Is(err, entity.ErrTrackNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusNotFound, entity.ErrTrackNotFound.Error())
+		case errors.Is(err, entity.ErrTrackNotPlayable):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrTrackNotPlayable.Error())
+		case errors.Is(err, entity.ErrDuplicateQueueTrack):
+			return echo.NewHTTPError(http.StatusConflict, entity.ErrDuplicateQueueTrack.Error())
+		case errors.Is(err, entity.ErrTooManyPendingQueueTracks):
+			return echo.NewHTTPError(http.StatusForbidden, entity.ErrTooManyPendingQueueTracks.Error())
+		case errors.Is(err, entity.ErrEnqueueRateLimitExceeded):
+			return echo.NewHTTPError(http.StatusTooManyRequests, entity.ErrEnqueueRateLimitExceeded.Error())
+		case errors.Is(err, entity.ErrSessionNotFound):
 			logger.Debug(err)
 			return echo.NewHTTPError(http.StatusNotFound, entity.ErrSessionNotFound.Error())
 		}
@@ -102,6 +176,243 @@ func (h *SessionHandler) Enqueue(c echo.Context) error {
 	return c.NoContent(http.StatusNoContent)
 }
 
+// RemoveQueueTrack は DELETE /sessions/:id/queue/:index に対応するハンドラーです。
+func (h *SessionHandler) RemoveQueueTrack(c echo.Context) error {
+	logger := log.New()
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		logger.Debug(err)
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid index")
+	}
+
+	ctx := c.Request().Context()
+	sessionID := c.Param("id")
+
+	if err := h.uc.RemoveQueueTrack(ctx, sessionID, index); err != nil {
+		return h.queueEditErrorToHTTPError(err, "failed to remove queue track")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// MoveQueueTrack は PUT /sessions/:id/queue/move に対応するハンドラーです。
+func (h *SessionHandler) MoveQueueTrack(c echo.Context) error {
+	logger := log.New()
+	type reqJSON struct {
+		From *int `json:"from"`
+		To   *int `json:"to"`
+	}
+	req := new(reqJSON)
+	if err := c.Bind(req); err != nil {
+		logger.Debugj(map[string]interface{}{"message": "failed to bind", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid index")
+	}
+
+	if req.From == nil || req.To == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid index")
+	}
+
+	ctx := c.Request().Context()
+	sessionID := c.Param("id")
+
+	if err := h.uc.MoveQueueTrack(ctx, sessionID, *req.From, *req.To); err != nil {
+		return h.queueEditErrorToHTTPError(err, "failed to move queue track")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// ShuffleQueueTracks は PUT /sessions/:id/shuffle に対応するハンドラーです。
+func (h *SessionHandler) ShuffleQueueTracks(c echo.Context) error {
+	logger := log.New()
+	type reqJSON struct {
+		Seed *int64 `json:"seed"`
+	}
+	req := new(reqJSON)
+	if err := c.Bind(req); err != nil {
+		logger.Debugj(map[string]interface{}{"message": "failed to bind", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid seed")
+	}
+
+	ctx := c.Request().Context()
+	sessionID := c.Param("id")
+
+	if err := h.uc.ShuffleQueueTracks(ctx, sessionID, req.Seed); err != nil {
+		return h.queueEditErrorToHTTPError(err, "failed to shuffle queue tracks")
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *SessionHandler) queueEditErrorToHTTPError(err error, message string) error {
+	logger := log.New()
+	switch {
+	case errors.Is(err, entity.ErrSessionNotAllowToControlOthers):
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrSessionNotAllowToControlOthers.Error())
+	case errors.Is(err, entity.ErrQueueTrackNotEditable):
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrQueueTrackNotEditable.Error())
+	case errors.Is(err, entity.ErrQueueTrackNotMovable):
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrQueueTrackNotMovable.Error())
+	case errors.Is(err, entity.ErrQueueTrackNotFound):
+		logger.Debug(err)
+		return echo.NewHTTPError(http.StatusNotFound, entity.ErrQueueTrackNotFound.Error())
+	case errors.Is(err, entity.ErrSessionNotFound):
+		logger.Debug(err)
+		return echo.NewHTTPError(http.StatusNotFound, entity.ErrSessionNotFound.Error())
+	case errors.Is(err, entity.ErrActiveDeviceNotFound):
+		logger.Debug(err)
+		return echo.NewHTTPError(http.StatusForbidden, entity.ErrActiveDeviceNotFound.Error())
+	}
+	logger.Errorj(map[string]interface{}{"message": message, "error": err.Error()})
+	return echo.NewHTTPError(http.StatusInternalServerError)
+}
+
+// VoteQueueTrack は PUT /sessions/:id/queue/:index/vote に対応するハンドラーです。
+func (h *SessionHandler) VoteQueueTrack(c echo.Context) error {
+	logger := log.New()
+	type reqJSON struct {
+		Vote *int `json:"vote"`
+	}
+
+	index, err := strconv.Atoi(c.Param("index"))
+	if err != nil {
+		logger.Debug(err)
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid index")
+	}
+
+	req := new(reqJSON)
+	if err := c.Bind(req); err != nil {
+		logger.Debugj(map[string]interface{}{"message": "failed to bind", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidVote.Error())
+	}
+
+	if req.Vote == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidVote.Error())
+	}
+
+	ctx := c.Request().Context()
+	sessionID := c.Param("id")
+
+	if err := h.uc.VoteQueueTrack(ctx, sessionID, index, *req.Vote); err != nil {
+		switch {
+		case errors.Is(err, entity.ErrInvalidVote):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidVote.Error())
+		case errors.Is(err, entity.ErrQueueModeIsNotVote):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrQueueModeIsNotVote.Error())
+		case errors.Is(err, entity.ErrQueueTrackNotEditable):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrQueueTrackNotEditable.Error())
+		case errors.Is(err, entity.ErrQueueTrackNotVotable):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrQueueTrackNotVotable.Error())
+		case errors.Is(err, entity.ErrQueueTrackNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusNotFound, entity.ErrQueueTrackNotFound.Error())
+		case errors.Is(err, entity.ErrSessionNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusNotFound, entity.ErrSessionNotFound.Error())
+		}
+		logger.Errorj(map[string]interface{}{"message": "failed to vote queue track", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// PatchSession は PATCH /sessions/:id に対応するハンドラーです。
+func (h *SessionHandler) PatchSession(c echo.Context) error {
+	logger := log.New()
+	type reqJSON struct {
+		QueueMode               *string `json:"queue_mode"`
+		MaxPendingTracksPerUser *int    `json:"max_pending_tracks_per_user"`
+		EnqueueRateLimit        *int    `json:"enqueue_rate_limit"`
+		EnqueueRateWindowSec    *int    `json:"enqueue_rate_window_sec"`
+		RejectDuplicateTracks   *bool   `json:"reject_duplicate_tracks"`
+		RadioMode               *bool   `json:"radio_mode"`
+		MaxVolume               *int    `json:"max_volume"`
+		DeviceFallback          *bool   `json:"device_fallback"`
+		RepeatQueue             *bool   `json:"repeat_queue"`
+		SleepAfterTracks        *int    `json:"sleep_after_tracks"`
+		SleepAt                 *string `json:"sleep_at"`
+		ScheduledStartAt        *string `json:"scheduled_start_at"`
+	}
+	req := new(reqJSON)
+	if err := c.Bind(req); err != nil {
+		logger.Debugj(map[string]interface{}{"message": "failed to bind", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidSessionSettings.Error())
+	}
+
+	settings := &entity.SessionSettingsToUpdate{
+		MaxPendingTracksPerUser: req.MaxPendingTracksPerUser,
+		EnqueueRateLimit:        req.EnqueueRateLimit,
+		RejectDuplicateTracks:   req.RejectDuplicateTracks,
+		RadioMode:               req.RadioMode,
+		MaxVolume:               req.MaxVolume,
+		DeviceFallback:          req.DeviceFallback,
+		RepeatQueue:             req.RepeatQueue,
+		SleepAfterTracks:        req.SleepAfterTracks,
+	}
+
+	if req.QueueMode != nil {
+		mode, err := entity.NewQueueMode(*req.QueueMode)
+		if err != nil {
+			logger.Debugj(map[string]interface{}{"message": "failed to convert queue mode", "error": err.Error()})
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidQueueMode.Error())
+		}
+		settings.QueueMode = &mode
+	}
+
+	if req.EnqueueRateWindowSec != nil {
+		window := time.Duration(*req.EnqueueRateWindowSec) * time.Second
+		settings.EnqueueRateWindow = &window
+	}
+
+	if req.SleepAt != nil {
+		var sleepAt time.Time
+		if *req.SleepAt != "" {
+			at, err := entity.NextSleepAt(*req.SleepAt, h.clock.Now().UTC())
+			if err != nil {
+				logger.Debugj(map[string]interface{}{"message": "failed to parse sleep at", "error": err.Error()})
+				return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidSessionSettings.Error())
+			}
+			sleepAt = at
+		}
+		settings.SleepAt = &sleepAt
+	}
+
+	if req.ScheduledStartAt != nil {
+		var scheduledStartAt time.Time
+		if *req.ScheduledStartAt != "" {
+			at, err := entity.NewScheduledStartAt(*req.ScheduledStartAt, h.clock.Now().UTC())
+			if err != nil {
+				logger.Debugj(map[string]interface{}{"message": "failed to parse scheduled start at", "error": err.Error()})
+				return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidSessionSettings.Error())
+			}
+			scheduledStartAt = at
+		}
+		settings.ScheduledStartAt = &scheduledStartAt
+	}
+
+	ctx := c.Request().Context()
+	sessionID := c.Param("id")
+
+	if err := h.uc.UpdateSettings(ctx, sessionID, settings); err != nil {
+		return h.settingsErrorToHTTPError(err)
+	}
+
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *SessionHandler) settingsErrorToHTTPError(err error) error {
+	logger := log.New()
+	switch {
+	case errors.Is(err, entity.ErrInvalidSessionSettings):
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidSessionSettings.Error())
+	case errors.Is(err, entity.ErrUserIsNotSessionCreator):
+		return echo.NewHTTPError(http.StatusForbidden, entity.ErrUserIsNotSessionCreator.Error())
+	case errors.Is(err, entity.ErrSessionNotFound):
+		logger.Debug(err)
+		return echo.NewHTTPError(http.StatusNotFound, entity.ErrSessionNotFound.Error())
+	}
+	logger.Errorj(map[string]interface{}{"message": "failed to update session settings", "error": err.Error()})
+	return echo.NewHTTPError(http.StatusInternalServerError)
+}
+
 // NextTrack は PUT /sessions/:id/next に対応するハンドラーです。
 func (h *SessionHandler) NextTrack(c echo.Context) error {
 	logger := log.New()
@@ -130,6 +441,179 @@ func (h *SessionHandler) NextTrack(c echo.Context) error {
 	return c.NoContent(http.StatusAccepted)
 }
 
+// PrevTrack は PUT /sessions/:id/prev に対応するハンドラーです。
+func (h *SessionHandler) PrevTrack(c echo.Context) error {
+	logger := log.New()
+
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	if err := h.stateUC.PrevTrack(ctx, id); err != nil {
+		switch {
+		case errors.Is(err, entity.ErrSessionNotAllowToControlOthers):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrSessionNotAllowToControlOthers.Error())
+		case errors.Is(err, entity.ErrChangeSessionStateNotPermit):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrChangeSessionStateNotPermit.Error())
+		case errors.Is(err, entity.ErrSessionPlayingDifferentTrack):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusConflict, entity.ErrSessionPlayingDifferentTrack.Error())
+		case errors.Is(err, entity.ErrSessionNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusNotFound, entity.ErrSessionNotFound.Error())
+		case errors.Is(err, entity.ErrActiveDeviceNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusForbidden, entity.ErrActiveDeviceNotFound.Error())
+		}
+		logger.Errorj(map[string]interface{}{"message": "failed to move to prev track", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// JumpToTrack は PUT /sessions/:id/head に対応するハンドラーです。
+func (h *SessionHandler) JumpToTrack(c echo.Context) error {
+	logger := log.New()
+	type reqJSON struct {
+		Head *int `json:"head"`
+	}
+	req := new(reqJSON)
+	if err := c.Bind(req); err != nil {
+		logger.Debugj(map[string]interface{}{"message": "failed to bind", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid head")
+	}
+	if req.Head == nil || *req.Head < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid head")
+	}
+
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	if err := h.stateUC.JumpToTrack(ctx, id, *req.Head); err != nil {
+		switch {
+		case errors.Is(err, entity.ErrSessionNotAllowToControlOthers):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrSessionNotAllowToControlOthers.Error())
+		case errors.Is(err, entity.ErrChangeSessionStateNotPermit):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrChangeSessionStateNotPermit.Error())
+		case errors.Is(err, entity.ErrQueueTrackNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusNotFound, entity.ErrQueueTrackNotFound.Error())
+		case errors.Is(err, entity.ErrSessionNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusNotFound, entity.ErrSessionNotFound.Error())
+		case errors.Is(err, entity.ErrActiveDeviceNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusForbidden, entity.ErrActiveDeviceNotFound.Error())
+		}
+		logger.Errorj(map[string]interface{}{"message": "failed to jump to track", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// Seek は PUT /sessions/:id/seek に対応するハンドラーです。
+func (h *SessionHandler) Seek(c echo.Context) error {
+	logger := log.New()
+	type reqJSON struct {
+		PositionMs *int64 `json:"position_ms"`
+	}
+	req := new(reqJSON)
+	if err := c.Bind(req); err != nil {
+		logger.Debugj(map[string]interface{}{"message": "failed to bind", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid position")
+	}
+	if req.PositionMs == nil || *req.PositionMs < 0 {
+		return echo.NewHTTPError(http.StatusBadRequest, "invalid position")
+	}
+
+	ctx := c.Request().Context()
+	sessionID := c.Param("id")
+	if err := h.stateUC.Seek(ctx, sessionID, time.Duration(*req.PositionMs)*time.Millisecond); err != nil {
+		switch {
+		case errors.Is(err, entity.ErrInvalidSeekPosition):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidSeekPosition.Error())
+		case errors.Is(err, entity.ErrChangeSessionStateNotPermit):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrChangeSessionStateNotPermit.Error())
+		case errors.Is(err, entity.ErrSessionNotAllowToControlOthers):
+			return echo.NewHTTPError(http.StatusBadRequest, entity.ErrSessionNotAllowToControlOthers.Error())
+		case errors.Is(err, entity.ErrSessionPlayingDifferentTrack):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusConflict, entity.ErrSessionPlayingDifferentTrack.Error())
+		case errors.Is(err, entity.ErrSessionNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusNotFound, entity.ErrSessionNotFound.Error())
+		case errors.Is(err, entity.ErrActiveDeviceNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusForbidden, entity.ErrActiveDeviceNotFound.Error())
+		}
+		logger.Errorj(map[string]interface{}{"message": "failed to seek", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusInternalServerError)
+	}
+	return c.NoContent(http.StatusAccepted)
+}
+
+// GetVolume は GET /sessions/:id/volume に対応するハンドラーです。
+func (h *SessionHandler) GetVolume(c echo.Context) error {
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	volume, session, err := h.stateUC.GetVolume(ctx, id)
+	if err != nil {
+		return h.volumeErrorToHTTPError(err, "failed to get volume")
+	}
+	return c.JSON(http.StatusOK, &volumeRes{
+		Volume:    volume,
+		MaxVolume: session.MaxVolume,
+	})
+}
+
+// SetVolume は PUT /sessions/:id/volume に対応するハンドラーです。
+func (h *SessionHandler) SetVolume(c echo.Context) error {
+	logger := log.New()
+	type reqJSON struct {
+		Volume *int `json:"volume"`
+	}
+	req := new(reqJSON)
+	if err := c.Bind(req); err != nil {
+		logger.Debugj(map[string]interface{}{"message": "failed to bind", "error": err.Error()})
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidVolume.Error())
+	}
+	if req.Volume == nil {
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidVolume.Error())
+	}
+
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	volume, session, err := h.stateUC.SetVolume(ctx, id, *req.Volume)
+	if err != nil {
+		return h.volumeErrorToHTTPError(err, "failed to set volume")
+	}
+	return c.JSON(http.StatusOK, &volumeRes{
+		Volume:    volume,
+		MaxVolume: session.MaxVolume,
+	})
+}
+
+func (h *SessionHandler) volumeErrorToHTTPError(err error, message string) error {
+	logger := log.New()
+	switch {
+	case errors.Is(err, entity.ErrInvalidVolume):
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrInvalidVolume.Error())
+	case errors.Is(err, entity.ErrSessionNotAllowToControlOthers):
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrSessionNotAllowToControlOthers.Error())
+	case errors.Is(err, entity.ErrChangeSessionStateNotPermit):
+		return echo.NewHTTPError(http.StatusBadRequest, entity.ErrChangeSessionStateNotPermit.Error())
+	case errors.Is(err, entity.ErrSessionNotFound):
+		logger.Debug(err)
+		return echo.NewHTTPError(http.StatusNotFound, entity.ErrSessionNotFound.Error())
+	case errors.Is(err, entity.ErrActiveDeviceNotFound):
+		logger.Debug(err)
+		return echo.NewHTTPError(http.StatusForbidden, entity.ErrActiveDeviceNotFound.Error())
+	}
+	logger.Errorj(map[string]interface{}{"message": message, "error": err.Error()})
+	return echo.NewHTTPError(http.StatusInternalServerError)
+}
+
 // State は PUT /sessions/:id/state に対応するハンドラーです。
 func (h *SessionHandler) State(c echo.Context) error {
 	logger := log.New()
@@ -217,6 +701,9 @@ func (h *SessionHandler) SetDevice(c echo.Context) error {
 		case errors.Is(err, entity.ErrUserIsNotSessionCreator):
 			logger.Debug(err)
 			return echo.NewHTTPError(http.StatusForbidden, entity.ErrUserIsNotSessionCreator.Error())
+		case errors.Is(err, entity.ErrActiveDeviceNotFound):
+			logger.Debug(err)
+			return echo.NewHTTPError(http.StatusForbidden, entity.ErrActiveDeviceNotFound.Error())
 
 		}
 		logger.Errorj(map[string]interface{}{"message": "failed to set device", "error": err.Error(), "deviceID": req.DeviceID})
@@ -254,9 +741,20 @@ func (h *SessionHandler) toSessionRes(session *entity.SessionWithUser, info *ent
 	}
 
 	return &sessionRes{
-		ID:                     session.ID,
-		Name:                   session.Name,
-		AllowToControlByOthers: session.AllowToControlByOthers,
+		ID:                      session.ID,
+		Name:                    session.Name,
+		AllowToControlByOthers:  session.AllowToControlByOthers,
+		MaxPendingTracksPerUser: session.MaxPendingTracksPerUser,
+		EnqueueRateLimit:        session.EnqueueRateLimit,
+		EnqueueRateWindowSec:    int64(session.EnqueueRateWindow.Seconds()),
+		RejectDuplicateTracks:   session.RejectDuplicateTracks,
+		RadioMode:               session.RadioMode,
+		MaxVolume:               session.MaxVolume,
+		DeviceFallback:          session.DeviceFallback,
+		RepeatQueue:             session.RepeatQueue,
+		SleepAfterTracks:        session.SleepAfterTracks,
+		SleepAt:                 toNullableTimeJSON(session.SleepAt),
+		ScheduledStartAt:        toNullableTimeJSON(session.ScheduledStartAt),
 		Creator: creatorJSON{
 			ID:          session.Creator.ID,
 			DisplayName: session.Creator.DisplayName,
@@ -267,18 +765,100 @@ func (h *SessionHandler) toSessionRes(session *entity.SessionWithUser, info *ent
 		},
 		Queue: queueJSON{
 			Head:   session.QueueHead,
-			Tracks: toTrackJSON(tracks),
+			Mode:   session.QueueMode.String(),
+			Tracks: toQueueTrackJSON(session.Session, tracks),
 		},
 	}
 }
 
+// toQueueTrackJSON はキューの曲の情報に曲を追加したユーザの情報を付与します。
+// ラジオモードで自動で追加された曲にはユーザの情報の代わりにその旨を付与します。
+// 投票モードのときは曲のスコアも付与します。
+func toQueueTrackJSON(session *entity.Session, tracks []*entity.Track) []*trackJSON {
+	trackJSONs := toTrackJSON(tracks)
+	if len(session.QueueTracks) != len(trackJSONs) {
+		return trackJSONs
+	}
+
+	for i, qt := range session.QueueTracks {
+		if trackJSONs[i].URI == "" {
+			trackJSONs[i].URI = qt.URI
+		}
+		if session.QueueMode == entity.QueueModeVote {
+			score := qt.Score
+			trackJSONs[i].Score = &score
+		}
+		if qt.AddedBySystem {
+			trackJSONs[i].AddedBySystem = true
+			continue
+		}
+		if qt.AddedByUserID == "" && qt.AddedByNickname == "" {
+			continue
+		}
+		trackJSONs[i].AddedBy = &addedByJSON{
+			UserID:   qt.AddedByUserID,
+			Nickname: qt.AddedByNickname,
+		}
+	}
+	return trackJSONs
+}
+
+// toPlayedTrackJSON は再生履歴の曲の情報に再生された日時と終了した理由を付与します。
+func toPlayedTrackJSON(playedTracks []*entity.PlayedTrack, tracks []*entity.Track) []*playedTrackJSON {
+	trackJSONs := toTrackJSON(tracks)
+	if len(playedTracks) != len(trackJSONs) {
+		return []*playedTrackJSON{}
+	}
+
+	playedTrackJSONs := make([]*playedTrackJSON, len(playedTracks))
+	for i, pt := range playedTracks {
+		if trackJSONs[i].URI == "" {
+			trackJSONs[i].URI = pt.URI
+		}
+		playedTrackJSONs[i] = &playedTrackJSON{
+			trackJSON: *trackJSONs[i],
+			StartedAt: pt.StartedAt,
+			EndedAt:   pt.EndedAt,
+			EndReason: pt.EndReason.String(),
+		}
+	}
+	return playedTrackJSONs
+}
+
+type historyRes struct {
+	Tracks []*playedTrackJSON `json:"tracks"`
+}
+
+type playedTrackJSON struct {
+	trackJSON
+	StartedAt time.Time `json:"started_at"`
+	EndedAt   time.Time `json:"ended_at"`
+	EndReason string    `json:"end_reason"`
+}
+
 type sessionRes struct {
-	ID                     string       `json:"id"`
-	Name                   string       `json:"name"`
-	AllowToControlByOthers bool         `json:"allow_to_control_by_others"`
-	Creator                creatorJSON  `json:"creator"`
-	Playback               playbackJSON `json:"playback"`
-	Queue                  queueJSON    `json:"queue"`
+	ID                      string       `json:"id"`
+	Name                    string       `json:"name"`
+	AllowToControlByOthers  bool         `json:"allow_to_control_by_others"`
+	MaxPendingTracksPerUser int          `json:"max_pending_tracks_per_user"`
+	EnqueueRateLimit        int          `json:"enqueue_rate_limit"`
+	EnqueueRateWindowSec    int64        `json:"enqueue_rate_window_sec"`
+	RejectDuplicateTracks   bool         `json:"reject_duplicate_tracks"`
+	RadioMode               bool         `json:"radio_mode"`
+	MaxVolume               int          `json:"max_volume"`
+	DeviceFallback          bool         `json:"device_fallback"`
+	RepeatQueue             bool         `json:"repeat_queue"`
+	SleepAfterTracks        int          `json:"sleep_after_tracks"`
+	SleepAt                 *time.Time   `json:"sleep_at"`
+	ScheduledStartAt        *time.Time   `json:"scheduled_start_at"`
+	Creator                 creatorJSON  `json:"creator"`
+	Playback                playbackJSON `json:"playback"`
+	Queue                   queueJSON    `json:"queue"`
+}
+
+type volumeRes struct {
+	Volume    int `json:"volume"`
+	MaxVolume int `json:"max_volume"`
 }
 
 type creatorJSON struct {
@@ -299,5 +879,14 @@ type stateJSON struct {
 
 type queueJSON struct {
 	Head   int          `json:"head"`
+	Mode   string       `json:"mode"`
 	Tracks []*trackJSON `json:"tracks"`
 }
+
+// toNullableTimeJSON は日時が設定されていない (ゼロ値の) ときはnilを返します。
+func toNullableTimeJSON(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
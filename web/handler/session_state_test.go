@@ -1,7 +1,7 @@
 package handler
 
 import (
-	"context"
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
@@ -16,7 +16,6 @@ import (
 	"github.com/camphor-/relaym-server/usecase"
 	"github.com/golang/mock/gomock"
 	"github.com/google/go-cmp/cmp"
-	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/labstack/echo/v4"
 )
 
@@ -101,12 +100,13 @@ func TestSessionHandler_State(t *testing.T) {
 					ProgressWhenPaused:     10 * time.Second,
 				}, nil)
 				m.EXPECT().Update(gomock.Any(), &entity.Session{
-					ID:        "sessionID",
-					Name:      "session_name",
-					CreatorID: "creator_id",
-					QueueHead: 0,
-					DeviceID:  "device_id",
-					StateType: entity.Play,
+					ID:            "sessionID",
+					Name:          "session_name",
+					CreatorID:     "creator_id",
+					QueueHead:     0,
+					DeviceID:      "device_id",
+					StateType:     entity.Play,
+					HeadStartedAt: fixedNow,
 					QueueTracks: []*entity.QueueTrack{
 						{Index: 0, URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF"},
 						{Index: 1, URI: "spotify:track:49BRCNV7E94s7Q2FUhhT3w"},
@@ -191,12 +191,13 @@ func TestSessionHandler_State_PLAY(t *testing.T) {
 					ProgressWhenPaused:     10 * time.Second,
 				}, nil)
 				m.EXPECT().Update(gomock.Any(), &entity.Session{
-					ID:        "sessionID",
-					Name:      "session_name",
-					CreatorID: "creator_id",
-					QueueHead: 0,
-					DeviceID:  "device_id",
-					StateType: entity.Play,
+					ID:            "sessionID",
+					Name:          "session_name",
+					CreatorID:     "creator_id",
+					QueueHead:     0,
+					DeviceID:      "device_id",
+					StateType:     entity.Play,
+					HeadStartedAt: fixedNow,
 					QueueTracks: []*entity.QueueTrack{
 						{Index: 0, URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF"},
 						{Index: 1, URI: "spotify:track:49BRCNV7E94s7Q2FUhhT3w"},
@@ -283,6 +284,7 @@ func TestSessionHandler_State_PLAY(t *testing.T) {
 						{Index: 2, URI: "spotify:track:4"},
 					},
 					AllowToControlByOthers: true,
+					HeadStartedAt:          fixedNow,
 				}).Return(nil)
 			},
 			wantErr:  false,
@@ -657,35 +659,20 @@ func TestSessionHandler_State_STOP(t *testing.T) {
 					},
 					AllowToControlByOthers: true,
 				}, nil)
-				m.EXPECT().Update(gomock.Any(), gomock.Any()).DoAndReturn(
-					func(ctx context.Context, sess *entity.Session) error {
-						twoDaysAfter := time.Now().AddDate(0, 0, 2).UTC()
-						fourDaysAfter := time.Now().AddDate(0, 0, 4).UTC()
-						sessionMustBeCall := &entity.Session{
-							ID:        "sessionID",
-							Name:      "session_name",
-							CreatorID: "creator_id",
-							QueueHead: 0,
-							DeviceID:  "device_id",
-							StateType: "STOP",
-							QueueTracks: []*entity.QueueTrack{
-								{Index: 0, URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF"},
-								{Index: 1, URI: "spotify:track:49BRCNV7E94s7Q2FUhhT3w"},
-							},
-							AllowToControlByOthers: true,
-						}
-
-						if !fourDaysAfter.After(sess.ExpiredAt) || !twoDaysAfter.Before(sess.ExpiredAt) {
-							t.Errorf("Update(): unexpected ExpiredAt: got: %v", sess.ExpiredAt)
-						}
-
-						opts := []cmp.Option{cmpopts.IgnoreFields(entity.Session{}, "ExpiredAt")}
-						if !cmp.Equal(sessionMustBeCall, sess, opts...) {
-							t.Errorf("Update(): unexpected args: diff: %v", cmp.Diff(sessionMustBeCall, sess))
-						}
-
-						return nil
-					})
+				m.EXPECT().Update(gomock.Any(), &entity.Session{
+					ID:        "sessionID",
+					Name:      "session_name",
+					CreatorID: "creator_id",
+					QueueHead: 0,
+					DeviceID:  "device_id",
+					StateType: "STOP",
+					QueueTracks: []*entity.QueueTrack{
+						{Index: 0, URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF"},
+						{Index: 1, URI: "spotify:track:49BRCNV7E94s7Q2FUhhT3w"},
+					},
+					AllowToControlByOthers: true,
+					ExpiredAt:              fixedNow.AddDate(0, 0, 3),
+				}).Return(nil)
 			},
 			wantErr:  false,
 			wantCode: http.StatusAccepted,
@@ -989,6 +976,693 @@ func TestSessionHandler_State_ARCHIVED(t *testing.T) {
 }
 
 // モックの準備
+func TestSessionHandler_PrevTrack(t *testing.T) {
+	sessionWithHead := func(st entity.StateType, head int, progressWhenPaused time.Duration, allowToControlByOthers bool) *entity.Session {
+		return &entity.Session{
+			ID:        "sessionID",
+			Name:      "session_name",
+			CreatorID: "creator_id",
+			QueueHead: head,
+			DeviceID:  "device_id",
+			StateType: st,
+			QueueTracks: []*entity.QueueTrack{
+				{Index: 0, URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF"},
+				{Index: 1, URI: "spotify:track:49BRCNV7E94s7Q2FUhhT3w"},
+				{Index: 2, URI: "spotify:track:3BhoyQ6D2yLt0pCwBSJ2ZF"},
+			},
+			AllowToControlByOthers: allowToControlByOthers,
+			ProgressWhenPaused:     progressWhenPaused,
+		}
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		userID                   string
+		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		wantErr                  bool
+		wantCode                 int
+	}{
+		{
+			name:      "PAUSEで曲の最初のほうのときは前の曲に戻ってSpotifyのキューを積み直し、202",
+			sessionID: "sessionID",
+			userID:    "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().DeleteAllTracksInQueue(gomock.Any(), "device_id", "spotify:track:5uQ0vKy2973Y9IUCd1wMEF").Return(nil)
+				m.EXPECT().PlayWithTracksAndPosition(gomock.Any(), "device_id", []string{"spotify:track:5uQ0vKy2973Y9IUCd1wMEF"}, time.Duration(0)).Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:49BRCNV7E94s7Q2FUhhT3w", "device_id").Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:3BhoyQ6D2yLt0pCwBSJ2ZF", "device_id").Return(nil)
+				m.EXPECT().Pause(gomock.Any(), "device_id").Return(nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{SessionID: "sessionID", Msg: entity.NewEventPrevTrack(0)})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 1, time.Second, true), nil)
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 1, time.Second, true), nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Pause, 0, 0, true)).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:      "PAUSEで曲の途中のときは現在の曲を最初から再生し直し、202",
+			sessionID: "sessionID",
+			userID:    "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().DeleteAllTracksInQueue(gomock.Any(), "device_id", "spotify:track:49BRCNV7E94s7Q2FUhhT3w").Return(nil)
+				m.EXPECT().PlayWithTracksAndPosition(gomock.Any(), "device_id", []string{"spotify:track:49BRCNV7E94s7Q2FUhhT3w"}, time.Duration(0)).Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:3BhoyQ6D2yLt0pCwBSJ2ZF", "device_id").Return(nil)
+				m.EXPECT().Pause(gomock.Any(), "device_id").Return(nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{SessionID: "sessionID", Msg: entity.NewEventSeek(0)})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 1, time.Minute, true), nil)
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 1, time.Minute, true), nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Pause, 1, 0, true)).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:                "作成者以外のリクエストで、他人による操作が許可されていないときは400",
+			sessionID:           "sessionID",
+			userID:              "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 1, time.Second, false), nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:                "STOPのときは400",
+			sessionID:           "sessionID",
+			userID:              "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Stop, 1, 0, true), nil)
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Stop, 1, 0, true), nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:                "存在しないセッションのときは404",
+			sessionID:           "sessionID",
+			userID:              "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(nil, entity.ErrSessionNotFound)
+			},
+			wantErr:  true,
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// httptestの準備
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/sessions/:id/prev")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.sessionID)
+			c = setToContext(c, tt.userID, nil)
+
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			h := newSessionStateHandlerForTest(t, ctrl, tt.prepareMockPlayerFn, tt.prepareMockPusherFn,
+				func(m *mock_repository.MockUser) {}, tt.prepareMockSessionRepoFn)
+
+			err := h.PrevTrack(c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("PrevTrack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// ステータスコードのチェック
+			if er, ok := err.(*echo.HTTPError); ok && er.Code != tt.wantCode {
+				t.Errorf("PrevTrack() code = %d, want = %d", er.Code, tt.wantCode)
+			}
+			if err == nil && rec.Code != tt.wantCode {
+				t.Errorf("PrevTrack() code = %d, want = %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSessionHandler_JumpToTrack(t *testing.T) {
+	sessionWithHead := func(st entity.StateType, head int, progressWhenPaused time.Duration) *entity.Session {
+		return &entity.Session{
+			ID:        "sessionID",
+			Name:      "session_name",
+			CreatorID: "creator_id",
+			QueueHead: head,
+			DeviceID:  "device_id",
+			StateType: st,
+			QueueTracks: []*entity.QueueTrack{
+				{Index: 0, URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF"},
+				{Index: 1, URI: "spotify:track:49BRCNV7E94s7Q2FUhhT3w"},
+				{Index: 2, URI: "spotify:track:3BhoyQ6D2yLt0pCwBSJ2ZF"},
+				{Index: 3, URI: "spotify:track:6zx4FNnodBEh1Ebzo2PXxw"},
+			},
+			AllowToControlByOthers: true,
+			ProgressWhenPaused:     progressWhenPaused,
+		}
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		body                     string
+		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		wantErr                  bool
+		wantCode                 int
+	}{
+		{
+			name:      "PAUSEのときは指定した曲から再生し直してSpotifyのキューを積み直し、202",
+			sessionID: "sessionID",
+			body:      `{"head": 1}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().DeleteAllTracksInQueue(gomock.Any(), "device_id", "spotify:track:49BRCNV7E94s7Q2FUhhT3w").Return(nil)
+				m.EXPECT().PlayWithTracksAndPosition(gomock.Any(), "device_id", []string{"spotify:track:49BRCNV7E94s7Q2FUhhT3w"}, time.Duration(0)).Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:3BhoyQ6D2yLt0pCwBSJ2ZF", "device_id").Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:6zx4FNnodBEh1Ebzo2PXxw", "device_id").Return(nil)
+				m.EXPECT().Pause(gomock.Any(), "device_id").Return(nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{SessionID: "sessionID", Msg: entity.NewEventNextTrack(1)})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 3, time.Minute), nil)
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 3, time.Minute), nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Pause, 1, 0)).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:                "STOPのときはheadを移動するだけで再生しない",
+			sessionID:           "sessionID",
+			body:                `{"head": 2}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{SessionID: "sessionID", Msg: entity.NewEventNextTrack(2)})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Stop, 0, 0), nil)
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Stop, 0, 0), nil)
+				m.EXPECT().Update(gomock.Any(), sessionWithHead(entity.Stop, 2, 0)).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:                     "headが指定されていないときは400",
+			sessionID:                "sessionID",
+			body:                     `{}`,
+			prepareMockPlayerFn:      func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			wantErr:                  true,
+			wantCode:                 http.StatusBadRequest,
+		},
+		{
+			name:                "存在しないindexのときは404",
+			sessionID:           "sessionID",
+			body:                `{"head": 4}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 0, 0), nil)
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(sessionWithHead(entity.Pause, 0, 0), nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// httptestの準備
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/sessions/:id/head")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.sessionID)
+			c = setToContext(c, "userID", nil)
+
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			h := newSessionStateHandlerForTest(t, ctrl, tt.prepareMockPlayerFn, tt.prepareMockPusherFn,
+				func(m *mock_repository.MockUser) {}, tt.prepareMockSessionRepoFn)
+
+			err := h.JumpToTrack(c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("JumpToTrack() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// ステータスコードのチェック
+			if er, ok := err.(*echo.HTTPError); (ok && er.Code != tt.wantCode) || (!ok && rec.Code != tt.wantCode) {
+				t.Errorf("JumpToTrack() code = %d, want = %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestSessionHandler_GetVolume(t *testing.T) {
+	sess := &entity.Session{
+		ID:        "sessionID",
+		Name:      "session_name",
+		CreatorID: "creator_id",
+		DeviceID:  "device_id",
+		StateType: entity.Play,
+		QueueTracks: []*entity.QueueTrack{
+			{Index: 0, URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF"},
+		},
+		MaxVolume: 80,
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		want                     *volumeRes
+		wantErr                  bool
+		wantCode                 int
+	}{
+		{
+			name:      "再生しているデバイスの音量と音量の上限を取得できる",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(&entity.CurrentPlayingInfo{
+					Playing: true,
+					Device:  &entity.Device{ID: "device_id", Volume: 60},
+				}, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sess, nil)
+			},
+			want:     &volumeRes{Volume: 60, MaxVolume: 80},
+			wantErr:  false,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "アクティブなデバイスが存在しないときは403",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(nil, entity.ErrActiveDeviceNotFound)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sess, nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:                "存在しないセッションのときは404",
+			sessionID:           "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(nil, entity.ErrSessionNotFound)
+			},
+			wantErr:  true,
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// httptestの準備
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/sessions/:id/volume")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.sessionID)
+
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			h := newSessionStateHandlerForTest(t, ctrl, tt.prepareMockPlayerFn, func(m *mock_event.MockPusher) {},
+				func(m *mock_repository.MockUser) {}, tt.prepareMockSessionRepoFn)
+
+			err := h.GetVolume(c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetVolume() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// ステータスコードのチェック
+			if er, ok := err.(*echo.HTTPError); (ok && er.Code != tt.wantCode) || (!ok && rec.Code != tt.wantCode) {
+				t.Errorf("GetVolume() code = %d, want = %d", rec.Code, tt.wantCode)
+			}
+
+			if !tt.wantErr {
+				got := &volumeRes{}
+				if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+					t.Fatal(err)
+				}
+				if !cmp.Equal(got, tt.want) {
+					t.Errorf("GetVolume() diff = %v", cmp.Diff(tt.want, got))
+				}
+			}
+		})
+	}
+}
+
+func TestSessionHandler_SetVolume(t *testing.T) {
+	sessionWithState := func(st entity.StateType, allowToControlByOthers bool) *entity.Session {
+		return &entity.Session{
+			ID:        "sessionID",
+			Name:      "session_name",
+			CreatorID: "creator_id",
+			DeviceID:  "device_id",
+			StateType: st,
+			QueueTracks: []*entity.QueueTrack{
+				{Index: 0, URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF"},
+			},
+			AllowToControlByOthers: allowToControlByOthers,
+			MaxVolume:              80,
+		}
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		body                     string
+		userID                   string
+		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		want                     *volumeRes
+		wantErr                  bool
+		wantCode                 int
+	}{
+		{
+			name:      "音量を変更してVOLUMEイベントを送る",
+			sessionID: "sessionID",
+			body:      `{"volume": 50}`,
+			userID:    "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().SetVolume(gomock.Any(), 50, "device_id").Return(nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{SessionID: "sessionID", Msg: entity.NewEventVolume(50)})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Play, true), nil)
+			},
+			want:     &volumeRes{Volume: 50, MaxVolume: 80},
+			wantErr:  false,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "上限を超える音量のときは上限の音量に変更される",
+			sessionID: "sessionID",
+			body:      `{"volume": 100}`,
+			userID:    "creator_id",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().SetVolume(gomock.Any(), 80, "device_id").Return(nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{SessionID: "sessionID", Msg: entity.NewEventVolume(80)})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Pause, false), nil)
+			},
+			want:     &volumeRes{Volume: 80, MaxVolume: 80},
+			wantErr:  false,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:                     "volumeが指定されていないときは400",
+			sessionID:                "sessionID",
+			body:                     `{}`,
+			userID:                   "userID",
+			prepareMockPlayerFn:      func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			wantErr:                  true,
+			wantCode:                 http.StatusBadRequest,
+		},
+		{
+			name:                "100%を超える音量のときは400",
+			sessionID:           "sessionID",
+			body:                `{"volume": 101}`,
+			userID:              "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Play, true), nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:                "作成者以外のリクエストで、他人による操作が許可されていないときは400",
+			sessionID:           "sessionID",
+			body:                `{"volume": 50}`,
+			userID:              "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Play, false), nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:                "STOPのときは400",
+			sessionID:           "sessionID",
+			body:                `{"volume": 50}`,
+			userID:              "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sessionWithState(entity.Stop, true), nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// httptestの準備
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/sessions/:id/volume")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.sessionID)
+			c = setToContext(c, tt.userID, nil)
+
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			h := newSessionStateHandlerForTest(t, ctrl, tt.prepareMockPlayerFn, tt.prepareMockPusherFn,
+				func(m *mock_repository.MockUser) {}, tt.prepareMockSessionRepoFn)
+
+			err := h.SetVolume(c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("SetVolume() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// ステータスコードのチェック
+			if er, ok := err.(*echo.HTTPError); (ok && er.Code != tt.wantCode) || (!ok && rec.Code != tt.wantCode) {
+				t.Errorf("SetVolume() code = %d, want = %d", rec.Code, tt.wantCode)
+			}
+
+			if !tt.wantErr {
+				got := &volumeRes{}
+				if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+					t.Fatal(err)
+				}
+				if !cmp.Equal(got, tt.want) {
+					t.Errorf("SetVolume() diff = %v", cmp.Diff(tt.want, got))
+				}
+			}
+		})
+	}
+}
+
+func TestSessionHandler_Seek(t *testing.T) {
+	pausedSession := func() *entity.Session {
+		return &entity.Session{
+			ID:        "sessionID",
+			Name:      "session_name",
+			CreatorID: "creator_id",
+			QueueHead: 0,
+			DeviceID:  "device_id",
+			StateType: entity.Pause,
+			QueueTracks: []*entity.QueueTrack{
+				{Index: 0, URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF"},
+				{Index: 1, URI: "spotify:track:49BRCNV7E94s7Q2FUhhT3w"},
+			},
+			AllowToControlByOthers: true,
+			ProgressWhenPaused:     10 * time.Second,
+		}
+	}
+	pausedPlayingInfo := &entity.CurrentPlayingInfo{
+		Playing:  false,
+		Progress: 10 * time.Second,
+		Track:    &entity.Track{URI: "spotify:track:5uQ0vKy2973Y9IUCd1wMEF", Duration: 3 * time.Minute},
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		body                     string
+		userID                   string
+		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		wantErr                  bool
+		wantCode                 int
+	}{
+		{
+			name:                     "position_msが指定されていないときは400",
+			sessionID:                "sessionID",
+			body:                     `{}`,
+			userID:                   "userID",
+			prepareMockPlayerFn:      func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			wantErr:                  true,
+			wantCode:                 http.StatusBadRequest,
+		},
+		{
+			name:                     "position_msが負のときは400",
+			sessionID:                "sessionID",
+			body:                     `{"position_ms": -1}`,
+			userID:                   "userID",
+			prepareMockPlayerFn:      func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			wantErr:                  true,
+			wantCode:                 http.StatusBadRequest,
+		},
+		{
+			name:      "PAUSEのときは再開時の再生位置が変更されて202",
+			sessionID: "sessionID",
+			body:      `{"position_ms": 60000}`,
+			userID:    "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(pausedPlayingInfo, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{SessionID: "sessionID", Msg: entity.NewEventSeek(time.Minute)})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(pausedSession(), nil)
+				want := pausedSession()
+				want.ProgressWhenPaused = time.Minute
+				m.EXPECT().Update(gomock.Any(), want).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusAccepted,
+		},
+		{
+			name:      "曲の長さ以上の再生位置のときは400",
+			sessionID: "sessionID",
+			body:      `{"position_ms": 180000}`,
+			userID:    "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(pausedPlayingInfo, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(pausedSession(), nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:                "STOPのときは400",
+			sessionID:           "sessionID",
+			body:                `{"position_ms": 60000}`,
+			userID:              "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				sess := pausedSession()
+				sess.StateType = entity.Stop
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(sess, nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:                "存在しないセッションのときは404",
+			sessionID:           "sessionID",
+			body:                `{"position_ms": 60000}`,
+			userID:              "userID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(nil, entity.ErrSessionNotFound)
+			},
+			wantErr:  true,
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// httptestの準備
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/sessions/:id/seek")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.sessionID)
+			c = setToContext(c, tt.userID, nil)
+
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			h := newSessionStateHandlerForTest(t, ctrl, tt.prepareMockPlayerFn, tt.prepareMockPusherFn,
+				func(m *mock_repository.MockUser) {}, tt.prepareMockSessionRepoFn)
+
+			err := h.Seek(c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Seek() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// ステータスコードのチェック
+			if er, ok := err.(*echo.HTTPError); ok && er.Code != tt.wantCode {
+				t.Errorf("Seek() code = %d, want = %d", er.Code, tt.wantCode)
+			}
+			if err == nil && rec.Code != tt.wantCode {
+				t.Errorf("Seek() code = %d, want = %d", rec.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
 func newSessionStateHandlerForTest(
 	t *testing.T,
 	ctrl *gomock.Controller,
@@ -1006,9 +1680,9 @@ func newSessionStateHandlerForTest(
 	prepareMockUserRepoFn(mockUserRepo)
 	mockSessionRepo := mock_repository.NewMockSession(ctrl)
 	prepareMockSessionRepoFn(mockSessionRepo)
-	syncCheckTimerManager := entity.NewSyncCheckTimerManager()
-	timerUC := usecase.NewSessionTimerUseCase(mockSessionRepo, mockPlayer, mockPusher, syncCheckTimerManager)
+	clock := entity.NewFakeClock(fixedNow)
+	timerUC := usecase.NewSessionTimerUseCase(mockSessionRepo, mockPlayer, nil, mockPusher, entity.NewPlaybackScheduler(1, clock), nil, clock)
 	uc := usecase.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockPlayer, nil, nil, mockPusher, timerUC)
-	stateUC := usecase.NewSessionStateUseCase(mockSessionRepo, mockPlayer, mockPusher, timerUC)
-	return &SessionHandler{uc: uc, stateUC: stateUC}
+	stateUC := usecase.NewSessionStateUseCase(mockSessionRepo, mockPlayer, nil, mockPusher, timerUC)
+	return &SessionHandler{uc: uc, stateUC: stateUC, clock: clock}
 }
@@ -31,6 +31,8 @@ func TestSessionHandler_SetDevice(t *testing.T) {
 		userID                string
 		sessionID             string
 		body                  string
+		prepareMockPlayerFn   func(m *mock_spotify.MockPlayer)
+		prepareMockPusherFn   func(m *mock_event.MockPusher)
 		prepareMockRepoFn     func(m *mock_repository.MockSession)
 		prepareMockUserRepoFn func(m *mock_repository.MockUser)
 		wantErr               bool
@@ -41,16 +43,20 @@ func TestSessionHandler_SetDevice(t *testing.T) {
 			userID:                "user_id",
 			sessionID:             "sessionID",
 			body:                  `{"device_id": ""}`,
+			prepareMockPlayerFn:   func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn:   func(m *mock_event.MockPusher) {},
 			prepareMockRepoFn:     func(m *mock_repository.MockSession) {},
 			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
 			wantErr:               true,
 			wantCode:              http.StatusBadRequest,
 		},
 		{
-			name:      "セッションが存在しないと404",
-			userID:    "user_id",
-			sessionID: "session_id",
-			body:      `{"device_id": "device_id"}`,
+			name:                "セッションが存在しないと404",
+			userID:              "user_id",
+			sessionID:           "session_id",
+			body:                `{"device_id": "device_id"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
 			prepareMockRepoFn: func(m *mock_repository.MockSession) {
 				m.EXPECT().FindByID(gomock.Any(), "session_id").Return(nil, entity.ErrSessionNotFound)
 			},
@@ -59,16 +65,61 @@ func TestSessionHandler_SetDevice(t *testing.T) {
 			wantCode:              http.StatusNotFound,
 		},
 		{
-			name:      "正しくデバイスをセットできると204",
+			name:                "STOPのときは再生を移さずにデバイスをセットできて204",
+			userID:              "creator_id",
+			sessionID:           "session_id",
+			body:                `{"device_id": "device_id"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "session_id",
+					Msg:       entity.EventDeviceChanged,
+				})
+			},
+			prepareMockRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "session_id").Return(&entity.Session{
+					ID:          "session_id",
+					Name:        "name",
+					CreatorID:   "creator_id",
+					DeviceID:    "",
+					StateType:   "STOP",
+					QueueHead:   0,
+					QueueTracks: nil,
+				}, nil)
+				m.EXPECT().Update(gomock.Any(), &entity.Session{
+					ID:          "session_id",
+					Name:        "name",
+					CreatorID:   "creator_id",
+					DeviceID:    "device_id",
+					StateType:   "STOP",
+					QueueHead:   0,
+					QueueTracks: nil,
+				})
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			wantErr:               false,
+			wantCode:              http.StatusNoContent,
+		},
+		{
+			name:      "PAUSEのときは一時停止したまま再生を新しいデバイスに移して204",
 			userID:    "creator_id",
 			sessionID: "session_id",
 			body:      `{"device_id": "device_id"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().TransferPlayback(gomock.Any(), "device_id", false).Return(nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "session_id",
+					Msg:       entity.EventDeviceChanged,
+				})
+			},
 			prepareMockRepoFn: func(m *mock_repository.MockSession) {
 				m.EXPECT().FindByID(gomock.Any(), "session_id").Return(&entity.Session{
 					ID:          "session_id",
 					Name:        "name",
 					CreatorID:   "creator_id",
-					DeviceID:    "",
+					DeviceID:    "old_device_id",
 					StateType:   "PAUSE",
 					QueueHead:   0,
 					QueueTracks: nil,
@@ -87,6 +138,52 @@ func TestSessionHandler_SetDevice(t *testing.T) {
 			wantErr:               false,
 			wantCode:              http.StatusNoContent,
 		},
+		{
+			name:                "同じデバイスが指定されたときは何もせずに204",
+			userID:              "creator_id",
+			sessionID:           "session_id",
+			body:                `{"device_id": "device_id"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "session_id").Return(&entity.Session{
+					ID:          "session_id",
+					Name:        "name",
+					CreatorID:   "creator_id",
+					DeviceID:    "device_id",
+					StateType:   "PAUSE",
+					QueueHead:   0,
+					QueueTracks: nil,
+				}, nil)
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			wantErr:               false,
+			wantCode:              http.StatusNoContent,
+		},
+		{
+			name:      "再生を移す先のデバイスが見つからないと403",
+			userID:    "creator_id",
+			sessionID: "session_id",
+			body:      `{"device_id": "device_id"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().TransferPlayback(gomock.Any(), "device_id", false).Return(entity.ErrActiveDeviceNotFound)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "session_id").Return(&entity.Session{
+					ID:          "session_id",
+					Name:        "name",
+					CreatorID:   "creator_id",
+					DeviceID:    "old_device_id",
+					StateType:   "PAUSE",
+					QueueHead:   0,
+					QueueTracks: nil,
+				}, nil)
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			wantErr:               true,
+			wantCode:              http.StatusForbidden,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -104,13 +201,7 @@ func TestSessionHandler_SetDevice(t *testing.T) {
 			// モックの準備
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
-			mockRepo := mock_repository.NewMockSession(ctrl)
-			tt.prepareMockRepoFn(mockRepo)
-			mockUserRepo := mock_repository.NewMockUser(ctrl)
-			tt.prepareMockUserRepoFn(mockUserRepo)
-
-			uc := usecase.NewSessionUseCase(mockRepo, mockUserRepo, nil, nil, nil, nil, nil)
-			h := &SessionHandler{uc: uc}
+			h := newSessionStateHandlerForTest(t, ctrl, tt.prepareMockPlayerFn, tt.prepareMockPusherFn, tt.prepareMockUserRepoFn, tt.prepareMockRepoFn)
 
 			err := h.SetDevice(c)
 			if (err != nil) != tt.wantErr {
@@ -142,6 +233,7 @@ func TestSessionHandler_PostSession(t *testing.T) {
 		},
 		Queue: queueJSON{
 			Head:   0,
+			Mode:   "FIFO",
 			Tracks: []*trackJSON{},
 		},
 	}
@@ -190,6 +282,18 @@ func TestSessionHandler_PostSession(t *testing.T) {
 			wantErr:                  true,
 			wantCode:                 http.StatusBadRequest,
 		},
+		{
+			name:                     "過去の日時を予約するとinvalid scheduled_start_atが返る",
+			body:                     `{"name": "go! go! session!", "allow_to_control_by_others": true, "scheduled_start_at": "2020-01-01T18:00:00+09:00"}`,
+			userID:                   "creatorID",
+			prepareMockPlayerFn:      func(m *mock_spotify.MockPlayer) {},
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			prepareMockUserRepoFn:    func(m *mock_repository.MockUser) {},
+			want:                     sessionResponse,
+			wantErr:                  true,
+			wantCode:                 http.StatusBadRequest,
+		},
 	}
 
 	for _, tt := range tests {
@@ -248,6 +352,20 @@ func TestSessionHandler_Enqueue(t *testing.T) {
 		},
 		},
 	}
+	sessionForBulk := &entity.Session{
+		ID:        "sessionForBulkID",
+		Name:      "sessionName",
+		CreatorID: "sessionCreator",
+		DeviceID:  "sessionDeviceID",
+		StateType: "PLAY",
+		QueueHead: 0,
+		QueueTracks: []*entity.QueueTrack{{
+			Index:     0,
+			URI:       "spotify:track:track_uri",
+			SessionID: "sessionForBulkID",
+		},
+		},
+	}
 	sessionHadManyTracks := &entity.Session{
 		ID:        "sessionHadManyTracksID",
 		Name:      "sessionName",
@@ -273,11 +391,112 @@ func TestSessionHandler_Enqueue(t *testing.T) {
 			},
 		},
 	}
+	sessionRejectDuplicate := &entity.Session{
+		ID:                    "sessionRejectDuplicateID",
+		Name:                  "sessionName",
+		CreatorID:             "sessionCreator",
+		DeviceID:              "sessionDeviceID",
+		StateType:             "PLAY",
+		QueueHead:             0,
+		RejectDuplicateTracks: true,
+		QueueTracks: []*entity.QueueTrack{
+			{
+				Index:     0,
+				URI:       "spotify:track:track_uri1",
+				SessionID: "sessionRejectDuplicateID",
+			},
+			{
+				Index:     1,
+				URI:       "spotify:track:track_uri2",
+				SessionID: "sessionRejectDuplicateID",
+			},
+		},
+	}
+	sessionLimitedPending := &entity.Session{
+		ID:                      "sessionLimitedID",
+		Name:                    "sessionName",
+		CreatorID:               "sessionCreator",
+		DeviceID:                "sessionDeviceID",
+		StateType:               "PLAY",
+		QueueHead:               0,
+		MaxPendingTracksPerUser: 1,
+		QueueTracks: []*entity.QueueTrack{
+			{
+				Index:           0,
+				URI:             "spotify:track:track_uri1",
+				SessionID:       "sessionLimitedID",
+				AddedByNickname: "guest",
+			},
+			{
+				Index:           1,
+				URI:             "spotify:track:track_uri2",
+				SessionID:       "sessionLimitedID",
+				AddedByNickname: "guest",
+			},
+		},
+	}
+	sessionLimitedRate := &entity.Session{
+		ID:                "sessionLimitedID",
+		Name:              "sessionName",
+		CreatorID:         "sessionCreator",
+		DeviceID:          "sessionDeviceID",
+		StateType:         "PLAY",
+		QueueHead:         0,
+		EnqueueRateLimit:  1,
+		EnqueueRateWindow: 10 * time.Minute,
+		QueueTracks: []*entity.QueueTrack{
+			{
+				Index:           0,
+				URI:             "spotify:track:track_uri1",
+				SessionID:       "sessionLimitedID",
+				AddedByNickname: "guest",
+				AddedAt:         fixedNow,
+			},
+		},
+	}
+	// sessionForPlayNext は次に再生する曲を追加するテスト用のセッションを返します。
+	// 追加後に取得し直したセッションを表すため、追加する曲を指定できます。
+	sessionForPlayNext := func(queueMode entity.QueueMode, addedURIs ...string) *entity.Session {
+		sess := &entity.Session{
+			ID:                 "sessionForPlayNextID",
+			Name:               "sessionName",
+			CreatorID:          "sessionCreator",
+			DeviceID:           "sessionDeviceID",
+			StateType:          entity.Pause,
+			QueueHead:          0,
+			QueueMode:          queueMode,
+			ProgressWhenPaused: 10 * time.Second,
+			QueueTracks: []*entity.QueueTrack{
+				{ID: 1, Index: 0, URI: "spotify:track:track_uri1", SessionID: "sessionForPlayNextID"},
+				{ID: 2, Index: 1, URI: "spotify:track:track_uri2", SessionID: "sessionForPlayNextID"},
+				{ID: 3, Index: 2, URI: "spotify:track:track_uri3", SessionID: "sessionForPlayNextID"},
+			},
+		}
+		for _, uri := range addedURIs {
+			sess.QueueTracks = append(sess.QueueTracks, &entity.QueueTrack{
+				ID: int64(len(sess.QueueTracks) + 1), Index: len(sess.QueueTracks), URI: uri, SessionID: "sessionForPlayNextID", AddedByUserID: "sessionCreator", AddedByNickname: "creator",
+			})
+		}
+		return sess
+	}
+	getMeInJP := func(m *mock_spotify.MockUser) {
+		m.EXPECT().GetMe(gomock.Any()).Return(&entity.SpotifyUser{Country: "JP"}, nil)
+	}
+	playableTracks := func(uris ...string) []*entity.Track {
+		tracks := make([]*entity.Track, len(uris))
+		for i, uri := range uris {
+			tracks[i] = &entity.Track{URI: uri, AvailableMarkets: []string{"JP", "US"}}
+		}
+		return tracks
+	}
 	tests := []struct {
 		name                     string
 		sessionID                string
+		userID                   string
 		body                     string
 		prepareMockPlayerFn      func(m *mock_spotify.MockPlayer)
+		prepareMockTrackFn       func(m *mock_spotify.MockTrackClient)
+		prepareMockUserCliFn     func(m *mock_spotify.MockUser)
 		prepareMockPusherFn      func(m *mock_event.MockPusher)
 		prepareMockUserRepoFn    func(m *mock_repository.MockUser)
 		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
@@ -289,18 +508,24 @@ func TestSessionHandler_Enqueue(t *testing.T) {
 			sessionID:           "sessionHadManyTracksID",
 			body:                `{"uri": "spotify:track:valid_uri"}`,
 			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:valid_uri"}).Return(playableTracks("spotify:track:valid_uri"), nil)
+			},
+			prepareMockUserCliFn: getMeInJP,
 			prepareMockPusherFn: func(m *mock_event.MockPusher) {
 				m.EXPECT().Push(&event.PushMessage{
 					SessionID: "sessionHadManyTracksID",
-					Msg:       entity.EventAddTrack,
+					Msg:       entity.NewEventAddTrack("", "", 1),
 				})
 			},
 			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
 			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
-				m.EXPECT().FindByID(gomock.Any(), "sessionHadManyTracksID").Return(sessionHadManyTracks, nil)
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionHadManyTracksID").Return(sessionHadManyTracks, nil)
 				m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
 					URI:       "spotify:track:valid_uri",
 					SessionID: "sessionHadManyTracksID",
+					AddedAt:   fixedNow,
 				}).Return(nil)
 			},
 			wantErr:  false,
@@ -313,28 +538,408 @@ func TestSessionHandler_Enqueue(t *testing.T) {
 			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
 				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:valid_uri", "sessionDeviceID").Return(nil)
 			},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:valid_uri"}).Return(playableTracks("spotify:track:valid_uri"), nil)
+			},
+			prepareMockUserCliFn: getMeInJP,
 			prepareMockPusherFn: func(m *mock_event.MockPusher) {
 				m.EXPECT().Push(&event.PushMessage{
 					SessionID: "sessionID",
-					Msg:       entity.EventAddTrack,
+					Msg:       entity.NewEventAddTrack("", "", 1),
 				})
 			},
 			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
 			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
-				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(session, nil)
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(session, nil)
 				m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
 					URI:       "spotify:track:valid_uri",
 					SessionID: "sessionID",
+					AddedAt:   fixedNow,
+				}).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:                "ゲストがニックネームを指定して追加するとニックネームが記録される",
+			sessionID:           "sessionHadManyTracksID",
+			body:                `{"uri": "spotify:track:valid_uri", "nickname": "guest"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:valid_uri"}).Return(playableTracks("spotify:track:valid_uri"), nil)
+			},
+			prepareMockUserCliFn: getMeInJP,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionHadManyTracksID",
+					Msg:       entity.NewEventAddTrack("", "guest", 1),
+				})
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionHadManyTracksID").Return(sessionHadManyTracks, nil)
+				m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
+					URI:             "spotify:track:valid_uri",
+					SessionID:       "sessionHadManyTracksID",
+					AddedByNickname: "guest",
+					AddedAt:         fixedNow,
+				}).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:                "ログインしているユーザが追加するとユーザIDと表示名が記録される",
+			sessionID:           "sessionHadManyTracksID",
+			userID:              "userID",
+			body:                `{"uri": "spotify:track:valid_uri", "nickname": "ignored"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:valid_uri"}).Return(playableTracks("spotify:track:valid_uri"), nil)
+			},
+			prepareMockUserCliFn: getMeInJP,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionHadManyTracksID",
+					Msg:       entity.NewEventAddTrack("userID", "userDisplayName", 1),
+				})
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {
+				m.EXPECT().FindByID("userID").Return(&entity.User{
+					ID:            "userID",
+					SpotifyUserID: "userSpotifyUserID",
+					DisplayName:   "userDisplayName",
+				}, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionHadManyTracksID").Return(sessionHadManyTracks, nil)
+				m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
+					URI:             "spotify:track:valid_uri",
+					SessionID:       "sessionHadManyTracksID",
+					AddedByUserID:   "userID",
+					AddedByNickname: "userDisplayName",
+					AddedAt:         fixedNow,
+				}).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:                "未再生の曲と同じ曲の追加が拒否される設定のときに同じ曲を追加すると409",
+			sessionID:           "sessionRejectDuplicateID",
+			body:                `{"uri": "spotify:track:track_uri2"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:track_uri2"}).Return(playableTracks("spotify:track:track_uri2"), nil)
+			},
+			prepareMockUserCliFn:  getMeInJP,
+			prepareMockPusherFn:   func(m *mock_event.MockPusher) {},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionRejectDuplicateID").Return(sessionRejectDuplicate, nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusConflict,
+		},
+		{
+			name:                "一人が追加できる未再生の曲数の上限に達しているときは403",
+			sessionID:           "sessionLimitedID",
+			body:                `{"uri": "spotify:track:valid_uri", "nickname": "guest"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:valid_uri"}).Return(playableTracks("spotify:track:valid_uri"), nil)
+			},
+			prepareMockUserCliFn:  getMeInJP,
+			prepareMockPusherFn:   func(m *mock_event.MockPusher) {},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionLimitedID").Return(sessionLimitedPending, nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:                "一定時間内に追加できる曲数の上限に達しているときは429",
+			sessionID:           "sessionLimitedID",
+			body:                `{"uri": "spotify:track:valid_uri", "nickname": "guest"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:valid_uri"}).Return(playableTracks("spotify:track:valid_uri"), nil)
+			},
+			prepareMockUserCliFn:  getMeInJP,
+			prepareMockPusherFn:   func(m *mock_event.MockPusher) {},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionLimitedID").Return(sessionLimitedRate, nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:      "アルバムのuriが渡されると含まれる曲が全て追加され、Spotifyのキューに先読みされる曲はEnqueueを叩く",
+			sessionID: "sessionForBulkID",
+			body:      `{"uri": "spotify:album:album_uri", "nickname": "guest"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:album_track1", "sessionDeviceID").Return(nil)
+				m.EXPECT().Enqueue(gomock.Any(), "spotify:track:album_track2", "sessionDeviceID").Return(nil)
+			},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTrackURIsFromAlbum(gomock.Any(), "spotify:album:album_uri").
+					Return([]string{"spotify:track:album_track1", "spotify:track:album_track2", "spotify:track:album_track3"}, nil)
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:album_track1", "spotify:track:album_track2", "spotify:track:album_track3"}).Return(playableTracks("spotify:track:album_track1", "spotify:track:album_track2", "spotify:track:album_track3"), nil)
+			},
+			prepareMockUserCliFn: getMeInJP,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionForBulkID",
+					Msg:       entity.NewEventAddTrack("", "guest", 3),
+				})
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionForBulkID").Return(sessionForBulk, nil)
+				for _, uri := range []string{"spotify:track:album_track1", "spotify:track:album_track2", "spotify:track:album_track3"} {
+					m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
+						URI:             uri,
+						SessionID:       "sessionForBulkID",
+						AddedByNickname: "guest",
+						AddedAt:         fixedNow,
+					}).Return(nil)
+				}
+			},
+			wantErr:  false,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:                "プレイリストのuriが渡されると含まれる曲が全て追加される",
+			sessionID:           "sessionHadManyTracksID",
+			body:                `{"uri": "spotify:playlist:playlist_uri"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTrackURIsFromPlaylist(gomock.Any(), "spotify:playlist:playlist_uri").
+					Return([]string{"spotify:track:playlist_track1", "spotify:track:playlist_track2"}, nil)
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:playlist_track1", "spotify:track:playlist_track2"}).Return(playableTracks("spotify:track:playlist_track1", "spotify:track:playlist_track2"), nil)
+			},
+			prepareMockUserCliFn: getMeInJP,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionHadManyTracksID",
+					Msg:       entity.NewEventAddTrack("", "", 2),
+				})
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionHadManyTracksID").Return(sessionHadManyTracks, nil)
+				for _, uri := range []string{"spotify:track:playlist_track1", "spotify:track:playlist_track2"} {
+					m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
+						URI:       uri,
+						SessionID: "sessionHadManyTracksID",
+						AddedAt:   fixedNow,
+					}).Return(nil)
+				}
+			},
+			wantErr:  false,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:                "プレイリストに存在しない曲や再生できない曲が含まれる場合はそれらを除いて追加される",
+			sessionID:           "sessionHadManyTracksID",
+			body:                `{"uri": "spotify:playlist:playlist_uri"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTrackURIsFromPlaylist(gomock.Any(), "spotify:playlist:playlist_uri").
+					Return([]string{"spotify:track:playlist_track1", "spotify:track:not_found", "spotify:track:not_playable"}, nil)
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:playlist_track1", "spotify:track:not_found", "spotify:track:not_playable"}).
+					Return([]*entity.Track{
+						{URI: "spotify:track:playlist_track1", AvailableMarkets: []string{"JP"}},
+						nil,
+						{URI: "spotify:track:not_playable", AvailableMarkets: []string{"US"}},
+					}, nil)
+			},
+			prepareMockUserCliFn: getMeInJP,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionHadManyTracksID",
+					Msg:       entity.NewEventAddTrack("", "", 1),
+				})
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionHadManyTracksID").Return(sessionHadManyTracks, nil)
+				m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
+					URI:       "spotify:track:playlist_track1",
+					SessionID: "sessionHadManyTracksID",
+					AddedAt:   fixedNow,
+				}).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:                     "曲・アルバム・プレイリスト以外のuriの時400",
+			sessionID:                "sessionID",
+			body:                     `{"uri": "spotify:artist:artist_uri"}`,
+			prepareMockPlayerFn:      func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn:       func(m *mock_spotify.MockTrackClient) {},
+			prepareMockUserCliFn:     func(m *mock_spotify.MockUser) {},
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			prepareMockUserRepoFn:    func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			wantErr:                  true,
+			wantCode:                 http.StatusBadRequest,
+		},
+		{
+			name:                "Spotifyに存在しない曲の時404",
+			sessionID:           "sessionID",
+			body:                `{"uri": "spotify:track:not_found"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:not_found"}).Return([]*entity.Track{nil}, nil)
+			},
+			prepareMockUserCliFn:     getMeInJP,
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			prepareMockUserRepoFn:    func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			wantErr:                  true,
+			wantCode:                 http.StatusNotFound,
+		},
+		{
+			name:                "セッションの作成者の国で再生できない曲の時400",
+			sessionID:           "sessionID",
+			body:                `{"uri": "spotify:track:not_playable"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:not_playable"}).
+					Return([]*entity.Track{{URI: "spotify:track:not_playable", AvailableMarkets: []string{"US"}}}, nil)
+			},
+			prepareMockUserCliFn:     getMeInJP,
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			prepareMockUserRepoFn:    func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			wantErr:                  true,
+			wantCode:                 http.StatusBadRequest,
+		},
+		{
+			name:                     "uriが空の時400",
+			sessionID:                "sessionID",
+			body:                     `{"uri": ""}`,
+			prepareMockPlayerFn:      func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn:       func(m *mock_spotify.MockTrackClient) {},
+			prepareMockUserCliFn:     func(m *mock_spotify.MockUser) {},
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			prepareMockUserRepoFn:    func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			wantErr:                  true,
+			wantCode:                 http.StatusBadRequest,
+		},
+		{
+			name:      "作成者がpositionにnextを指定すると次に再生されるように追加され、Spotifyのキューが積み直される",
+			sessionID: "sessionForPlayNextID",
+			userID:    "sessionCreator",
+			body:      `{"uri": "spotify:track:valid_uri", "position": "next"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				gomock.InOrder(
+					m.EXPECT().DeleteAllTracksInQueue(gomock.Any(), "sessionDeviceID", "spotify:track:track_uri1").Return(nil),
+					m.EXPECT().PlayWithTracksAndPosition(gomock.Any(), "sessionDeviceID", []string{"spotify:track:track_uri1"}, 10*time.Second).Return(nil),
+					m.EXPECT().Enqueue(gomock.Any(), "spotify:track:valid_uri", "sessionDeviceID").Return(nil),
+					m.EXPECT().Enqueue(gomock.Any(), "spotify:track:track_uri2", "sessionDeviceID").Return(nil),
+					m.EXPECT().Pause(gomock.Any(), "sessionDeviceID").Return(nil),
+				)
+			},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:valid_uri"}).Return(playableTracks("spotify:track:valid_uri"), nil)
+			},
+			prepareMockUserCliFn: getMeInJP,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionForPlayNextID",
+					Msg:       entity.NewEventAddTrack("sessionCreator", "creator", 1),
+				})
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {
+				m.EXPECT().FindByID("sessionCreator").Return(&entity.User{ID: "sessionCreator", DisplayName: "creator"}, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				gomock.InOrder(
+					m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionForPlayNextID").Return(sessionForPlayNext(entity.QueueModeFIFO), nil),
+					m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionForPlayNextID").Return(sessionForPlayNext(entity.QueueModeFIFO, "spotify:track:valid_uri"), nil),
+				)
+				m.EXPECT().StoreQueueTrack(gomock.Any(), &entity.QueueTrackToStore{
+					URI:             "spotify:track:valid_uri",
+					SessionID:       "sessionForPlayNextID",
+					AddedByUserID:   "sessionCreator",
+					AddedByNickname: "creator",
+					AddedAt:         fixedNow,
+				}).Return(nil)
+				m.EXPECT().UpdateQueueTrackIndexes(gomock.Any(), []*entity.QueueTrack{
+					{ID: 4, Index: 1, URI: "spotify:track:valid_uri", SessionID: "sessionForPlayNextID", AddedByUserID: "sessionCreator", AddedByNickname: "creator"},
+					{ID: 2, Index: 2, URI: "spotify:track:track_uri2", SessionID: "sessionForPlayNextID"},
+					{ID: 3, Index: 3, URI: "spotify:track:track_uri3", SessionID: "sessionForPlayNextID"},
 				}).Return(nil)
 			},
 			wantErr:  false,
 			wantCode: http.StatusNoContent,
 		},
 		{
-			name:                     "uriが空の時400",
-			sessionID:                "sessionID",
-			body:                     `{"uri": ""}`,
+			name:                "他人による操作が許可されていないときに作成者以外がpositionにnextを指定すると400",
+			sessionID:           "sessionForPlayNextID",
+			body:                `{"uri": "spotify:track:valid_uri", "nickname": "guest", "position": "next"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:valid_uri"}).Return(playableTracks("spotify:track:valid_uri"), nil)
+			},
+			prepareMockUserCliFn:  getMeInJP,
+			prepareMockPusherFn:   func(m *mock_event.MockPusher) {},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionForPlayNextID").Return(sessionForPlayNext(entity.QueueModeFIFO), nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:                "投票モードのときにpositionにnextを指定すると400",
+			sessionID:           "sessionForPlayNextID",
+			userID:              "sessionCreator",
+			body:                `{"uri": "spotify:track:valid_uri", "position": "next"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:valid_uri"}).Return(playableTracks("spotify:track:valid_uri"), nil)
+			},
+			prepareMockUserCliFn: getMeInJP,
+			prepareMockPusherFn:  func(m *mock_event.MockPusher) {},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {
+				m.EXPECT().FindByID("sessionCreator").Return(&entity.User{ID: "sessionCreator", DisplayName: "creator"}, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				gomock.InOrder(
+					m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionForPlayNextID").Return(sessionForPlayNext(entity.QueueModeVote), nil),
+					m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionForPlayNextID").Return(sessionForPlayNext(entity.QueueModeVote, "spotify:track:valid_uri"), nil),
+				)
+				m.EXPECT().StoreQueueTrack(gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:                     "不正なpositionを指定すると400",
+			sessionID:                "sessionForPlayNextID",
+			body:                     `{"uri": "spotify:track:valid_uri", "position": "first"}`,
 			prepareMockPlayerFn:      func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn:       func(m *mock_spotify.MockTrackClient) {},
+			prepareMockUserCliFn:     func(m *mock_spotify.MockUser) {},
 			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
 			prepareMockUserRepoFn:    func(m *mock_repository.MockUser) {},
 			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
@@ -342,14 +947,19 @@ func TestSessionHandler_Enqueue(t *testing.T) {
 			wantCode:                 http.StatusBadRequest,
 		},
 		{
-			name:                  "存在しないsessionIDの時404",
-			sessionID:             "invalidSessionID",
-			body:                  `{"uri": "valid_uri"}`,
-			prepareMockPlayerFn:   func(m *mock_spotify.MockPlayer) {},
+			name:                "存在しないsessionIDの時404",
+			sessionID:           "invalidSessionID",
+			body:                `{"uri": "spotify:track:valid_uri"}`,
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {},
+			prepareMockTrackFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:valid_uri"}).Return(playableTracks("spotify:track:valid_uri"), nil)
+			},
+			prepareMockUserCliFn:  getMeInJP,
 			prepareMockPusherFn:   func(m *mock_event.MockPusher) {},
 			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {},
 			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
-				m.EXPECT().FindByID(gomock.Any(), "invalidSessionID").Return(nil, entity.ErrSessionNotFound)
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "invalidSessionID").Return(nil, entity.ErrSessionNotFound)
 			},
 			wantErr:  true,
 			wantCode: http.StatusNotFound,
@@ -366,11 +976,12 @@ func TestSessionHandler_Enqueue(t *testing.T) {
 			c.SetPath("/sessions/:id/queue")
 			c.SetParamNames("id")
 			c.SetParamValues(tt.sessionID)
+			c = setToContext(c, tt.userID, nil)
 
 			// モックの準備
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
-			h := newSessionStateHandlerForTest(t, ctrl, tt.prepareMockPlayerFn, tt.prepareMockPusherFn, tt.prepareMockUserRepoFn, tt.prepareMockSessionRepoFn)
+			h := newSessionHandlerForTest(t, ctrl, tt.prepareMockPlayerFn, tt.prepareMockTrackFn, tt.prepareMockUserCliFn, tt.prepareMockPusherFn, tt.prepareMockUserRepoFn, tt.prepareMockSessionRepoFn, "")
 
 			err := h.Enqueue(c)
 			if (err != nil) != tt.wantErr {
@@ -395,9 +1006,11 @@ func TestSessionHandler_GetSession(t *testing.T) {
 		QueueHead: 0,
 		QueueTracks: []*entity.QueueTrack{
 			{
-				Index:     0,
-				URI:       "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
-				SessionID: "sessionID",
+				Index:           0,
+				URI:             "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
+				SessionID:       "sessionID",
+				AddedByUserID:   "creatorID",
+				AddedByNickname: "creatorDisplayName",
 			},
 		},
 	}
@@ -473,6 +1086,10 @@ func TestSessionHandler_GetSession(t *testing.T) {
 				Name:   "Interstate 46 E.P.",
 				Images: albumImageJSONs,
 			},
+			AddedBy: &addedByJSON{
+				UserID:   "creatorID",
+				Nickname: "creatorDisplayName",
+			},
 		},
 	}
 
@@ -532,6 +1149,45 @@ func TestSessionHandler_GetSession(t *testing.T) {
 			wantErr:  false,
 			wantCode: http.StatusOK,
 		},
+		{
+			name:      "Spotifyから曲の情報が取得できなかった曲はuri以外が空で返る",
+			sessionID: "sessionID",
+			prepareMockPlayerFn: func(m *mock_spotify.MockPlayer) {
+				m.EXPECT().CurrentlyPlaying(gomock.Any()).Return(cpi, nil)
+			},
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockTrackCliFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:06QTSGUEgcmKwiEJ0IMPig"}).Return([]*entity.Track{nil}, nil)
+			},
+			prepareMockUserRepoFn: func(m *mock_repository.MockUser) {
+				m.EXPECT().FindByID("creatorID").Return(user, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(session, nil)
+			},
+			want: &sessionRes{
+				ID:       "sessionID",
+				Name:     "sessionName",
+				Creator:  sessionResponse.Creator,
+				Playback: sessionResponse.Playback,
+				Queue: queueJSON{
+					Head: 0,
+					Tracks: []*trackJSON{
+						{
+							URI:     "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
+							Artists: []*artistJSON{},
+							Album:   &albumJSON{Images: []*albumImageJSON{}},
+							AddedBy: &addedByJSON{
+								UserID:   "creatorID",
+								Nickname: "creatorDisplayName",
+							},
+						},
+					},
+				},
+			},
+			wantErr:  false,
+			wantCode: http.StatusOK,
+		},
 		{
 			name:      "存在しないsessionIDを渡した時404",
 			sessionID: "non_exist_sessionID",
@@ -572,9 +1228,11 @@ func TestSessionHandler_GetSession(t *testing.T) {
 					QueueHead: 0,
 					QueueTracks: []*entity.QueueTrack{
 						{
-							Index:     0,
-							URI:       "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
-							SessionID: "sessionID",
+							Index:           0,
+							URI:             "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
+							SessionID:       "sessionID",
+							AddedByUserID:   "creatorID",
+							AddedByNickname: "creatorDisplayName",
 						},
 					},
 				}, nil)
@@ -651,9 +1309,11 @@ func TestSessionHandler_GetSession(t *testing.T) {
 					QueueHead: 0,
 					QueueTracks: []*entity.QueueTrack{
 						{
-							Index:     0,
-							URI:       "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
-							SessionID: "sessionID",
+							Index:           0,
+							URI:             "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
+							SessionID:       "sessionID",
+							AddedByUserID:   "creatorID",
+							AddedByNickname: "creatorDisplayName",
 						},
 					},
 				}, nil)
@@ -666,9 +1326,11 @@ func TestSessionHandler_GetSession(t *testing.T) {
 					QueueHead: 0,
 					QueueTracks: []*entity.QueueTrack{
 						{
-							Index:     0,
-							URI:       "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
-							SessionID: "sessionID",
+							Index:           0,
+							URI:             "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
+							SessionID:       "sessionID",
+							AddedByUserID:   "creatorID",
+							AddedByNickname: "creatorDisplayName",
 						},
 					},
 				}).Return(nil)
@@ -715,7 +1377,7 @@ func TestSessionHandler_GetSession(t *testing.T) {
 			// モックの準備
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
-			h := newSessionHandlerForTest(t, ctrl, tt.prepareMockPlayerFn, tt.prepareMockTrackCliFn, tt.prepareMockPusherFn, tt.prepareMockUserRepoFn, tt.prepareMockSessionRepoFn, tt.addToTimerSessionID)
+			h := newSessionHandlerForTest(t, ctrl, tt.prepareMockPlayerFn, tt.prepareMockTrackCliFn, func(m *mock_spotify.MockUser) {}, tt.prepareMockPusherFn, tt.prepareMockUserRepoFn, tt.prepareMockSessionRepoFn, tt.addToTimerSessionID)
 			err := h.GetSession(c)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("GetSession() error = %v, wantErr %v", err, tt.wantErr)
@@ -744,6 +1406,172 @@ func TestSessionHandler_GetSession(t *testing.T) {
 	}
 }
 
+func TestSessionHandler_GetHistory(t *testing.T) {
+	startedAt := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+	playedTracks := []*entity.PlayedTrack{
+		{
+			ID:        1,
+			SessionID: "sessionID",
+			URI:       "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
+			StartedAt: startedAt,
+			EndedAt:   startedAt.Add(3 * time.Minute),
+			EndReason: entity.TrackEndReasonFinished,
+		},
+	}
+	tracks := []*entity.Track{
+		{
+			URI:      "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
+			ID:       "06QTSGUEgcmKwiEJ0IMPig",
+			Name:     "Borderland",
+			Duration: 213066000000,
+			Artists:  []*entity.Artist{{Name: "MONOEYES"}},
+			URL:      "https://open.spotify.com/track/06QTSGUEgcmKwiEJ0IMPig",
+			Album: &entity.Album{
+				Name:   "Interstate 46 E.P.",
+				Images: []*entity.AlbumImage{},
+			},
+		},
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		prepareMockTrackCliFn    func(m *mock_spotify.MockTrackClient)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		want                     *historyRes
+		wantErr                  bool
+		wantCode                 int
+	}{
+		{
+			name:                  "存在しないセッションのとき404",
+			sessionID:             "notFoundSessionID",
+			prepareMockTrackCliFn: func(m *mock_spotify.MockTrackClient) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "notFoundSessionID").Return(nil, entity.ErrSessionNotFound)
+			},
+			wantErr:  true,
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:      "まだ曲が再生されていないときは空の履歴が返る",
+			sessionID: "sessionID",
+			prepareMockTrackCliFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{}).Return(nil, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(&entity.Session{ID: "sessionID"}, nil)
+				m.EXPECT().FindPlayedTracksBySessionID(gomock.Any(), "sessionID").Return([]*entity.PlayedTrack{}, nil)
+			},
+			want: &historyRes{
+				Tracks: []*playedTrackJSON{},
+			},
+			wantErr:  false,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "再生された曲が再生された日時と終了した理由付きで返る",
+			sessionID: "sessionID",
+			prepareMockTrackCliFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:06QTSGUEgcmKwiEJ0IMPig"}).Return(tracks, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(&entity.Session{ID: "sessionID"}, nil)
+				m.EXPECT().FindPlayedTracksBySessionID(gomock.Any(), "sessionID").Return(playedTracks, nil)
+			},
+			want: &historyRes{
+				Tracks: []*playedTrackJSON{
+					{
+						trackJSON: trackJSON{
+							URI:      "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
+							ID:       "06QTSGUEgcmKwiEJ0IMPig",
+							Name:     "Borderland",
+							Duration: 213066,
+							Artists:  []*artistJSON{{Name: "MONOEYES"}},
+							URL:      "https://open.spotify.com/track/06QTSGUEgcmKwiEJ0IMPig",
+							Album: &albumJSON{
+								Name:   "Interstate 46 E.P.",
+								Images: []*albumImageJSON{},
+							},
+						},
+						StartedAt: startedAt,
+						EndedAt:   startedAt.Add(3 * time.Minute),
+						EndReason: "FINISHED",
+					},
+				},
+			},
+			wantErr:  false,
+			wantCode: http.StatusOK,
+		},
+		{
+			name:      "Spotifyから曲の情報が取得できなかった曲はuri以外が空で返る",
+			sessionID: "sessionID",
+			prepareMockTrackCliFn: func(m *mock_spotify.MockTrackClient) {
+				m.EXPECT().GetTracksFromURI(gomock.Any(), []string{"spotify:track:06QTSGUEgcmKwiEJ0IMPig"}).Return([]*entity.Track{nil}, nil)
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().FindByID(gomock.Any(), "sessionID").Return(&entity.Session{ID: "sessionID"}, nil)
+				m.EXPECT().FindPlayedTracksBySessionID(gomock.Any(), "sessionID").Return(playedTracks, nil)
+			},
+			want: &historyRes{
+				Tracks: []*playedTrackJSON{
+					{
+						trackJSON: trackJSON{
+							URI:     "spotify:track:06QTSGUEgcmKwiEJ0IMPig",
+							Artists: []*artistJSON{},
+							Album: &albumJSON{
+								Images: []*albumImageJSON{},
+							},
+						},
+						StartedAt: startedAt,
+						EndedAt:   startedAt.Add(3 * time.Minute),
+						EndReason: "FINISHED",
+					},
+				},
+			},
+			wantErr:  false,
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// httptestの準備
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/sessions/:id/history")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.sessionID)
+
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			h := newSessionHandlerForTest(t, ctrl, func(m *mock_spotify.MockPlayer) {}, tt.prepareMockTrackCliFn, func(m *mock_spotify.MockUser) {}, func(m *mock_event.MockPusher) {}, func(m *mock_repository.MockUser) {}, tt.prepareMockSessionRepoFn, "")
+
+			err := h.GetHistory(c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetHistory() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			// ステータスコードのチェック
+			if er, ok := err.(*echo.HTTPError); (ok && er.Code != tt.wantCode) || (!ok && rec.Code != tt.wantCode) {
+				t.Errorf("GetHistory() code = %d, want = %d", rec.Code, tt.wantCode)
+				return
+			}
+			if !tt.wantErr {
+				got := &historyRes{}
+				if err := json.Unmarshal(rec.Body.Bytes(), got); err != nil {
+					t.Fatal(err)
+				}
+				opt := cmp.AllowUnexported(playedTrackJSON{})
+				if !cmp.Equal(got, tt.want, opt) {
+					t.Errorf("GetHistory() diff = %v", cmp.Diff(got, tt.want, opt))
+				}
+			}
+		})
+	}
+}
+
 func TestUserHandler_GetActiveDevices(t *testing.T) {
 	t.Parallel()
 
@@ -983,7 +1811,7 @@ func TestSessionHandler_NextTrack(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			h := newSessionHandlerForTest(t, ctrl, tt.prepareMockPlayerCliFn, tt.prepareMockTrackCliFn,
+			h := newSessionHandlerForTest(t, ctrl, tt.prepareMockPlayerCliFn, tt.prepareMockTrackCliFn, func(m *mock_spotify.MockUser) {},
 				tt.prepareMockPusherFn, tt.prepareMockUserRepoFn, tt.prepareMockSessionRepoFn, tt.addToTimerSessionID)
 
 			err := h.NextTrack(c)
@@ -1009,6 +1837,7 @@ func newSessionHandlerForTest(
 	ctrl *gomock.Controller,
 	prepareMockPlayerFn func(m *mock_spotify.MockPlayer),
 	prepareMockTrackFun func(m *mock_spotify.MockTrackClient),
+	prepareMockUserCliFn func(m *mock_spotify.MockUser),
 	prepareMockPusherFn func(m *mock_event.MockPusher),
 	prepareMockUserRepoFn func(m *mock_repository.MockUser),
 	prepareMockSessionRepoFn func(m *mock_repository.MockSession),
@@ -1019,19 +1848,173 @@ func newSessionHandlerForTest(
 	prepareMockPlayerFn(mockPlayer)
 	mockTrackCli := mock_spotify.NewMockTrackClient(ctrl)
 	prepareMockTrackFun(mockTrackCli)
+	mockUserCli := mock_spotify.NewMockUser(ctrl)
+	prepareMockUserCliFn(mockUserCli)
 	mockPusher := mock_event.NewMockPusher(ctrl)
 	prepareMockPusherFn(mockPusher)
 	mockUserRepo := mock_repository.NewMockUser(ctrl)
 	prepareMockUserRepoFn(mockUserRepo)
 	mockSessionRepo := mock_repository.NewMockSession(ctrl)
 	prepareMockSessionRepoFn(mockSessionRepo)
-	syncCheckTimerManager := entity.NewSyncCheckTimerManager()
+	clock := entity.NewFakeClock(fixedNow)
+	playbackScheduler := entity.NewPlaybackScheduler(1, clock)
 	if sessionID != "" {
-		timer := syncCheckTimerManager.CreateExpiredTimer(sessionID)
-		timer.SetDuration(5 * time.Minute)
+		playbackScheduler.Add(sessionID, entity.PlaybackJobTrackEnd, fixedNow.Add(5*time.Minute))
+	}
+	timerUC := usecase.NewSessionTimerUseCase(mockSessionRepo, mockPlayer, mockTrackCli, mockPusher, playbackScheduler, nil, clock)
+	uc := usecase.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockPlayer, mockTrackCli, mockUserCli, mockPusher, timerUC)
+	stateUC := usecase.NewSessionStateUseCase(mockSessionRepo, mockPlayer, nil, mockPusher, timerUC)
+	return &SessionHandler{uc: uc, stateUC: stateUC, clock: clock}
+}
+
+func TestSessionHandler_ShuffleQueueTracks(t *testing.T) {
+	t.Parallel()
+
+	stopSession := func(mode entity.QueueMode, allowToControlByOthers bool) *entity.Session {
+		return &entity.Session{
+			ID:        "sessionID",
+			Name:      "name",
+			CreatorID: "creatorID",
+			DeviceID:  "deviceID",
+			StateType: entity.Stop,
+			QueueHead: 1,
+			QueueTracks: []*entity.QueueTrack{
+				{ID: 1, Index: 0, URI: "spotify:track:track_uri1", SessionID: "sessionID"},
+				{ID: 2, Index: 1, URI: "spotify:track:track_uri2", SessionID: "sessionID"},
+				{ID: 3, Index: 2, URI: "spotify:track:track_uri3", SessionID: "sessionID"},
+				{ID: 4, Index: 3, URI: "spotify:track:track_uri4", SessionID: "sessionID"},
+			},
+			AllowToControlByOthers: allowToControlByOthers,
+			QueueMode:              mode,
+		}
+	}
+
+	tests := []struct {
+		name                     string
+		sessionID                string
+		userID                   string
+		body                     string
+		prepareMockPusherFn      func(m *mock_event.MockPusher)
+		prepareMockSessionRepoFn func(m *mock_repository.MockSession)
+		wantErr                  bool
+		wantCode                 int
+	}{
+		{
+			name:      "未再生の曲を並び替えて保存し、QUEUE_CHANGEDイベントを送って204",
+			sessionID: "sessionID",
+			userID:    "userID",
+			body:      `{"seed": 1}`,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.EventQueueChanged,
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(stopSession(entity.QueueModeFIFO, true), nil)
+				m.EXPECT().UpdateQueueTrackIndexes(gomock.Any(), gomock.Len(3)).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:      "seedを省略しても並び替えられて204",
+			sessionID: "sessionID",
+			userID:    "userID",
+			body:      `{}`,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {
+				m.EXPECT().Push(&event.PushMessage{
+					SessionID: "sessionID",
+					Msg:       entity.EventQueueChanged,
+				})
+			},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(stopSession(entity.QueueModeFIFO, true), nil)
+				m.EXPECT().UpdateQueueTrackIndexes(gomock.Any(), gomock.Len(3)).Return(nil)
+			},
+			wantErr:  false,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:                     "seedが不正なときは400",
+			sessionID:                "sessionID",
+			userID:                   "userID",
+			body:                     `{"seed": "seed"}`,
+			prepareMockPusherFn:      func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {},
+			wantErr:                  true,
+			wantCode:                 http.StatusBadRequest,
+		},
+		{
+			name:                "投票モードのときは400",
+			sessionID:           "sessionID",
+			userID:              "userID",
+			body:                `{"seed": 1}`,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(stopSession(entity.QueueModeVote, true), nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:                "作成者以外による操作が許可されていないときは400",
+			sessionID:           "sessionID",
+			userID:              "userID",
+			body:                `{"seed": 1}`,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "sessionID").Return(stopSession(entity.QueueModeFIFO, false), nil)
+			},
+			wantErr:  true,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:                "セッションが存在しないときは404",
+			sessionID:           "notFoundSessionID",
+			userID:              "userID",
+			body:                `{"seed": 1}`,
+			prepareMockPusherFn: func(m *mock_event.MockPusher) {},
+			prepareMockSessionRepoFn: func(m *mock_repository.MockSession) {
+				m.EXPECT().DoInTx(gomock.Any(), gomock.Any()).DoAndReturn(doInTx)
+				m.EXPECT().FindByIDForUpdate(gomock.Any(), "notFoundSessionID").Return(nil, entity.ErrSessionNotFound)
+			},
+			wantErr:  true,
+			wantCode: http.StatusNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// httptestの準備
+			e := echo.New()
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetPath("/sessions/:id/shuffle")
+			c.SetParamNames("id")
+			c.SetParamValues(tt.sessionID)
+			c = setToContext(c, tt.userID, nil)
+
+			// モックの準備
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			h := newSessionStateHandlerForTest(t, ctrl, func(m *mock_spotify.MockPlayer) {}, tt.prepareMockPusherFn,
+				func(m *mock_repository.MockUser) {}, tt.prepareMockSessionRepoFn)
+
+			err := h.ShuffleQueueTracks(c)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ShuffleQueueTracks() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			// ステータスコードのチェック
+			if er, ok := err.(*echo.HTTPError); (ok && er.Code != tt.wantCode) || (!ok && rec.Code != tt.wantCode) {
+				t.Errorf("ShuffleQueueTracks() code = %d, want = %d", rec.Code, tt.wantCode)
+			}
+		})
 	}
-	timerUC := usecase.NewSessionTimerUseCase(mockSessionRepo, mockPlayer, mockPusher, syncCheckTimerManager)
-	uc := usecase.NewSessionUseCase(mockSessionRepo, mockUserRepo, mockPlayer, mockTrackCli, nil, mockPusher, timerUC)
-	stateUC := usecase.NewSessionStateUseCase(mockSessionRepo, mockPlayer, mockPusher, timerUC)
-	return &SessionHandler{uc: uc, stateUC: stateUC}
 }
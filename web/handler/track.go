@@ -39,10 +39,20 @@ func (h *TrackHandler) SearchTracks(c echo.Context) error {
 	})
 }
 
+// toTrackJSON は曲の情報をレスポンスの形式に変換します。
+// Spotifyから削除された曲など情報が取得できなかった曲 (nil) は、uri以外が空の曲として返します。
+// uriは呼び出し側で補う必要があります。
 func toTrackJSON(tracks []*entity.Track) []*trackJSON {
 	trackJSONs := make([]*trackJSON, len(tracks))
 
 	for i, track := range tracks {
+		if track == nil {
+			trackJSONs[i] = &trackJSON{
+				Artists: []*artistJSON{},
+				Album:   &albumJSON{Images: []*albumImageJSON{}},
+			}
+			continue
+		}
 		trackJSONs[i] = &trackJSON{
 			URI:      track.URI,
 			ID:       track.ID,
@@ -50,16 +60,23 @@ func toTrackJSON(tracks []*entity.Track) []*trackJSON {
 			Duration: track.Duration.Milliseconds(),
 			Artists:  toArtistJSON(track),
 			URL:      track.URL,
-			Album: &albumJSON{
-				Name:   track.Album.Name,
-				Images: toAlbumImageJSON(track),
-			},
+			Album:    toAlbumJSON(track),
 		}
 	}
 
 	return trackJSONs
 }
 
+func toAlbumJSON(track *entity.Track) *albumJSON {
+	if track.Album == nil {
+		return &albumJSON{Images: []*albumImageJSON{}}
+	}
+	return &albumJSON{
+		Name:   track.Album.Name,
+		Images: toAlbumImageJSON(track),
+	}
+}
+
 func toArtistJSON(track *entity.Track) []*artistJSON {
 	artistJSONs := make([]*artistJSON, len(track.Artists))
 	for i, artist := range track.Artists {
@@ -89,13 +106,21 @@ type tracksRes struct {
 }
 
 type trackJSON struct {
-	URI      string        `json:"uri"`
-	ID       string        `json:"id"`
-	Name     string        `json:"name"`
-	Duration int64         `json:"duration_ms"`
-	Artists  []*artistJSON `json:"artists"`
-	URL      string        `json:"external_url"`
-	Album    *albumJSON    `json:"album"`
+	URI           string        `json:"uri"`
+	ID            string        `json:"id"`
+	Name          string        `json:"name"`
+	Duration      int64         `json:"duration_ms"`
+	Artists       []*artistJSON `json:"artists"`
+	URL           string        `json:"external_url"`
+	Album         *albumJSON    `json:"album"`
+	AddedBy       *addedByJSON  `json:"added_by,omitempty"`
+	AddedBySystem bool          `json:"added_by_system,omitempty"`
+	Score         *int          `json:"score,omitempty"`
+}
+
+type addedByJSON struct {
+	UserID   string `json:"user_id"`
+	Nickname string `json:"nickname"`
 }
 
 type albumJSON struct {
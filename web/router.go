@@ -2,6 +2,7 @@ package web
 
 import (
 	"github.com/camphor-/relaym-server/config"
+	"github.com/camphor-/relaym-server/domain/entity"
 	"github.com/camphor-/relaym-server/usecase"
 	"github.com/camphor-/relaym-server/web/handler"
 	"github.com/camphor-/relaym-server/web/ws"
@@ -11,7 +12,7 @@ import (
 )
 
 // NewServer はミドルウェアやハンドラーが登録されたechoの構造体を返します。
-func NewServer(authUC *usecase.AuthUseCase, userUC *usecase.UserUseCase, sessionUC *usecase.SessionUseCase, sessionStateUC *usecase.SessionStateUseCase, trackUC *usecase.TrackUseCase, batchUC *usecase.BatchUseCase, hub *ws.Hub) *echo.Echo {
+func NewServer(authUC *usecase.AuthUseCase, userUC *usecase.UserUseCase, sessionUC *usecase.SessionUseCase, sessionStateUC *usecase.SessionStateUseCase, trackUC *usecase.TrackUseCase, batchUC *usecase.BatchUseCase, hub *ws.Hub, clock entity.Clock) *echo.Echo {
 	e := echo.New()
 
 	e.Use(middleware.Logger())
@@ -44,7 +45,7 @@ func NewServer(authUC *usecase.AuthUseCase, userUC *usecase.UserUseCase, session
 
 	userHandler := handler.NewUserHandler(userUC)
 	trackHandler := handler.NewTrackHandler(trackUC)
-	sessionHandler := handler.NewSessionHandler(sessionUC, sessionStateUC)
+	sessionHandler := handler.NewSessionHandler(sessionUC, sessionStateUC, clock)
 	authHandler := handler.NewAuthHandler(authUC, config.FrontendURL())
 	wsHandler := handler.NewWebSocketHandler(hub, sessionUC)
 	batchHandler := handler.NewBatchHandler(batchUC)
@@ -63,15 +64,26 @@ func NewServer(authUC *usecase.AuthUseCase, userUC *usecase.UserUseCase, session
 
 	authedSession := authed.Group("/sessions")
 	authedSession.POST("", sessionHandler.PostSession)
+	authedSession.PUT("/:id/queue/:index/vote", sessionHandler.VoteQueueTrack)
 
 	sessionWithCreatorToken := v3.Group("/sessions/:id", NewCreatorTokenMiddleware(authUC).SetCreatorTokenToContext)
 	sessionWithCreatorToken.GET("", sessionHandler.GetSession)
+	sessionWithCreatorToken.PATCH("", sessionHandler.PatchSession)
 	sessionWithCreatorToken.GET("/search", trackHandler.SearchTracks)
 	sessionWithCreatorToken.GET("/devices", sessionHandler.GetActiveDevices)
 	sessionWithCreatorToken.PUT("/devices", sessionHandler.SetDevice)
 	sessionWithCreatorToken.POST("/queue", sessionHandler.Enqueue)
+	sessionWithCreatorToken.PUT("/queue/move", sessionHandler.MoveQueueTrack)
+	sessionWithCreatorToken.DELETE("/queue/:index", sessionHandler.RemoveQueueTrack)
+	sessionWithCreatorToken.PUT("/shuffle", sessionHandler.ShuffleQueueTracks)
 	sessionWithCreatorToken.PUT("/state", sessionHandler.State)
 	sessionWithCreatorToken.PUT("/next", sessionHandler.NextTrack)
+	sessionWithCreatorToken.PUT("/prev", sessionHandler.PrevTrack)
+	sessionWithCreatorToken.PUT("/head", sessionHandler.JumpToTrack)
+	sessionWithCreatorToken.PUT("/seek", sessionHandler.Seek)
+	sessionWithCreatorToken.GET("/volume", sessionHandler.GetVolume)
+	sessionWithCreatorToken.PUT("/volume", sessionHandler.SetVolume)
+	sessionWithCreatorToken.GET("/history", sessionHandler.GetHistory)
 	sessionWithCreatorToken.GET("/ws", wsHandler.WebSocket)
 	return e
 }